@@ -0,0 +1,245 @@
+package analyser
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"strings"
+	iHtml "web-analyser/internal/utils/html"
+)
+
+// names of the rules checked by the a11y inspector, in the order of the checks of the report
+const (
+	a11yImageAlt     = "image_alt"
+	a11yFormLabels   = "form_labels"
+	a11yHtmlLang     = "html_lang"
+	a11yLinkNames    = "link_names"
+	a11yButtonNames  = "button_names"
+	a11yDuplicateIDs = "duplicate_ids"
+	a11yHeadingOrder = "heading_order"
+	a11yTableHeaders = "table_headers"
+)
+
+// a11yRules are the rules checked by the a11y inspector along with the severity of their issues and the outcome of
+// their check when the page has such issues
+var a11yRules = []*struct {
+	name     string
+	severity Severity
+	status   CheckStatus
+	passed   string
+	failed   string
+}{
+	{a11yImageAlt, SeverityError, CheckFail, "every image has an alt text", "images without an alt text: %d"},
+	{a11yFormLabels, SeverityError, CheckFail, "every form field has a label", "form fields without a label: %d"},
+	{a11yHtmlLang, SeverityError, CheckFail, "the language of the page is set", "the language of the page is not set"},
+	{a11yLinkNames, SeverityError, CheckFail, "every link has a name", "links without a name: %d"},
+	{a11yButtonNames, SeverityError, CheckFail, "every button has a name", "buttons without a name: %d"},
+	{a11yDuplicateIDs, SeverityError, CheckFail, "the ids are unique", "elements with a duplicate id: %d"},
+	{a11yHeadingOrder, SeverityWarning, CheckWarn, "the heading levels are not skipped", "headings skipping a level: %d"},
+	{a11yTableHeaders, SeverityError, CheckFail, "every table has headers", "tables without headers: %d"},
+}
+
+// a11yIssue represents an element failing a rule of the a11y inspector
+type a11yIssue struct {
+	rule     string
+	message  string
+	selector string
+}
+
+// a11yField represents a form field which is not labelled by its attributes nor by an enclosing label, it is labelled
+// if a label of the page refers to its id
+type a11yField struct {
+	id       string
+	selector string
+}
+
+// a11yInspector checks the page against the accessibility rules which can be checked on the HTML alone, each issue is
+// reported along with the selector path of the offending element
+type a11yInspector struct{}
+
+func (i *a11yInspector) Name() string { return InspectorA11y }
+
+func (i *a11yInspector) Inspect(page *Page) Inspection {
+	return &a11yInspection{ids: map[string]int{}, labelled: map[string]struct{}{}}
+}
+
+type a11yInspection struct {
+	issues    []*a11yIssue
+	ids       map[string]int
+	labelled  map[string]struct{}
+	fields    []*a11yField
+	lastLevel int
+}
+
+func (i *a11yInspection) Visit(n *html.Node) {
+	// the elements of the embedded svg and math documents follow their own accessibility rules
+	if n.Type != html.ElementNode || n.Namespace != "" {
+		return
+	}
+
+	// the first element with the id is not reported, only the elements reusing it
+	if id, _ := iHtml.Attr(n, "id"); id != "" {
+		if i.ids[id]++; i.ids[id] > 1 {
+			i.addIssue(a11yDuplicateIDs, fmt.Sprintf("the id %q is already used by another element", id), n)
+		}
+	}
+
+	switch n.Data {
+	case "html":
+		if lang, _ := iHtml.Attr(n, "lang"); strings.TrimSpace(lang) == "" {
+			i.addIssue(a11yHtmlLang, "the html element has no lang attribute", n)
+		}
+	case "img":
+		if _, ok := iHtml.Attr(n, "alt"); !ok && !isPresentational(n) && !hasAriaName(n) {
+			i.addIssue(a11yImageAlt, "the image has no alt attribute", n)
+		}
+	case "input":
+		i.visitInput(n)
+	case "select", "textarea":
+		i.visitField(n)
+	case "label":
+		if id, _ := iHtml.Attr(n, "for"); id != "" {
+			i.labelled[id] = struct{}{}
+		}
+	case "a":
+		if _, ok := iHtml.Attr(n, "href"); ok && !hasAccessibleName(n) {
+			i.addIssue(a11yLinkNames, "the link has no text nor label", n)
+		}
+	case "button":
+		if !hasAccessibleName(n) {
+			i.addIssue(a11yButtonNames, "the button has no text nor label", n)
+		}
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		level := int(n.Data[1] - '0')
+		if i.lastLevel > 0 && level > i.lastLevel+1 {
+			i.addIssue(a11yHeadingOrder, fmt.Sprintf("the h%d heading follows an h%d heading", level, i.lastLevel), n)
+		}
+		i.lastLevel = level
+	case "table":
+		if !isPresentational(n) && !hasTableHeaders(n) {
+			i.addIssue(a11yTableHeaders, "the table has no th header cells", n)
+		}
+	}
+}
+
+// visitInput checks the input as an image, a button or a form field as per its type
+func (i *a11yInspection) visitInput(n *html.Node) {
+	inputType, _ := iHtml.Attr(n, "type")
+	switch strings.ToLower(inputType) {
+	case "hidden", "submit", "reset":
+		// hidden inputs are not rendered, submit and reset buttons are named by the browser if they have no value
+	case "image":
+		if alt, _ := iHtml.Attr(n, "alt"); strings.TrimSpace(alt) == "" && !hasAriaName(n) {
+			i.addIssue(a11yImageAlt, "the image button has no alt attribute", n)
+		}
+	case "button":
+		if value, _ := iHtml.Attr(n, "value"); strings.TrimSpace(value) == "" && !hasAriaName(n) {
+			i.addIssue(a11yButtonNames, "the button has no value nor label", n)
+		}
+	default:
+		i.visitField(n)
+	}
+}
+
+// visitField checks whether the form field is labelled by its attributes or an enclosing label, otherwise it is kept
+// to be checked against the labels of the page once every node is visited
+func (i *a11yInspection) visitField(n *html.Node) {
+	if hasAriaName(n) {
+		return
+	}
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && p.Data == "label" {
+			return
+		}
+	}
+	id, _ := iHtml.Attr(n, "id")
+	i.fields = append(i.fields, &a11yField{id: id, selector: iHtml.Selector(n)})
+}
+
+// addIssue adds the issue of the element failing the rule
+func (i *a11yInspection) addIssue(rule string, message string, n *html.Node) {
+	i.issues = append(i.issues, &a11yIssue{rule: rule, message: message, selector: iHtml.Selector(n)})
+}
+
+func (i *a11yInspection) Finish(summary *Summary) {
+	// the labels may come after their fields, so the fields are checked once every label is known
+	for _, field := range i.fields {
+		if _, ok := i.labelled[field.id]; !ok {
+			i.issues = append(i.issues, &a11yIssue{rule: a11yFormLabels, message: "the form field has no label",
+				selector: field.selector})
+		}
+	}
+
+	report := NewReport(InspectorA11y)
+	counts := make(map[string]int, len(a11yRules))
+	for _, issue := range i.issues {
+		counts[issue.rule]++
+	}
+	for _, rule := range a11yRules {
+		if counts[rule.name] == 0 {
+			report.AddCheck(rule.name, CheckPass, rule.passed)
+			continue
+		}
+		message := rule.failed
+		if strings.Contains(message, "%d") {
+			message = fmt.Sprintf(message, counts[rule.name])
+		}
+		report.AddCheck(rule.name, rule.status, message)
+		for _, issue := range i.issues {
+			if issue.rule == rule.name {
+				report.AddElementFinding(rule.severity, issue.message, issue.selector)
+			}
+		}
+	}
+	report.SetValue("issues", len(i.issues))
+	summary.AddReport(report)
+}
+
+// hasAriaName returns whether the element is named by its aria-label, aria-labelledby or title attributes
+func hasAriaName(n *html.Node) bool {
+	for _, key := range []string{"aria-label", "aria-labelledby", "title"} {
+		if value, _ := iHtml.Attr(n, key); strings.TrimSpace(value) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAccessibleName returns whether the element is named by its attributes, its text or the alt text of its images
+func hasAccessibleName(n *html.Node) bool {
+	return hasAriaName(n) || strings.TrimSpace(iHtml.Text(n)) != "" || hasImageAlt(n)
+}
+
+// hasImageAlt returns whether the node contains an image with a non-empty alt text
+func hasImageAlt(n *html.Node) bool {
+	if n.Type == html.ElementNode && n.Data == "img" {
+		alt, _ := iHtml.Attr(n, "alt")
+		return strings.TrimSpace(alt) != ""
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if hasImageAlt(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPresentational returns whether the element is hidden from the assistive technologies by its role
+func isPresentational(n *html.Node) bool {
+	role, _ := iHtml.Attr(n, "role")
+	role = strings.ToLower(strings.TrimSpace(role))
+	return role == "presentation" || role == "none"
+}
+
+// hasTableHeaders returns whether the table has a th cell of its own, the cells of the nested tables are not
+// considered
+func hasTableHeaders(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.Data == "table" {
+			continue
+		}
+		if c.Data == "th" || hasTableHeaders(c) {
+			return true
+		}
+	}
+	return false
+}
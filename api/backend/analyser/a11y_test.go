@@ -0,0 +1,95 @@
+package analyser_test
+
+import (
+	"reflect"
+	"testing"
+	"web-analyser/api/backend/analyser"
+)
+
+func TestA11yInspector(t *testing.T) {
+	tests := []*struct {
+		name             string
+		document         string
+		expectedStatuses map[string]analyser.CheckStatus
+		expectedScore    int
+		expectedFindings []*analyser.Finding
+	}{
+		{
+			name: "Should pass every check of an accessible page",
+			document: `<html lang="en"><body><h1>Google</h1><h2>Search</h2>` +
+				`<img src="/logo.png" alt="Google"><img src="/line.png" alt=""><img src="/x.png" role="presentation">` +
+				`<form><label for="q">Search</label><input id="q" name="q">` +
+				`<label>Country <select name="c"></select></label><textarea aria-label="Comment"></textarea>` +
+				`<input type="hidden" name="t"><input type="submit"><button><img src="/go.png" alt="Go"></button>` +
+				`</form><a href="/about">About</a><a name="top"></a><a href="/help" aria-label="Help"></a>` +
+				`<table><tr><th>Name</th></tr><tr><td>Google</td></tr></table>` +
+				`<table role="presentation"><tr><td>Layout</td></tr></table><svg><a href="/"></a></svg></body></html>`,
+			expectedStatuses: map[string]analyser.CheckStatus{
+				"image_alt": analyser.CheckPass, "form_labels": analyser.CheckPass, "html_lang": analyser.CheckPass,
+				"link_names": analyser.CheckPass, "button_names": analyser.CheckPass, "duplicate_ids": analyser.CheckPass,
+				"heading_order": analyser.CheckPass, "table_headers": analyser.CheckPass,
+			},
+			expectedScore:    100,
+			expectedFindings: []*analyser.Finding{},
+		},
+		{
+			name: "Should report each offending element with its selector path",
+			document: `<html><body><h1 id="title">Google</h1><h3>Search</h3>` +
+				`<img src="/logo.png"><form id="title"><input name="q"><input type="image" src="/go.png">` +
+				`<input type="button"><button></button><label for="other">Other</label></form>` +
+				`<a href="/about"></a><table><tr><td>Google</td></tr>` +
+				`<tr><td><table><tr><th>Nested</th></tr></table></td></tr></table></body></html>`,
+			expectedStatuses: map[string]analyser.CheckStatus{
+				"image_alt": analyser.CheckFail, "form_labels": analyser.CheckFail, "html_lang": analyser.CheckFail,
+				"link_names": analyser.CheckFail, "button_names": analyser.CheckFail, "duplicate_ids": analyser.CheckFail,
+				"heading_order": analyser.CheckWarn, "table_headers": analyser.CheckFail,
+			},
+			expectedScore: 6,
+			expectedFindings: []*analyser.Finding{
+				{Severity: analyser.SeverityError, Message: "the image has no alt attribute",
+					Selector: "html > body > img"},
+				{Severity: analyser.SeverityError, Message: "the image button has no alt attribute",
+					Selector: "html > body > form#title > input:nth-of-type(2)"},
+				{Severity: analyser.SeverityError, Message: "the form field has no label",
+					Selector: "html > body > form#title > input:nth-of-type(1)"},
+				{Severity: analyser.SeverityError, Message: "the html element has no lang attribute",
+					Selector: "html"},
+				{Severity: analyser.SeverityError, Message: "the link has no text nor label",
+					Selector: "html > body > a"},
+				{Severity: analyser.SeverityError, Message: "the button has no value nor label",
+					Selector: "html > body > form#title > input:nth-of-type(3)"},
+				{Severity: analyser.SeverityError, Message: "the button has no text nor label",
+					Selector: "html > body > form#title > button"},
+				{Severity: analyser.SeverityError, Message: `the id "title" is already used by another element`,
+					Selector: "html > body > form#title"},
+				{Severity: analyser.SeverityWarning, Message: "the h3 heading follows an h1 heading",
+					Selector: "html > body > h3"},
+				{Severity: analyser.SeverityError, Message: "the table has no th header cells",
+					Selector: "html > body > table"},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			report := inspect(t, analyser.InspectorA11y, tc.document, nil)
+			if report == nil {
+				t.Fatalf("Expected:%v, Got:%v", analyser.InspectorA11y, nil)
+			}
+			if !reflect.DeepEqual(tc.expectedStatuses, checkStatuses(report)) {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedStatuses, checkStatuses(report))
+			}
+			if report.Score == nil || *report.Score != tc.expectedScore {
+				t.Fatalf("Expected:%v, Got:%+v", tc.expectedScore, report)
+			}
+			if !reflect.DeepEqual(tc.expectedFindings, report.Findings) {
+				for _, finding := range report.Findings {
+					t.Logf("%+v", finding)
+				}
+				t.Fatalf("Expected:%v, Got:%v", len(tc.expectedFindings), len(report.Findings))
+			}
+			if report.Values["issues"] != len(tc.expectedFindings) {
+				t.Fatalf("Expected:%v, Got:%v", len(tc.expectedFindings), report.Values["issues"])
+			}
+		})
+	}
+}
@@ -1,36 +1,64 @@
 package analyser
 
 import (
+	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"golang.org/x/net/html"
+	"io"
 	"net/http"
 	"net/url"
-	"strings"
-	iHtml "web-analyser/internal/utils/html"
+	"sort"
+	"time"
+	"web-analyser/config"
 	iHttp "web-analyser/internal/utils/http"
 )
 
 type Analyser interface {
-	Analyse(url *url.URL) (*Summary, error, int)
+	// Analyse analyses the url, the analysis is cancelled along with the context
+	Analyse(ctx context.Context, url *url.URL) (*Summary, error, int)
+	// AnalyseWithProgress analyses the url like Analyse, calling the progress func as the analysis progresses
+	AnalyseWithProgress(ctx context.Context, url *url.URL, progress ProgressFunc) (*Summary, error, int)
 }
 
 type AnalyserImpl struct {
-	httpClient iHttp.Client
+	httpClient  iHttp.Client
+	linkChecker LinkChecker
+	conf        *config.AnalyserConf
+	inspectors  []Inspector
 }
 
-func NewAnalyser(httpClient iHttp.Client) *AnalyserImpl {
+// NewAnalyser creates a new instance of AnalyserImpl, the given inspectors are registered after the built-in
+// inspectors and only the inspectors enabled via the config inspect the pages
+func NewAnalyser(httpClient iHttp.Client, linkChecker LinkChecker, conf *config.AnalyserConf,
+	inspectors ...Inspector) *AnalyserImpl {
 	return &AnalyserImpl{
-		httpClient: httpClient,
+		httpClient:  httpClient,
+		linkChecker: linkChecker,
+		conf:        conf,
+		inspectors:  enabledInspectors(append(BuiltInInspectors(conf), inspectors...), conf.Inspectors),
 	}
 }
 
 // Analyse analyses the url and sets the fields in the summary. In case of no error, it returns the summary, else it
-// returns the error and the http status code in case of error
-func (a *AnalyserImpl) Analyse(url *url.URL) (*Summary, error, int) {
+// returns the error and the http status code in case of error. The requests are bound to the context, the error of
+// the context is returned if it is done before the analysis is finished.
+func (a *AnalyserImpl) Analyse(ctx context.Context, url *url.URL) (*Summary, error, int) {
+	return a.AnalyseWithProgress(ctx, url, nil)
+}
+
+// AnalyseWithProgress analyses the url like Analyse, the progress func is called once the page is fetched, once it
+// is parsed and every time a link is checked. The progress func is optional.
+func (a *AnalyserImpl) AnalyseWithProgress(ctx context.Context, url *url.URL,
+	progress ProgressFunc) (*Summary, error, int) {
+	if progress == nil {
+		progress = func(*Progress) {}
+	}
+
 	summary := NewSummary(url)
-	// use the http client to get the html page
-	resp, err := a.httpClient.Get(url.String())
+	// use the http client to get the html page, following the redirects
+	resp, err := a.fetch(ctx, summary)
 
 	// set the status code if present
 	var httpStatusCode int
@@ -43,6 +71,7 @@ func (a *AnalyserImpl) Analyse(url *url.URL) (*Summary, error, int) {
 	}
 
 	defer resp.Body.Close()
+	progress(&Progress{Stage: StageFetched})
 
 	// if http status code is not 200, it means the url entered is incorrect, so return error
 	if httpStatusCode != http.StatusOK {
@@ -50,61 +79,151 @@ func (a *AnalyserImpl) Analyse(url *url.URL) (*Summary, error, int) {
 			httpStatusCode)), httpStatusCode
 	}
 
-	// parse the response body to build the html page tree
-	doc, err := html.Parse(resp.Body)
+	// sniff the media type of the page from the start of the response body, counting the bytes of the body
+	counter := &countingReader{r: resp.Body}
+	body := bufio.NewReaderSize(counter, charsetPrescanSize)
+	start, err := body.Peek(sniffLen)
+	if err != nil && err != io.EOF {
+		return nil, err, httpStatusCode
+	}
+	content, err := detectContent(resp.Header.Get("Content-Type"), start)
+	if err != nil {
+		return nil, err, httpStatusCode
+	}
+	summary.SetContent(content)
+
+	// transcode the response body to UTF-8 and parse it to build the html page tree
+	decoded, encoding, err := decodeBody(body, resp.Header.Get("Content-Type"))
 	if err != nil {
 		return nil, err, httpStatusCode
 	}
+	summary.SetEncoding(encoding)
+	doc, err := parseBody(content, decoded)
+	if err != nil {
+		return nil, err, httpStatusCode
+	}
+	content.Size = counter.n
+	content.Truncated = iHttp.IsTruncated(resp)
+
+	// inspect the html page tree
+	page := &Page{URL: summary.FinalURL, Header: resp.Header, TLS: resp.TLS}
+	inspections := make([]Inspection, 0, len(a.inspectors))
+	for _, inspector := range a.inspectors {
+		inspections = append(inspections, inspector.Inspect(page))
+	}
+	processHTML(inspections, doc)
+	for _, inspection := range inspections {
+		inspection.Finish(summary)
+	}
+	progress(&Progress{Stage: StageParsed})
 
-	// process the html page tree
-	a.processHTML(summary, doc)
+	// load the links found in the html page
+	a.checkLinks(ctx, summary, progress)
+	// the links which were not loaded because of the context are not inaccessible, so the summary is discarded
+	if err := ctx.Err(); err != nil {
+		return nil, err, httpStatusCode
+	}
 	return summary, nil, httpStatusCode
 }
 
-// processHTML iterates over all the html nodes in a dfs manner and updates the required fields
-func (a *AnalyserImpl) processHTML(summary *Summary, n *html.Node) {
-	//get the type of the node
-	switch t := n.Type; t {
-	//if the type is html.DoctypeNode we can get the html version
-	case html.DoctypeNode:
-		summary.SetVersion(strings.ToUpper(iHtml.Version(n)))
-	case html.ElementNode:
-		//check the tag of the html.ElementNode
-		switch tagName := n.Data; tagName {
-		case "title":
-			summary.SetTitle(iHtml.Text(n))
-		case "h1", "h2", "h3", "h4", "h5", "h6":
-			summary.IncrementHeadersCount(tagName)
-		case "a":
-			link := ""
-			for _, attr := range n.Attr {
-				if attr.Key == "href" {
-					link = attr.Val
-					break
-				}
-			}
-			if !strings.HasPrefix(link, "mailto") && !strings.HasPrefix(link, "tel") &&
-				!strings.HasPrefix(link, "javascript") {
-				u, err := url.Parse(link)
-				if err != nil {
-					summary.AddInaccessibleLink(link)
-				} else {
-					if u.Host == "" || u.Hostname() == summary.URL.Hostname() {
-						summary.AddInternalLink(link)
-					} else {
-						summary.AddExternalLink(link)
-					}
-				}
-			}
-		case "form":
-			if iHtml.HasLoginForm(n) {
-				summary.SetHasLoginForm(true)
-			}
+// fetch gets the page at the URL of the summary following the redirects, up to the max redirects. Each redirect is
+// added to the redirect chain and the URL of the last response is set as the final URL. It returns an error in case
+// of a redirect loop or too many redirects, along with the last redirect response. The requests sent, including the
+// retries of the http client, and the time taken are set as the fetch.
+func (a *AnalyserImpl) fetch(ctx context.Context, summary *Summary) (*http.Response, error) {
+	stats, requests, begin := &iHttp.RetryStats{}, 0, time.Now()
+	ctx = iHttp.WithRetryStats(ctx, stats)
+	defer func() {
+		summary.SetFetch(&Fetch{Attempts: requests + stats.Retries(), Duration: time.Since(begin)})
+	}()
+
+	current := summary.URL
+	visited := map[string]struct{}{iHttp.NormaliseURL(current, false).String(): {}}
+	for {
+		start := time.Now()
+		requests++
+		resp, err := a.httpClient.Get(ctx, current.String())
+		if err != nil || !iHttp.IsRedirect(resp) {
+			summary.SetFinalURL(current)
+			return resp, err
 		}
+		// the body of the redirect response is not needed
+		resp.Body.Close()
+
+		location := resp.Header.Get("Location")
+		next, err := current.Parse(location)
+		if err != nil {
+			return resp, err
+		}
+
+		summary.AddRedirectHop(&RedirectHop{
+			URL:        current.String(),
+			StatusCode: resp.StatusCode,
+			Location:   location,
+			Duration:   time.Since(start),
+			Downgrade:  current.Scheme == "https" && next.Scheme == "http",
+		})
+
+		if _, ok := visited[iHttp.NormaliseURL(next, false).String()]; ok {
+			return resp, errors.New(fmt.Sprintf("redirect loop detected, %v redirects back to %v", current, next))
+		}
+		if len(summary.RedirectChain) > a.conf.MaxRedirects {
+			return resp, errors.New(fmt.Sprintf("stopped after %v redirects", a.conf.MaxRedirects))
+		}
+
+		visited[iHttp.NormaliseURL(next, false).String()] = struct{}{}
+		current = next
+	}
+}
+
+// checkLinks loads the internal and external links using the link checker, the links which fail to load are added
+// to the inaccessible links. Each normalised link is loaded once, as resolved from the first of the links of the page
+// it was normalised from, since the server may answer differently to the normalised link, for example without the
+// trailing slash.
+func (a *AnalyserImpl) checkLinks(ctx context.Context, summary *Summary, progress ProgressFunc) {
+	var links []string
+	// normalisedLinks maps the resolved links to be loaded to their normalised links
+	normalisedLinks := map[string]string{}
+	loaded := map[string]struct{}{}
+	pageLinks := make([]string, 0, len(summary.ResolvedLinks))
+	for link := range summary.ResolvedLinks {
+		pageLinks = append(pageLinks, link)
+	}
+	sort.Strings(pageLinks)
+	for _, link := range pageLinks {
+		u, err := url.Parse(summary.ResolvedLinks[link])
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+		normalisedLink := iHttp.NormaliseURL(u, a.conf.SortQueryParams).String()
+		if _, ok := loaded[normalisedLink]; ok {
+			continue
+		}
+		loaded[normalisedLink] = struct{}{}
+		normalisedLinks[u.String()] = normalisedLink
+		links = append(links, u.String())
+	}
+
+	if len(links) == 0 {
+		return
+	}
+
+	sort.Strings(links)
+	checked := func(checked int, total int) {
+		progress(&Progress{Stage: StageCheckingLinks, LinksChecked: checked, LinksTotal: total})
+	}
+	for link, status := range a.linkChecker.Check(ctx, links, checked) {
+		summary.SetLinkStatus(normalisedLinks[link], status)
+	}
+}
 
+// processHTML iterates over all the html nodes in a dfs manner and visits each inspection with every node
+func processHTML(inspections []Inspection, n *html.Node) {
+	for _, inspection := range inspections {
+		inspection.Visit(n)
 	}
 	//calls itself in a dfs manner
 	for c := n.FirstChild; c != nil; c = c.NextSibling {
-		a.processHTML(summary, c)
+		processHTML(inspections, c)
 	}
 }
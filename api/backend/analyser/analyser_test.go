@@ -1,74 +1,98 @@
 package analyser_test
 
 import (
+	"compress/gzip"
+	"context"
 	"errors"
 	"fmt"
 	"go.uber.org/mock/gomock"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 	"web-analyser/api/backend/analyser"
+	"web-analyser/config"
+	iHtml "web-analyser/internal/utils/html"
+	iHttp "web-analyser/internal/utils/http"
 	"web-analyser/mocks"
 )
 
+// fieldInspectors are the inspectors setting the fields of the summary
+var fieldInspectors = []string{analyser.InspectorVersion, analyser.InspectorTitle, analyser.InspectorHeadings,
+	analyser.InspectorLinks, analyser.InspectorLoginForm, analyser.InspectorForms}
+
+// redirectResponse returns a redirect response to the location
+func redirectResponse(statusCode int, location string) *http.Response {
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     http.Header{"Location": []string{location}},
+		Body:       io.NopCloser(strings.NewReader("")),
+	}
+}
+
 func TestAnalyserImpl_Analyse(t *testing.T) {
 	tests := []*struct {
 		name                   string
 		url                    string
-		setupExpectations      func(client *mocks.MockClient)
+		setupExpectations      func(client *mocks.MockClient, linkChecker *mocks.MockLinkChecker)
 		htmlData               string
 		expectedSummary        *analyser.Summary
 		expectedError          error
 		expectedHttpStatusCode int
+		conf                   *config.AnalyserConf
 	}{
 		{
 			"Should return error if unable to reach the url",
 			"https://google.com",
-			func(client *mocks.MockClient) {
-				client.EXPECT().Get("https://google.com").Return(nil, errors.New("error"))
+			func(client *mocks.MockClient, linkChecker *mocks.MockLinkChecker) {
+				client.EXPECT().Get(gomock.Any(), "https://google.com").Return(nil, errors.New("error"))
 			},
 			"",
 			nil,
 			errors.New("error"),
 			0,
+			nil,
 		},
 		{
 			"Should return error with http status code if unable to reach the url and http status code is present",
 			"https://google.com",
-			func(client *mocks.MockClient) {
+			func(client *mocks.MockClient, linkChecker *mocks.MockLinkChecker) {
 				resp := &http.Response{
 					StatusCode: 100,
 				}
-				client.EXPECT().Get("https://google.com").Return(resp, errors.New("error"))
+				client.EXPECT().Get(gomock.Any(), "https://google.com").Return(resp, errors.New("error"))
 			},
 			"",
 			nil,
 			errors.New("error"),
 			100,
+			nil,
 		},
 		{
 			"Should return error if status code is not 200",
 			"https://google.com",
-			func(client *mocks.MockClient) {
+			func(client *mocks.MockClient, linkChecker *mocks.MockLinkChecker) {
 				resp := &http.Response{
 					StatusCode: 404,
 					Body:       io.NopCloser(strings.NewReader("")),
 				}
 
-				client.EXPECT().Get("https://google.com").Return(resp, nil)
+				client.EXPECT().Get(gomock.Any(), "https://google.com").Return(resp, nil)
 			},
 			"",
 			nil,
 			errors.New(fmt.Sprintf("http status code not 200, value: %v", 404)),
 			404,
+			nil,
 		},
 		{
 			name: "Should update the version, title and headers count in the summary",
 			url:  "https://google.com",
-			setupExpectations: func(client *mocks.MockClient) {
+			setupExpectations: func(client *mocks.MockClient, linkChecker *mocks.MockLinkChecker) {
 				d := "<!DOCTYPE HTML><html><title>This is the title</title><p>Halo, <b>wie geht's</b>. It means," +
 					" \"Hello, <h6>how are you</h6>\".</p><h1>H1 heading.<h2>H2 heading inside H1 heading</h2></h1></html>"
 				resp := &http.Response{
@@ -76,7 +100,7 @@ func TestAnalyserImpl_Analyse(t *testing.T) {
 					StatusCode: 200,
 				}
 
-				client.EXPECT().Get("https://google.com").Return(resp, nil)
+				client.EXPECT().Get(gomock.Any(), "https://google.com").Return(resp, nil)
 			},
 			expectedSummary: &analyser.Summary{
 				Version:              "HTML 5",
@@ -86,53 +110,196 @@ func TestAnalyserImpl_Analyse(t *testing.T) {
 				ExternalLinksMap:     map[string]struct{}{},
 				InaccessibleLinksMap: map[string]struct{}{},
 				HasLoginForm:         false,
+				ResolvedLinks:        map[string]string{},
+				LinkStatuses:         map[string]*analyser.LinkStatus{},
+				RedirectChain:        []*analyser.RedirectHop{},
 			},
 		},
 		{
 			name: "Should update the internal, external and inaccessible links in the summary",
 			url:  "https://google.com",
-			setupExpectations: func(client *mocks.MockClient) {
+			setupExpectations: func(client *mocks.MockClient, linkChecker *mocks.MockLinkChecker) {
 				d := "<html><a href='internal_link1'></a><a href='https://google.com/internal_link2'></a>" +
 					"<a href='#internal_link3'></a><a href='https://www.facebook.com/external_link1'></a>" +
 					"<a href='//abc.google.com/external_link2'></a><a href='abc%$^inaccessible_link1'>" +
-					"<a href='mailto:invalid_link1@abc.com'><a href='tel invalid_link2'></html>"
+					"<a href='/internal_link1/'></a><a href='HTTPS://WWW.Google.com:443/internal_link2#top'></a>" +
+					"<a href='mailto:invalid_link1@abc.com'><a href='tel:+4912345'><a href='JavaScript:void(0)'>" +
+					"<a href='television.html'><a name='no_link'></html>"
 				resp := &http.Response{
 					Body:       io.NopCloser(strings.NewReader(d)),
 					StatusCode: 200,
 				}
 
-				client.EXPECT().Get("https://google.com").Return(resp, nil)
+				client.EXPECT().Get(gomock.Any(), "https://google.com").Return(resp, nil)
+				// the links are loaded as resolved, once per normalised link
+				linkChecker.EXPECT().Check(gomock.Any(), []string{
+					"https://WWW.Google.com:443/internal_link2",
+					"https://abc.google.com/external_link2",
+					"https://google.com",
+					"https://google.com/internal_link1/",
+					"https://google.com/internal_link2",
+					"https://google.com/television.html",
+					"https://www.facebook.com/external_link1",
+				}, gomock.Any()).Return(map[string]*analyser.LinkStatus{
+					"https://WWW.Google.com:443/internal_link2": {StatusCode: 200},
+					"https://abc.google.com/external_link2":     {ErrorClass: analyser.LinkErrorDNS},
+					"https://google.com":                        {StatusCode: 200},
+					"https://google.com/internal_link1/":        {StatusCode: 404, ErrorClass: analyser.LinkErrorHttpStatus},
+					"https://google.com/internal_link2":         {StatusCode: 200},
+					"https://google.com/television.html":        {StatusCode: 200},
+					"https://www.facebook.com/external_link1":   {ErrorClass: analyser.LinkErrorSkipped},
+				})
 			},
 			expectedSummary: &analyser.Summary{
 				Version:      "",
 				Title:        "",
 				HeadersCount: map[string]int{},
 				InternalLinksMap: map[string]struct{}{
-					"internal_link1":                    struct{}{},
-					"https://google.com/internal_link2": struct{}{},
-					"#internal_link3":                   struct{}{},
+					"https://google.com/internal_link1":     struct{}{},
+					"https://google.com/internal_link2":     struct{}{},
+					"https://google.com/":                   struct{}{},
+					"https://google.com/television.html":    struct{}{},
+					"https://www.google.com/internal_link2": struct{}{},
 				},
 				ExternalLinksMap: map[string]struct{}{
 					"https://www.facebook.com/external_link1": struct{}{},
-					"//abc.google.com/external_link2":         struct{}{},
+					"https://abc.google.com/external_link2":   struct{}{},
 				},
 				InaccessibleLinksMap: map[string]struct{}{
-					"abc%$^inaccessible_link1": struct{}{},
+					"abc%$^inaccessible_link1":              struct{}{},
+					"https://google.com/internal_link1":     struct{}{},
+					"https://abc.google.com/external_link2": struct{}{},
 				},
 				HasLoginForm: false,
+				ResolvedLinks: map[string]string{
+					"internal_link1":                                "https://google.com/internal_link1",
+					"/internal_link1/":                              "https://google.com/internal_link1/",
+					"https://google.com/internal_link2":             "https://google.com/internal_link2",
+					"HTTPS://WWW.Google.com:443/internal_link2#top": "https://WWW.Google.com:443/internal_link2",
+					"#internal_link3":                               "https://google.com",
+					"https://www.facebook.com/external_link1":       "https://www.facebook.com/external_link1",
+					"//abc.google.com/external_link2":               "https://abc.google.com/external_link2",
+					"television.html":                               "https://google.com/television.html",
+				},
+				LinkStatuses: map[string]*analyser.LinkStatus{
+					"https://google.com/internal_link1":       {StatusCode: 404, ErrorClass: analyser.LinkErrorHttpStatus},
+					"https://google.com/internal_link2":       {StatusCode: 200},
+					"https://google.com/":                     {StatusCode: 200},
+					"https://www.facebook.com/external_link1": {ErrorClass: analyser.LinkErrorSkipped},
+					"https://abc.google.com/external_link2":   {ErrorClass: analyser.LinkErrorDNS},
+					"https://www.google.com/internal_link2":   {StatusCode: 200},
+					"https://google.com/television.html":      {StatusCode: 200},
+				},
+				RedirectChain: []*analyser.RedirectHop{},
+			},
+		},
+		{
+			name: "Should resolve the links against the base href and classify them as per the same site policy",
+			url:  "https://www.google.com/search/",
+			conf: &config.AnalyserConf{
+				SameSitePolicy:  "domain",
+				SiteAliases:     []string{"google.de"},
+				SortQueryParams: true,
+			},
+			setupExpectations: func(client *mocks.MockClient, linkChecker *mocks.MockLinkChecker) {
+				d := "<html><head><base href='https://mail.google.com/inbox/'></head>" +
+					"<a href='link1?b=2&a=1'></a><a href='../link2'></a><a href='https://google.de'></a>" +
+					"<a href='https://google.co.uk'></a></html>"
+				resp := &http.Response{
+					Body:       io.NopCloser(strings.NewReader(d)),
+					StatusCode: 200,
+				}
+
+				client.EXPECT().Get(gomock.Any(), "https://www.google.com/search/").Return(resp, nil)
+				linkChecker.EXPECT().Check(gomock.Any(), gomock.Len(4), gomock.Any()).Return(map[string]*analyser.LinkStatus{})
+			},
+			expectedSummary: &analyser.Summary{
+				HeadersCount: map[string]int{},
+				InternalLinksMap: map[string]struct{}{
+					"https://mail.google.com/inbox/link1?a=1&b=2": struct{}{},
+					"https://mail.google.com/link2":               struct{}{},
+					"https://google.de/":                          struct{}{},
+				},
+				ExternalLinksMap: map[string]struct{}{
+					"https://google.co.uk/": struct{}{},
+				},
+				InaccessibleLinksMap: map[string]struct{}{},
+				ResolvedLinks: map[string]string{
+					"link1?b=2&a=1":        "https://mail.google.com/inbox/link1?b=2&a=1",
+					"../link2":             "https://mail.google.com/link2",
+					"https://google.de":    "https://google.de",
+					"https://google.co.uk": "https://google.co.uk",
+				},
+				LinkStatuses:  map[string]*analyser.LinkStatus{},
+				RedirectChain: []*analyser.RedirectHop{},
+			},
+		},
+		{
+			name: "Should follow the redirects and classify the links as per the final url",
+			url:  "https://google.com",
+			setupExpectations: func(client *mocks.MockClient, linkChecker *mocks.MockLinkChecker) {
+				client.EXPECT().Get(gomock.Any(), "https://google.com").Return(
+					redirectResponse(301, "https://www.google.com/"), nil)
+				client.EXPECT().Get(gomock.Any(), "https://www.google.com/").Return(
+					redirectResponse(302, "http://www.google.de/home"), nil)
+				client.EXPECT().Get(gomock.Any(), "http://www.google.de/home").Return(&http.Response{
+					Body:       io.NopCloser(strings.NewReader("<html><a href='/about'></a></html>")),
+					StatusCode: 200,
+				}, nil)
+				linkChecker.EXPECT().Check(gomock.Any(), []string{"http://www.google.de/about"}, gomock.Any()).Return(
+					map[string]*analyser.LinkStatus{"http://www.google.de/about": {StatusCode: 200}})
+			},
+			expectedSummary: &analyser.Summary{
+				HeadersCount:         map[string]int{},
+				InternalLinksMap:     map[string]struct{}{"http://www.google.de/about": struct{}{}},
+				ExternalLinksMap:     map[string]struct{}{},
+				InaccessibleLinksMap: map[string]struct{}{},
+				ResolvedLinks:        map[string]string{"/about": "http://www.google.de/about"},
+				LinkStatuses: map[string]*analyser.LinkStatus{
+					"http://www.google.de/about": {StatusCode: 200},
+				},
+				FinalURL: &url.URL{Scheme: "http", Host: "www.google.de", Path: "/home"},
+				RedirectChain: []*analyser.RedirectHop{
+					{URL: "https://google.com", StatusCode: 301, Location: "https://www.google.com/"},
+					{URL: "https://www.google.com/", StatusCode: 302, Location: "http://www.google.de/home",
+						Downgrade: true},
+				},
+				Fetch: &analyser.Fetch{Attempts: 3},
+			},
+		},
+		{
+			name: "Should return error in case of a redirect loop",
+			url:  "https://google.com",
+			setupExpectations: func(client *mocks.MockClient, linkChecker *mocks.MockLinkChecker) {
+				client.EXPECT().Get(gomock.Any(), "https://google.com").Return(redirectResponse(302, "/a"), nil)
+				client.EXPECT().Get(gomock.Any(), "https://google.com/a").Return(redirectResponse(302, "https://GOOGLE.com/"), nil)
+			},
+			expectedError: errors.New(
+				"redirect loop detected, https://google.com/a redirects back to https://GOOGLE.com/"),
+			expectedHttpStatusCode: 302,
+		},
+		{
+			name: "Should return error if there are more redirects than the max redirects",
+			url:  "https://google.com",
+			setupExpectations: func(client *mocks.MockClient, linkChecker *mocks.MockLinkChecker) {
+				client.EXPECT().Get(gomock.Any(), "https://google.com").Return(redirectResponse(307, "/a"), nil)
+				client.EXPECT().Get(gomock.Any(), "https://google.com/a").Return(redirectResponse(308, "/b"), nil)
 			},
+			expectedError:          errors.New("stopped after 1 redirects"),
+			expectedHttpStatusCode: 308,
+			conf:                   &config.AnalyserConf{SameSitePolicy: "host", MaxRedirects: 1},
 		},
 		{
 			name: "Should not update the has login form in the summary",
 			url:  "https://google.com",
-			setupExpectations: func(client *mocks.MockClient) {
+			setupExpectations: func(client *mocks.MockClient, linkChecker *mocks.MockLinkChecker) {
 				d := "<!DOCTYPE HTML><form action='/signup'></html>"
 				resp := &http.Response{
 					Body:       io.NopCloser(strings.NewReader(d)),
 					StatusCode: 200,
 				}
 
-				client.EXPECT().Get("https://google.com").Return(resp, nil)
+				client.EXPECT().Get(gomock.Any(), "https://google.com").Return(resp, nil)
 			},
 			expectedSummary: &analyser.Summary{
 				Version:              "HTML 5",
@@ -142,8 +309,327 @@ func TestAnalyserImpl_Analyse(t *testing.T) {
 				ExternalLinksMap:     map[string]struct{}{},
 				InaccessibleLinksMap: map[string]struct{}{},
 				HasLoginForm:         false,
+				ResolvedLinks:        map[string]string{},
+				LinkStatuses:         map[string]*analyser.LinkStatus{},
+				RedirectChain:        []*analyser.RedirectHop{},
+				DetectedForms: []*analyser.DetectedForm{{Selector: "html > body > form", Kind: iHtml.FormSignup,
+					Confidence: 40, Reasons: []string{`the form attributes mention "signup"`}}},
+				Forms: []*analyser.Form{{Selector: "html > body > form", Method: "GET",
+					Action: "https://google.com/signup", Fields: []*analyser.FormField{}}},
 			},
 		},
+		{
+			name: "Should update the has login form and the detected forms in the summary",
+			url:  "https://google.com",
+			setupExpectations: func(client *mocks.MockClient, linkChecker *mocks.MockLinkChecker) {
+				d := "<!DOCTYPE HTML><body><form role='search'><input type='search' name='q'></form>" +
+					"<div><input id='user'><input type='password'><button>Sign in</button></div>" +
+					"<label for='user'>Username</label></html>"
+				resp := &http.Response{
+					Body:       io.NopCloser(strings.NewReader(d)),
+					StatusCode: 200,
+				}
+
+				client.EXPECT().Get(gomock.Any(), "https://google.com").Return(resp, nil)
+			},
+			expectedSummary: &analyser.Summary{
+				Version:              "HTML 5",
+				Title:                "",
+				HeadersCount:         map[string]int{},
+				InternalLinksMap:     map[string]struct{}{},
+				ExternalLinksMap:     map[string]struct{}{},
+				InaccessibleLinksMap: map[string]struct{}{},
+				HasLoginForm:         true,
+				ResolvedLinks:        map[string]string{},
+				LinkStatuses:         map[string]*analyser.LinkStatus{},
+				RedirectChain:        []*analyser.RedirectHop{},
+				DetectedForms: []*analyser.DetectedForm{
+					{Selector: "html > body > form", Kind: iHtml.FormSearch, Confidence: 90,
+						Reasons: []string{"the form has the search role", "has a search field"}},
+					{Selector: "html > body > div", Kind: iHtml.FormLogin, Confidence: 100,
+						Reasons: []string{`the submit button reads "sign in"`, "has a password field", "has a username field"}},
+				},
+				Forms: []*analyser.Form{{Selector: "html > body > form", Method: "GET", Action: "https://google.com",
+					Fields: []*analyser.FormField{{Type: "search", Name: "q"}}}},
+			},
+		},
+		{
+			name: "Should list the forms with their resolved action and fields in the summary",
+			url:  "https://google.com/account/",
+			setupExpectations: func(client *mocks.MockClient, linkChecker *mocks.MockLinkChecker) {
+				d := "<!DOCTYPE HTML><head><base href='https://google.com/api/'></head><body>" +
+					"<form method='Post' action='upload'><input type='hidden' name='authenticity_token'>" +
+					"<input type='file' name='avatar' required><textarea name='bio'></textarea></form>" +
+					"<form action='http://google.de/subscribe' method='dialog'><input type='email' name='email' " +
+					"autocomplete='email'></form><form><input type='hidden' name='next_page_token'>" +
+					"<input type='text' name='csrf'></form></body></html>"
+				resp := &http.Response{
+					Body:       io.NopCloser(strings.NewReader(d)),
+					StatusCode: 200,
+				}
+
+				client.EXPECT().Get(gomock.Any(), "https://google.com/account/").Return(resp, nil)
+			},
+			expectedSummary: &analyser.Summary{
+				Version:              "HTML 5",
+				HeadersCount:         map[string]int{},
+				InternalLinksMap:     map[string]struct{}{},
+				ExternalLinksMap:     map[string]struct{}{},
+				InaccessibleLinksMap: map[string]struct{}{},
+				ResolvedLinks:        map[string]string{},
+				LinkStatuses:         map[string]*analyser.LinkStatus{},
+				RedirectChain:        []*analyser.RedirectHop{},
+				Forms: []*analyser.Form{
+					{Selector: "html > body > form:nth-of-type(1)", Method: "POST",
+						Action: "https://google.com/api/upload", HasCSRFToken: true, HasFileUpload: true,
+						Fields: []*analyser.FormField{
+							{Type: "hidden", Name: "authenticity_token"}, {Type: "file", Name: "avatar", Required: true},
+							{Type: "textarea", Name: "bio"},
+						}},
+					{Selector: "html > body > form:nth-of-type(2)", Method: "DIALOG",
+						Action: "http://google.de/subscribe", CrossOrigin: true, Insecure: true,
+						Fields: []*analyser.FormField{{Type: "email", Name: "email", Autocomplete: "email"}}},
+					// neither a hidden field merely holding a token nor a visible csrf field is a CSRF token
+					{Selector: "html > body > form:nth-of-type(3)", Method: "GET", Action: "https://google.com/account/",
+						Fields: []*analyser.FormField{{Type: "hidden", Name: "next_page_token"},
+							{Type: "text", Name: "csrf"}}},
+				},
+			},
+		},
+		{
+			name: "Should decode the page as per the charset of the Content-Type header",
+			url:  "https://google.com",
+			setupExpectations: func(client *mocks.MockClient, linkChecker *mocks.MockLinkChecker) {
+				d := "<html><head><title>\x93\xfa\x96\x7b</title></head></html>"
+				resp := &http.Response{
+					Body:       io.NopCloser(strings.NewReader(d)),
+					StatusCode: 200,
+					Header:     http.Header{"Content-Type": []string{"text/html; charset=Shift_JIS"}},
+				}
+
+				client.EXPECT().Get(gomock.Any(), "https://google.com").Return(resp, nil)
+			},
+			expectedSummary: &analyser.Summary{
+				Title:                "日本",
+				HeadersCount:         map[string]int{},
+				InternalLinksMap:     map[string]struct{}{},
+				ExternalLinksMap:     map[string]struct{}{},
+				InaccessibleLinksMap: map[string]struct{}{},
+				ResolvedLinks:        map[string]string{},
+				LinkStatuses:         map[string]*analyser.LinkStatus{},
+				RedirectChain:        []*analyser.RedirectHop{},
+				Encoding: &analyser.Encoding{Charset: "shift_jis", Source: analyser.EncodingSourceHeader,
+					HeaderCharset: "shift_jis"},
+			},
+		},
+		{
+			name: "Should decode the page as per the charset of its meta element",
+			url:  "https://google.com",
+			setupExpectations: func(client *mocks.MockClient, linkChecker *mocks.MockLinkChecker) {
+				d := "<html><head><meta http-equiv='Content-Type' content='text/html; charset=windows-1251'>" +
+					"<title>\xcf\xf0\xe8\xe2\xe5\xf2</title></head></html>"
+				resp := &http.Response{
+					Body:       io.NopCloser(strings.NewReader(d)),
+					StatusCode: 200,
+					Header:     http.Header{"Content-Type": []string{"text/html"}},
+				}
+
+				client.EXPECT().Get(gomock.Any(), "https://google.com").Return(resp, nil)
+			},
+			expectedSummary: &analyser.Summary{
+				Title:                "Привет",
+				HeadersCount:         map[string]int{},
+				InternalLinksMap:     map[string]struct{}{},
+				ExternalLinksMap:     map[string]struct{}{},
+				InaccessibleLinksMap: map[string]struct{}{},
+				ResolvedLinks:        map[string]string{},
+				LinkStatuses:         map[string]*analyser.LinkStatus{},
+				RedirectChain:        []*analyser.RedirectHop{},
+				Encoding: &analyser.Encoding{Charset: "windows-1251", Source: analyser.EncodingSourceMeta,
+					MetaCharset: "windows-1251"},
+			},
+		},
+		{
+			name: "Should report the mismatch between the charsets of the Content-Type header and the meta element",
+			url:  "https://google.com",
+			setupExpectations: func(client *mocks.MockClient, linkChecker *mocks.MockLinkChecker) {
+				d := "<html><head><meta charset='utf-8'><title>Caf\xe9</title></head></html>"
+				resp := &http.Response{
+					Body:       io.NopCloser(strings.NewReader(d)),
+					StatusCode: 200,
+					Header:     http.Header{"Content-Type": []string{"text/html; charset=ISO-8859-1"}},
+				}
+
+				client.EXPECT().Get(gomock.Any(), "https://google.com").Return(resp, nil)
+			},
+			expectedSummary: &analyser.Summary{
+				Title:                "Café",
+				HeadersCount:         map[string]int{},
+				InternalLinksMap:     map[string]struct{}{},
+				ExternalLinksMap:     map[string]struct{}{},
+				InaccessibleLinksMap: map[string]struct{}{},
+				ResolvedLinks:        map[string]string{},
+				LinkStatuses:         map[string]*analyser.LinkStatus{},
+				RedirectChain:        []*analyser.RedirectHop{},
+				Encoding: &analyser.Encoding{Charset: "windows-1252", Source: analyser.EncodingSourceHeader,
+					HeaderCharset: "windows-1252", MetaCharset: "utf-8", Mismatch: true},
+			},
+		},
+		{
+			name: "Should decode the page as per its byte order mark and drop it",
+			url:  "https://google.com",
+			setupExpectations: func(client *mocks.MockClient, linkChecker *mocks.MockLinkChecker) {
+				d := "\xef\xbb\xbf<html><head><title>Caf\xc3\xa9</title></head></html>"
+				resp := &http.Response{
+					Body:       io.NopCloser(strings.NewReader(d)),
+					StatusCode: 200,
+					Header:     http.Header{"Content-Type": []string{"text/html; charset=windows-1251"}},
+				}
+
+				client.EXPECT().Get(gomock.Any(), "https://google.com").Return(resp, nil)
+			},
+			expectedSummary: &analyser.Summary{
+				Title:                "Café",
+				HeadersCount:         map[string]int{},
+				InternalLinksMap:     map[string]struct{}{},
+				ExternalLinksMap:     map[string]struct{}{},
+				InaccessibleLinksMap: map[string]struct{}{},
+				ResolvedLinks:        map[string]string{},
+				LinkStatuses:         map[string]*analyser.LinkStatus{},
+				RedirectChain:        []*analyser.RedirectHop{},
+				Encoding: &analyser.Encoding{Charset: "utf-8", Source: analyser.EncodingSourceBOM,
+					HeaderCharset: "windows-1251"},
+			},
+		},
+		{
+			name: "Should sniff the media type of a page without a Content-Type header and report its size",
+			url:  "https://google.com",
+			setupExpectations: func(client *mocks.MockClient, linkChecker *mocks.MockLinkChecker) {
+				d := "<!DOCTYPE html><html><head><title>Google</title></head></html>"
+				resp := &http.Response{
+					Body:       io.NopCloser(strings.NewReader(d)),
+					StatusCode: 200,
+				}
+
+				client.EXPECT().Get(gomock.Any(), "https://google.com").Return(resp, nil)
+			},
+			expectedSummary: &analyser.Summary{
+				Version:              "HTML 5",
+				Title:                "Google",
+				HeadersCount:         map[string]int{},
+				InternalLinksMap:     map[string]struct{}{},
+				ExternalLinksMap:     map[string]struct{}{},
+				InaccessibleLinksMap: map[string]struct{}{},
+				ResolvedLinks:        map[string]string{},
+				LinkStatuses:         map[string]*analyser.LinkStatus{},
+				RedirectChain:        []*analyser.RedirectHop{},
+				Content:              &analyser.Content{Type: "text/html", SniffedType: "text/html", Size: 62},
+			},
+		},
+		{
+			name: "Should parse an XHTML page as XML",
+			url:  "https://google.com",
+			setupExpectations: func(client *mocks.MockClient, linkChecker *mocks.MockLinkChecker) {
+				d := `<?xml version="1.0" encoding="UTF-8"?><!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.1//EN" ` +
+					`"http://www.w3.org/TR/xhtml11/DTD/xhtml11.dtd"><html xmlns="http://www.w3.org/1999/xhtml">` +
+					`<head><title>Caf&eacute;</title></head><body><p>Google</p></body></html>`
+				resp := &http.Response{
+					Body:       io.NopCloser(strings.NewReader(d)),
+					StatusCode: 200,
+					Header:     http.Header{"Content-Type": []string{"application/xhtml+xml; charset=utf-8"}},
+				}
+
+				client.EXPECT().Get(gomock.Any(), "https://google.com").Return(resp, nil)
+			},
+			expectedSummary: &analyser.Summary{
+				Version:              "XHTML 1.1",
+				Title:                "Café",
+				HeadersCount:         map[string]int{},
+				InternalLinksMap:     map[string]struct{}{},
+				ExternalLinksMap:     map[string]struct{}{},
+				InaccessibleLinksMap: map[string]struct{}{},
+				ResolvedLinks:        map[string]string{},
+				LinkStatuses:         map[string]*analyser.LinkStatus{},
+				RedirectChain:        []*analyser.RedirectHop{},
+				Encoding: &analyser.Encoding{Charset: "utf-8", Source: analyser.EncodingSourceHeader,
+					HeaderCharset: "utf-8"},
+				Content: &analyser.Content{Type: "application/xhtml+xml", DeclaredType: "application/xhtml+xml",
+					SniffedType: "text/xml", Size: 250},
+			},
+		},
+		{
+			name: "Should parse an XHTML page which is not well-formed as an HTML page",
+			url:  "https://google.com",
+			setupExpectations: func(client *mocks.MockClient, linkChecker *mocks.MockLinkChecker) {
+				d := "<html><head><title>Google<br></title></head><body><p>Search</body></html>"
+				resp := &http.Response{
+					Body:       io.NopCloser(strings.NewReader(d)),
+					StatusCode: 200,
+					Header:     http.Header{"Content-Type": []string{"application/xhtml+xml"}},
+				}
+
+				client.EXPECT().Get(gomock.Any(), "https://google.com").Return(resp, nil)
+			},
+			expectedSummary: &analyser.Summary{
+				Title:                "Google<br>",
+				HeadersCount:         map[string]int{},
+				InternalLinksMap:     map[string]struct{}{},
+				ExternalLinksMap:     map[string]struct{}{},
+				InaccessibleLinksMap: map[string]struct{}{},
+				ResolvedLinks:        map[string]string{},
+				LinkStatuses:         map[string]*analyser.LinkStatus{},
+				RedirectChain:        []*analyser.RedirectHop{},
+				Content: &analyser.Content{Type: "text/html", DeclaredType: "application/xhtml+xml",
+					SniffedType: "text/html", Size: 73},
+			},
+		},
+		{
+			name: "Should refuse a page declared as another media type",
+			url:  "https://google.com",
+			setupExpectations: func(client *mocks.MockClient, linkChecker *mocks.MockLinkChecker) {
+				d := "{\"title\": \"Google\"}"
+				resp := &http.Response{
+					Body:       io.NopCloser(strings.NewReader(d)),
+					StatusCode: 200,
+					Header:     http.Header{"Content-Type": []string{"application/json"}},
+				}
+
+				client.EXPECT().Get(gomock.Any(), "https://google.com").Return(resp, nil)
+			},
+			expectedError:          &analyser.ContentTypeError{ContentType: "application/json"},
+			expectedHttpStatusCode: 200,
+		},
+		{
+			name: "Should refuse a page without a Content-Type header which is not sniffed as HTML",
+			url:  "https://google.com",
+			setupExpectations: func(client *mocks.MockClient, linkChecker *mocks.MockLinkChecker) {
+				d := "Google"
+				resp := &http.Response{
+					Body:       io.NopCloser(strings.NewReader(d)),
+					StatusCode: 200,
+				}
+
+				client.EXPECT().Get(gomock.Any(), "https://google.com").Return(resp, nil)
+			},
+			expectedError:          &analyser.ContentTypeError{ContentType: "text/plain"},
+			expectedHttpStatusCode: 200,
+		},
+		{
+			name: "Should refuse a binary page whatever its Content-Type header",
+			url:  "https://google.com",
+			setupExpectations: func(client *mocks.MockClient, linkChecker *mocks.MockLinkChecker) {
+				d := "%PDF-1.7\n%\xe2\xe3"
+				resp := &http.Response{
+					Body:       io.NopCloser(strings.NewReader(d)),
+					StatusCode: 200,
+					Header:     http.Header{"Content-Type": []string{"text/html"}},
+				}
+
+				client.EXPECT().Get(gomock.Any(), "https://google.com").Return(resp, nil)
+			},
+			expectedError:          &analyser.ContentTypeError{ContentType: "application/pdf"},
+			expectedHttpStatusCode: 200,
+		},
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
@@ -151,15 +637,24 @@ func TestAnalyserImpl_Analyse(t *testing.T) {
 			defer ctrl.Finish()
 
 			mockClient := mocks.NewMockClient(ctrl)
-			a := analyser.NewAnalyser(mockClient)
+			mockLinkChecker := mocks.NewMockLinkChecker(ctrl)
+			conf := tc.conf
+			if conf == nil {
+				conf = &config.AnalyserConf{SameSitePolicy: "host", MaxRedirects: 10}
+			}
+			// the reports of the audits are covered by the tests of their inspectors
+			if conf.Inspectors == nil {
+				conf.Inspectors = fieldInspectors
+			}
+			a := analyser.NewAnalyser(mockClient, mockLinkChecker, conf)
 			u := tc.url
 
 			if tc.setupExpectations != nil {
-				tc.setupExpectations(mockClient)
+				tc.setupExpectations(mockClient, mockLinkChecker)
 			}
 
 			parsedUrl, _ := url.Parse(u)
-			summary, err, statusCode := a.Analyse(parsedUrl)
+			summary, err, statusCode := a.Analyse(context.Background(), parsedUrl)
 			if tc.expectedError != nil && err.Error() != tc.expectedError.Error() {
 				t.Fatalf("Expected:%v, Got:%v", tc.expectedError, err)
 			}
@@ -169,6 +664,37 @@ func TestAnalyserImpl_Analyse(t *testing.T) {
 
 			if tc.expectedSummary != nil {
 				tc.expectedSummary.URL = parsedUrl
+				if tc.expectedSummary.FinalURL == nil {
+					tc.expectedSummary.FinalURL = parsedUrl
+				}
+				if tc.expectedSummary.Reports == nil {
+					tc.expectedSummary.Reports = []*analyser.Report{}
+				}
+				if tc.expectedSummary.DetectedForms == nil {
+					tc.expectedSummary.DetectedForms = []*analyser.DetectedForm{}
+				}
+				if tc.expectedSummary.Forms == nil {
+					tc.expectedSummary.Forms = []*analyser.Form{}
+				}
+				// the pages declaring no charset and having no UTF-8 characters are decoded as windows-1252
+				if tc.expectedSummary.Encoding == nil {
+					tc.expectedSummary.Encoding = &analyser.Encoding{Charset: "windows-1252",
+						Source: analyser.EncodingSourceDefault}
+				}
+				// the content is only compared by the cases checking it, since its size varies with every page
+				if tc.expectedSummary.Content == nil {
+					summary.Content = nil
+				}
+				// the fetch is only compared by the cases checking it, and like the redirects its duration varies on
+				// every run
+				if tc.expectedSummary.Fetch == nil {
+					summary.Fetch = nil
+				} else {
+					summary.Fetch.Duration = 0
+				}
+				for _, hop := range summary.RedirectChain {
+					hop.Duration = 0
+				}
 				if !reflect.DeepEqual(tc.expectedSummary, summary) {
 					t.Fatalf("Expected:%+v, Got:%+v", tc.expectedSummary, summary)
 				}
@@ -177,3 +703,103 @@ func TestAnalyserImpl_Analyse(t *testing.T) {
 		})
 	}
 }
+
+func TestAnalyserImpl_Analyse_Cancelled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	mockClient := mocks.NewMockClient(ctrl)
+	mockLinkChecker := mocks.NewMockLinkChecker(ctrl)
+	// the page is fetched with a copy of the context recording the retries, which is done along with the context
+	isCtx := gomock.Cond(func(x any) bool { return x.(context.Context).Done() == ctx.Done() })
+	mockClient.EXPECT().Get(isCtx, "https://google.com").Return(&http.Response{
+		Body:       io.NopCloser(strings.NewReader("<html><a href='/about'></a></html>")),
+		StatusCode: 200,
+	}, nil)
+	// the context is cancelled while the links are checked, so the links are not loaded
+	mockLinkChecker.EXPECT().Check(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, links []string, _ func(int, int)) map[string]*analyser.LinkStatus {
+			cancel()
+			return map[string]*analyser.LinkStatus{links[0]: {ErrorClass: analyser.LinkErrorTimeout}}
+		})
+
+	a := analyser.NewAnalyser(mockClient, mockLinkChecker, &config.AnalyserConf{SameSitePolicy: "host"})
+	u, _ := url.Parse("https://google.com")
+	summary, err, statusCode := a.Analyse(ctx, u)
+	if summary != nil || !errors.Is(err, context.Canceled) || statusCode != 200 {
+		t.Fatalf("Expected:%v, Got:%v %v %v", context.Canceled, summary, err, statusCode)
+	}
+}
+
+func TestAnalyserImpl_Analyse_Truncated(t *testing.T) {
+	// the server streams an endless gzip page until the client goes away
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("Content-Encoding", "gzip")
+		gw := gzip.NewWriter(w)
+		io.WriteString(gw, "<!DOCTYPE html><html><head><title>Google</title></head><body>")
+		for r.Context().Err() == nil {
+			if _, err := io.WriteString(gw, strings.Repeat("<p>Search</p>", 100)); err != nil {
+				return
+			}
+			gw.Flush()
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client, _ := iHttp.NewNoRedirectHttpClient(&config.ClientConf{AllowedAddresses: []string{"127.0.0.1"},
+		MaxBodySize: 4096})
+	conf := &config.AnalyserConf{SameSitePolicy: "host", Inspectors: fieldInspectors}
+	a := analyser.NewAnalyser(client, mocks.NewMockLinkChecker(ctrl), conf)
+	u, _ := url.Parse(server.URL)
+	summary, err, _ := a.Analyse(context.Background(), u)
+	if err != nil {
+		t.Fatalf("Expected:%v, Got:%v", nil, err)
+	}
+
+	// the page is analysed up to the max body size
+	expectedContent := &analyser.Content{Type: "text/html", DeclaredType: "text/html", SniffedType: "text/html",
+		Size: 4096, Truncated: true}
+	if !reflect.DeepEqual(expectedContent, summary.Content) {
+		t.Fatalf("Expected:%+v, Got:%+v", expectedContent, summary.Content)
+	}
+	if summary.Title != "Google" {
+		t.Fatalf("Expected:%v, Got:%v", "Google", summary.Title)
+	}
+}
+
+func TestAnalyserImpl_Analyse_Retried(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// the page responds with a 503 and then with the page, the retry client retries it after the Retry-After
+	mockClient := mocks.NewMockClient(ctrl)
+	gomock.InOrder(
+		mockClient.EXPECT().Do(gomock.Any()).Return(&http.Response{
+			Header:     http.Header{"Retry-After": []string{"0"}},
+			Body:       io.NopCloser(strings.NewReader("")),
+			StatusCode: 503,
+		}, nil),
+		mockClient.EXPECT().Do(gomock.Any()).Return(&http.Response{
+			Body:       io.NopCloser(strings.NewReader("<html><title>Google</title></html>")),
+			StatusCode: 200,
+		}, nil),
+	)
+
+	client := iHttp.NewRetryClient(mockClient, &config.ClientConf{RetryMaxAttempts: 3, RetryMaxDelay: time.Second})
+	conf := &config.AnalyserConf{SameSitePolicy: "host", Inspectors: fieldInspectors}
+	a := analyser.NewAnalyser(client, mocks.NewMockLinkChecker(ctrl), conf)
+	u, _ := url.Parse("https://google.com")
+	summary, err, statusCode := a.Analyse(context.Background(), u)
+	if err != nil || statusCode != 200 {
+		t.Fatalf("Expected:%v, Got:%v %v", 200, err, statusCode)
+	}
+	if summary.Fetch.Attempts != 2 || summary.Fetch.Duration <= 0 {
+		t.Fatalf("Expected:%v, Got:%+v", 2, summary.Fetch)
+	}
+}
@@ -0,0 +1,64 @@
+package analyser
+
+import (
+	"bufio"
+	"bytes"
+	"golang.org/x/net/html/charset"
+	"io"
+	"mime"
+	"strings"
+	iHtml "web-analyser/internal/utils/html"
+)
+
+// charsetPrescanSize is the number of bytes at the start of the page which are scanned for a byte order mark and the
+// meta elements declaring the charset, as per the HTML spec
+const charsetPrescanSize = 1024
+
+// byteOrderMarks are the byte order marks of the UTF-8, UTF-16BE and UTF-16LE encodings
+var byteOrderMarks = [][]byte{{0xef, 0xbb, 0xbf}, {0xfe, 0xff}, {0xff, 0xfe}}
+
+// decodeBody returns the body transcoded to UTF-8 along with the encoding of the page, which is found from its byte
+// order mark, the charset of the Content-Type header or the charset declared by its meta elements, in that order. The
+// pages declaring none are decoded as UTF-8 if valid, otherwise as windows-1252 like the browsers do.
+func decodeBody(body io.Reader, contentType string) (io.Reader, *Encoding, error) {
+	r := bufio.NewReaderSize(body, charsetPrescanSize)
+	content, err := r.Peek(charsetPrescanSize)
+	if err != nil && err != io.EOF {
+		return nil, nil, err
+	}
+
+	encoding := &Encoding{MetaCharset: charsetName(iHtml.MetaCharset(content)), Source: EncodingSourceDefault}
+	if _, params, err := mime.ParseMediaType(contentType); err == nil {
+		encoding.HeaderCharset = charsetName(params["charset"])
+	}
+	encoding.Mismatch = encoding.HeaderCharset != "" && encoding.MetaCharset != "" &&
+		encoding.HeaderCharset != encoding.MetaCharset
+
+	e, name, _ := charset.DetermineEncoding(content, contentType)
+	encoding.Charset = name
+	for _, bom := range byteOrderMarks {
+		if bytes.HasPrefix(content, bom) {
+			encoding.Source = EncodingSourceBOM
+			// the decoders keep the byte order mark, which would end up in the text of the page
+			if _, err := r.Discard(len(bom)); err != nil {
+				return nil, nil, err
+			}
+			return e.NewDecoder().Reader(r), encoding, nil
+		}
+	}
+	switch name {
+	case encoding.HeaderCharset:
+		encoding.Source = EncodingSourceHeader
+	case encoding.MetaCharset:
+		encoding.Source = EncodingSourceMeta
+	}
+	return e.NewDecoder().Reader(r), encoding, nil
+}
+
+// charsetName returns the canonical name of the encoding with the label, or the lowercased label if it's unknown
+func charsetName(label string) string {
+	if _, name := charset.Lookup(label); name != "" {
+		return name
+	}
+	return strings.ToLower(strings.TrimSpace(label))
+}
@@ -0,0 +1,102 @@
+package analyser
+
+import (
+	"bytes"
+	"golang.org/x/net/html"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	iHtml "web-analyser/internal/utils/html"
+)
+
+// media types of the pages which can be analysed
+const (
+	mediaTypeHTML  = "text/html"
+	mediaTypeXHTML = "application/xhtml+xml"
+)
+
+// sniffLen is the number of bytes at the start of the page the media type is sniffed from, as per
+// http.DetectContentType
+const sniffLen = 512
+
+// genericMediaTypes are the media types which don't tell the type of the page, so the media type is sniffed instead
+var genericMediaTypes = map[string]struct{}{
+	"":                         {},
+	"application/octet-stream": {},
+	"application/unknown":      {},
+	"unknown/unknown":          {},
+	"*/*":                      {},
+}
+
+// ContentTypeError represents a page which isn't an HTML or XHTML page, such as a PDF, an image or a JSON document
+type ContentTypeError struct {
+	ContentType string // ContentType represents the media type of the page
+}
+
+func (e *ContentTypeError) Error() string {
+	return "unsupported content type: " + e.ContentType
+}
+
+// detectContent returns the content of the page as per the Content-Type header and the media type sniffed from the
+// start of the body. The declared media type is honoured, it's sniffed only if it's missing or generic, while the
+// binary pages are never parsed whatever their Content-Type header. It returns a ContentTypeError if the page isn't
+// an HTML or XHTML page.
+func detectContent(contentType string, start []byte) (*Content, error) {
+	content := &Content{SniffedType: mediaType(http.DetectContentType(start))}
+	if contentType != "" {
+		content.DeclaredType = mediaType(contentType)
+	}
+
+	if !strings.HasPrefix(content.SniffedType, "text/") {
+		return nil, &ContentTypeError{ContentType: content.SniffedType}
+	}
+	content.Type = content.DeclaredType
+	if _, ok := genericMediaTypes[content.DeclaredType]; ok {
+		content.Type = content.SniffedType
+	}
+	if content.Type != mediaTypeHTML && content.Type != mediaTypeXHTML {
+		return nil, &ContentTypeError{ContentType: content.Type}
+	}
+	return content, nil
+}
+
+// parseBody parses the body as per the media type of the content, the XHTML pages which aren't well-formed are parsed
+// as HTML pages instead, and their media type is changed accordingly
+func parseBody(content *Content, body io.Reader) (*html.Node, error) {
+	if content.Type != mediaTypeXHTML {
+		return html.Parse(body)
+	}
+
+	// the XHTML page is kept so that it can be parsed again as an HTML page
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	if doc, err := iHtml.ParseXHTML(bytes.NewReader(b)); err == nil {
+		return doc, nil
+	}
+	content.Type = mediaTypeHTML
+	return html.Parse(bytes.NewReader(b))
+}
+
+// mediaType returns the lowercased media type of the Content-Type without its params, or the Content-Type as is if it
+// can't be parsed
+func mediaType(contentType string) string {
+	if t, _, err := mime.ParseMediaType(contentType); err == nil {
+		return t
+	}
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
+
+// countingReader counts the bytes read from the reader
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
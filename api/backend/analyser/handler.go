@@ -1,31 +1,44 @@
 package analyser
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"github.com/rs/zerolog"
+	"mime"
 	"net/http"
-	netUrl "net/url"
+	"time"
 	iCtx "web-analyser/internal/utils/ctx"
 	iError "web-analyser/internal/utils/error"
 	iHttp "web-analyser/internal/utils/http"
+	"web-analyser/internal/utils/render"
 )
 
 type Handler interface {
 	Index(w http.ResponseWriter, r *http.Request)
 	Summary(w http.ResponseWriter, r *http.Request)
+	Analysis(w http.ResponseWriter, r *http.Request)
 }
 
 type HandlerImpl struct {
 	logger   *zerolog.Logger
 	tpl      Template
 	analyser Analyser
+	recorder Recorder
 }
 
-func NewHandler(logger *zerolog.Logger, tpl Template, analyser Analyser) *HandlerImpl {
+// analysisRequest is the JSON body accepted by the analysis api, the request options are set along with the url
+type analysisRequest struct {
+	URL string `json:"url"`
+	iHttp.RequestOptions
+}
+
+func NewHandler(logger *zerolog.Logger, tpl Template, analyser Analyser, recorder Recorder) *HandlerImpl {
 	return &HandlerImpl{
 		logger:   logger,
 		tpl:      tpl,
 		analyser: analyser,
+		recorder: recorder,
 	}
 }
 
@@ -35,34 +48,18 @@ func (h *HandlerImpl) Index(w http.ResponseWriter, r *http.Request) {
 	h.renderTemplate(w, r, "index.gohtml", nil)
 }
 
-// Summary gives the summary of the url.
+// Summary gives the summary of the url, it renders the summary page unless the client accepts JSON.
 func (h *HandlerImpl) Summary(w http.ResponseWriter, r *http.Request) {
-	// get the url from the request
-	url := r.FormValue("url")
+	// get the url and the request options from the request and analyse it
+	summary, statusCode, customError := h.analyse(r, r.FormValue("url"), iHttp.FormRequestOptions(r))
 
-	reqID := iCtx.RequestID(r.Context())
-
-	// Validate the URL using the IsValidURL function
-	if !iHttp.IsValidURL(url) {
-		// If the URL is invalid, log and render the error page with proper message
-		h.logger.Error().Str(iCtx.KeyRequestID, reqID).Str("url", url).
-			Err(errors.New("invalid URL")).Msg("")
-		h.renderTemplate(w, r, "error.gohtml", iError.CustomError{Message: string(iError.InvalidURLError)})
+	if iHttp.AcceptsJSON(r) {
+		h.renderSummaryJSON(w, r, summary, statusCode, customError)
 		return
 	}
 
-	parsedUrl, _ := netUrl.Parse(url)
-	summary, err, statusCode := h.analyser.Analyse(parsedUrl)
-	if err != nil {
-		customError := iError.CustomError{Message: string(iError.UnreachableURLError)}
-		// if there is http status code returned, add it to the error object so that it can be conveyed to the user
-		if statusCode != 0 {
-			customError.HttpStatusCode = statusCode
-		}
-
-		// log and render the error page with proper message
-		h.logger.Error().Str(iCtx.KeyRequestID, reqID).Err(err).Msg(customError.Message)
-		h.renderTemplate(w, r, "error.gohtml", customError)
+	if customError != nil {
+		h.renderTemplate(w, r, "error.gohtml", *customError)
 		return
 	}
 
@@ -70,12 +67,102 @@ func (h *HandlerImpl) Summary(w http.ResponseWriter, r *http.Request) {
 	h.renderTemplate(w, r, "summary.gohtml", summary)
 }
 
-func (h *HandlerImpl) renderTemplate(w http.ResponseWriter, r *http.Request, tpl string, data any) {
+// Analysis gives the summary of the url as JSON, the url and the request options are read from the JSON body in case
+// of a JSON request, otherwise from the query or form values.
+func (h *HandlerImpl) Analysis(w http.ResponseWriter, r *http.Request) {
+	url, options := r.FormValue("url"), iHttp.FormRequestOptions(r)
+
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == iHttp.ContentTypeJSON {
+		var body analysisRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			h.logger.Error().Str(iCtx.KeyRequestID, iCtx.RequestID(r.Context())).Err(err).Msg("invalid request body")
+			h.renderJSON(w, r, http.StatusBadRequest,
+				ErrorResponse{Error: iError.CustomError{Message: string(iError.InvalidRequestError)}})
+			return
+		}
+		url, options = body.URL, &body.RequestOptions
+	}
+
+	summary, statusCode, customError := h.analyse(r, url, options)
+	h.renderSummaryJSON(w, r, summary, statusCode, customError)
+}
+
+// analyse validates the url and the request options and analyses the url with the options. In case of failure, it
+// logs the error and returns the http status code to respond with along with the error to be conveyed to the user
+func (h *HandlerImpl) analyse(r *http.Request, url string, options *iHttp.RequestOptions) (*Summary, int,
+	*iError.CustomError) {
 	reqID := iCtx.RequestID(r.Context())
-	err := h.tpl.ExecuteTemplate(w, tpl, data)
+
+	// Validate the URL using the ValidateURL function
+	parsedUrl, err := iHttp.ValidateURL(url)
+	if err != nil {
+		// If the URL is invalid, log and return the error with the reason why it is invalid
+		h.logger.Error().Str(iCtx.KeyRequestID, reqID).Str("url", url).Err(err).Msg("invalid URL")
+		return nil, http.StatusBadRequest, &iError.CustomError{Message: err.Error()}
+	}
+	// the options are not logged since they may hold credentials
+	if err := options.Validate(); err != nil {
+		h.logger.Error().Str(iCtx.KeyRequestID, reqID).Err(err).Msg("invalid request options")
+		return nil, http.StatusBadRequest, &iError.CustomError{Message: err.Error()}
+	}
+
+	start := time.Now()
+	summary, err, statusCode := h.analyser.Analyse(iHttp.WithRequestOptions(r.Context(), parsedUrl, options), parsedUrl)
 	if err != nil {
-		h.logger.Error().Str(iCtx.KeyRequestID, reqID).Err(err).Msg("template error")
-		w.WriteHeader(http.StatusInternalServerError)
+		// log and return the error with proper message
+		responseStatusCode, customError := AnalysisError(err, statusCode)
+		h.logger.Error().Str(iCtx.KeyRequestID, reqID).Err(err).Msg(customError.Message)
+		return nil, responseStatusCode, customError
+	}
+
+	// the analysis is served even if it can't be recorded
+	if err := h.recorder.Record(reqID, summary, statusCode, time.Since(start)); err != nil {
+		h.logger.Error().Str(iCtx.KeyRequestID, reqID).Err(err).Msg("unable to record the analysis")
+	}
+
+	return summary, http.StatusOK, nil
+}
+
+// AnalysisError returns the http status code to respond with along with the error to be conveyed to the user when
+// the analysis fails with the error and the http status code of the page
+func AnalysisError(err error, statusCode int) (int, *iError.CustomError) {
+	if errors.Is(err, iHttp.ErrBlockedAddress) {
+		// the url points to an internal address, the http status code is not conveyed since nothing was loaded
+		return http.StatusForbidden, &iError.CustomError{Message: string(iError.BlockedURLError)}
+	}
+	var contentTypeErr *ContentTypeError
+	if errors.As(err, &contentTypeErr) {
+		// the page was loaded but it can't be analysed, so its http status code is not conveyed either
+		return http.StatusUnprocessableEntity, &iError.CustomError{
+			Message: string(iError.UnsupportedContentTypeError) + contentTypeErr.ContentType}
+	}
+	if errors.Is(err, context.Canceled) {
+		// the client went away or the server is shutting down, the page itself may be reachable
+		return http.StatusServiceUnavailable, &iError.CustomError{Message: string(iError.AnalysisCancelledError)}
+	}
+
+	customError := &iError.CustomError{Message: string(iError.UnreachableURLError)}
+	// if there is http status code returned, add it to the error object so that it can be conveyed to the user
+	if statusCode != 0 {
+		customError.HttpStatusCode = statusCode
+	}
+	return http.StatusBadGateway, customError
+}
+
+func (h *HandlerImpl) renderTemplate(w http.ResponseWriter, r *http.Request, tpl string, data any) {
+	render.Template(w, r, h.logger, h.tpl, tpl, data)
+}
+
+// renderSummaryJSON writes either the summary or the error response as JSON
+func (h *HandlerImpl) renderSummaryJSON(w http.ResponseWriter, r *http.Request, summary *Summary, statusCode int,
+	customError *iError.CustomError) {
+	if customError != nil {
+		h.renderJSON(w, r, statusCode, ErrorResponse{Error: *customError})
 		return
 	}
+	h.renderJSON(w, r, statusCode, summary)
+}
+
+func (h *HandlerImpl) renderJSON(w http.ResponseWriter, r *http.Request, statusCode int, data any) {
+	render.JSON(w, r, h.logger, statusCode, data)
 }
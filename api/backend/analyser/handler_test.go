@@ -1,16 +1,22 @@
 package analyser_test
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"go.uber.org/mock/gomock"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	netUrl "net/url"
+	"reflect"
 	"strings"
 	"testing"
 	"web-analyser/api/backend/analyser"
+	iCtx "web-analyser/internal/utils/ctx"
 	iError "web-analyser/internal/utils/error"
+	iHttp "web-analyser/internal/utils/http"
 	l "web-analyser/internal/utils/logger"
 	"web-analyser/mocks"
 )
@@ -22,7 +28,7 @@ func TestHandlerImpl_Index(t *testing.T) {
 	mockedAnalyser := mocks.NewMockAnalyser(ctrl)
 	mockedTemplate := mocks.NewMockTemplate(ctrl)
 	logger := l.NewLogger(false)
-	handler := analyser.NewHandler(logger, mockedTemplate, mockedAnalyser)
+	handler := analyser.NewHandler(logger, mockedTemplate, mockedAnalyser, mocks.NewMockRecorder(ctrl))
 	w := httptest.NewRecorder()
 	r := httptest.NewRequest(http.MethodGet, "/", nil)
 	mockedTemplate.EXPECT().ExecuteTemplate(w, "index.gohtml", nil)
@@ -43,12 +49,20 @@ func TestHandlerImpl_Summary(t *testing.T) {
 					Message: string(iError.InvalidURLError)})
 			},
 		},
+		{
+			"Should render error template with the reason why the url is invalid", "http://google.com:99999",
+			func(w *httptest.ResponseRecorder, mockAnalyser *mocks.MockAnalyser,
+				mockTemplate *mocks.MockTemplate) {
+				mockTemplate.EXPECT().ExecuteTemplate(w, "error.gohtml", iError.CustomError{
+					Message: string(iError.InvalidPortError)})
+			},
+		},
 		{
 			"Should render error template if analysing the url returns error", "https://google.com",
 			func(w *httptest.ResponseRecorder, mockAnalyser *mocks.MockAnalyser,
 				mockTemplate *mocks.MockTemplate) {
 				u, _ := netUrl.Parse("https://google.com")
-				mockAnalyser.EXPECT().Analyse(u).Return(nil, errors.New("error"), 0)
+				mockAnalyser.EXPECT().Analyse(gomock.Any(), u).Return(nil, errors.New("error"), 0)
 				mockTemplate.EXPECT().ExecuteTemplate(w, "error.gohtml", iError.CustomError{
 					Message: string(iError.UnreachableURLError)})
 			},
@@ -60,7 +74,7 @@ func TestHandlerImpl_Summary(t *testing.T) {
 				mockTemplate *mocks.MockTemplate) {
 				u, _ := netUrl.Parse("https://google.com")
 				statusCode := 404
-				mockAnalyser.EXPECT().Analyse(u).Return(nil, errors.New("error"), statusCode)
+				mockAnalyser.EXPECT().Analyse(gomock.Any(), u).Return(nil, errors.New("error"), statusCode)
 				mockTemplate.EXPECT().ExecuteTemplate(w, "error.gohtml", iError.CustomError{
 					Message: string(iError.UnreachableURLError), HttpStatusCode: statusCode})
 			},
@@ -77,7 +91,7 @@ func TestHandlerImpl_Summary(t *testing.T) {
 					HeadersCount: nil,
 					HasLoginForm: false,
 				}
-				mockAnalyser.EXPECT().Analyse(u).Return(summary, nil, 0)
+				mockAnalyser.EXPECT().Analyse(gomock.Any(), u).Return(summary, nil, 0)
 				mockTemplate.EXPECT().ExecuteTemplate(w, "summary.gohtml", summary)
 			},
 		},
@@ -90,7 +104,7 @@ func TestHandlerImpl_Summary(t *testing.T) {
 			mockedAnalyser := mocks.NewMockAnalyser(ctrl)
 			mockedTemplate := mocks.NewMockTemplate(ctrl)
 			logger := l.NewLogger(false)
-			handler := analyser.NewHandler(logger, mockedTemplate, mockedAnalyser)
+			handler := analyser.NewHandler(logger, mockedTemplate, mockedAnalyser, newRecorder(ctrl))
 			w := httptest.NewRecorder()
 			url := tc.url
 			body := strings.NewReader(fmt.Sprintf("url=%v", url))
@@ -105,3 +119,230 @@ func TestHandlerImpl_Summary(t *testing.T) {
 		})
 	}
 }
+
+func TestHandlerImpl_Summary_JSON(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockedAnalyser := mocks.NewMockAnalyser(ctrl)
+	mockedTemplate := mocks.NewMockTemplate(ctrl)
+	logger := l.NewLogger(false)
+	handler := analyser.NewHandler(logger, mockedTemplate, mockedAnalyser, newRecorder(ctrl))
+	w := httptest.NewRecorder()
+
+	u, _ := netUrl.Parse("https://google.com")
+	summary := analyser.NewSummary(u)
+	summary.SetTitle("Title")
+	mockedAnalyser.EXPECT().Analyse(gomock.Any(), u).Return(summary, nil, 200)
+
+	r := httptest.NewRequest(http.MethodPost, "/summary", strings.NewReader("url=https://google.com"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set("Accept", "application/json, text/html;q=0.9")
+	handler.Summary(w, r)
+
+	expectedBody := `{"url":"https://google.com","version":"","title":"Title","headersCount":{},` +
+		`"hasLoginForm":false,"internalLinks":[],"externalLinks":[],"inaccessibleLinks":[]}`
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected:%v, Got:%v", http.StatusOK, w.Code)
+	}
+	assertJSONContains(t, expectedBody, w.Body.Bytes())
+}
+
+func TestHandlerImpl_Analysis(t *testing.T) {
+	tests := []*struct {
+		name               string
+		method             string
+		target             string
+		contentType        string
+		body               string
+		setupExpectations  func(*mocks.MockAnalyser)
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name:               "Should return bad request with the reason for invalid url",
+			method:             http.MethodGet,
+			target:             "/api/v1/analyses?url=ftp://google.com",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       fmt.Sprintf(`{"error":{"message":%q}}`, iError.UnsupportedSchemeError),
+		},
+		{
+			name:               "Should return bad request for malformed JSON body",
+			method:             http.MethodPost,
+			target:             "/api/v1/analyses",
+			contentType:        "application/json",
+			body:               "{",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       fmt.Sprintf(`{"error":{"message":%q}}`, iError.InvalidRequestError),
+		},
+		{
+			name:               "Should return bad request with the reason for invalid request options",
+			method:             http.MethodPost,
+			target:             "/api/v1/analyses",
+			contentType:        "application/json",
+			body:               `{"url":"https://google.com","username":"user","password":"pass","token":"token"}`,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       fmt.Sprintf(`{"error":{"message":%q}}`, iError.InvalidCredentialsError),
+		},
+		{
+			name:   "Should return bad gateway with http status code if analysing the url returns error",
+			method: http.MethodGet,
+			target: "/api/v1/analyses?url=https://google.com",
+			setupExpectations: func(mockAnalyser *mocks.MockAnalyser) {
+				u, _ := netUrl.Parse("https://google.com")
+				mockAnalyser.EXPECT().Analyse(gomock.Any(), u).Return(nil, errors.New("error"), 404)
+			},
+			expectedStatusCode: http.StatusBadGateway,
+			expectedBody: fmt.Sprintf(`{"error":{"message":%q,"httpStatusCode":404}}`,
+				iError.UnreachableURLError),
+		},
+		{
+			name:   "Should return forbidden if the url points to a blocked address",
+			method: http.MethodGet,
+			target: "/api/v1/analyses?url=http://169.254.169.254",
+			setupExpectations: func(mockAnalyser *mocks.MockAnalyser) {
+				u, _ := netUrl.Parse("http://169.254.169.254")
+				mockAnalyser.EXPECT().Analyse(gomock.Any(), u).Return(nil, fmt.Errorf("dial: %w", iHttp.ErrBlockedAddress), 0)
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedBody:       fmt.Sprintf(`{"error":{"message":%q}}`, iError.BlockedURLError),
+		},
+		{
+			name:   "Should return unprocessable entity if the url doesn't point to an HTML page",
+			method: http.MethodGet,
+			target: "/api/v1/analyses?url=https://google.com",
+			setupExpectations: func(mockAnalyser *mocks.MockAnalyser) {
+				u, _ := netUrl.Parse("https://google.com")
+				mockAnalyser.EXPECT().Analyse(gomock.Any(), u).Return(nil,
+					&analyser.ContentTypeError{ContentType: "application/pdf"}, 200)
+			},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedBody: fmt.Sprintf(`{"error":{"message":%q}}`,
+				string(iError.UnsupportedContentTypeError)+"application/pdf"),
+		},
+		{
+			name:   "Should return service unavailable if the analysis is cancelled",
+			method: http.MethodGet,
+			target: "/api/v1/analyses?url=https://google.com",
+			setupExpectations: func(mockAnalyser *mocks.MockAnalyser) {
+				u, _ := netUrl.Parse("https://google.com")
+				mockAnalyser.EXPECT().Analyse(gomock.Any(), u).Return(nil, context.Canceled, 200)
+			},
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedBody:       fmt.Sprintf(`{"error":{"message":%q}}`, iError.AnalysisCancelledError),
+		},
+		{
+			name:        "Should return the summary for the url in the JSON body",
+			method:      http.MethodPost,
+			target:      "/api/v1/analyses",
+			contentType: "application/json",
+			body:        `{"url":"https://google.com"}`,
+			setupExpectations: func(mockAnalyser *mocks.MockAnalyser) {
+				u, _ := netUrl.Parse("https://google.com")
+				summary := analyser.NewSummary(u)
+				summary.SetVersion("HTML 5")
+				summary.IncrementHeadersCount("h1")
+				summary.AddInternalLink("/b")
+				summary.AddInternalLink("/a")
+				summary.AddExternalLink("https://www.facebook.com")
+				summary.SetHasLoginForm(true)
+				mockAnalyser.EXPECT().Analyse(gomock.Any(), u).Return(summary, nil, 200)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBody: `{"url":"https://google.com","version":"HTML 5","title":"","headersCount":{"h1":1},` +
+				`"hasLoginForm":true,"internalLinks":["/a","/b"],"externalLinks":["https://www.facebook.com"],` +
+				`"inaccessibleLinks":[]}`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockedAnalyser := mocks.NewMockAnalyser(ctrl)
+			mockedTemplate := mocks.NewMockTemplate(ctrl)
+			logger := l.NewLogger(false)
+			handler := analyser.NewHandler(logger, mockedTemplate, mockedAnalyser, newRecorder(ctrl))
+			w := httptest.NewRecorder()
+
+			r := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			if tc.contentType != "" {
+				r.Header.Set("Content-Type", tc.contentType)
+			}
+			if tc.setupExpectations != nil {
+				tc.setupExpectations(mockedAnalyser)
+			}
+
+			handler.Analysis(w, r)
+
+			body, _ := io.ReadAll(w.Result().Body)
+			if w.Code != tc.expectedStatusCode {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedStatusCode, w.Code)
+			}
+			assertJSONContains(t, tc.expectedBody, body)
+			if contentType := w.Header().Get("Content-Type"); contentType != "application/json" {
+				t.Fatalf("Expected:%v, Got:%v", "application/json", contentType)
+			}
+		})
+	}
+}
+
+func TestHandlerImpl_Analysis_Record(t *testing.T) {
+	tests := []*struct {
+		name        string
+		recordError error
+	}{
+		{name: "Should record the analysis"},
+		{name: "Should return the summary even if the analysis can't be recorded", recordError: errors.New("error")},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockedAnalyser := mocks.NewMockAnalyser(ctrl)
+			mockedRecorder := mocks.NewMockRecorder(ctrl)
+			handler := analyser.NewHandler(l.NewLogger(false), mocks.NewMockTemplate(ctrl), mockedAnalyser,
+				mockedRecorder)
+			w := httptest.NewRecorder()
+
+			u, _ := netUrl.Parse("https://google.com")
+			summary := analyser.NewSummary(u)
+			mockedAnalyser.EXPECT().Analyse(gomock.Any(), u).Return(summary, nil, 200)
+			mockedRecorder.EXPECT().Record("request-id", summary, 200, gomock.Any()).Return(tc.recordError)
+
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/analyses?url=https://google.com", nil)
+			r = r.WithContext(iCtx.SetRequestID(r.Context(), "request-id"))
+			handler.Analysis(w, r)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected:%v, Got:%v", http.StatusOK, w.Code)
+			}
+		})
+	}
+}
+
+// newRecorder returns a recorder accepting any analysis, for the tests not concerned with the recording
+func newRecorder(ctrl *gomock.Controller) *mocks.MockRecorder {
+	recorder := mocks.NewMockRecorder(ctrl)
+	recorder.EXPECT().Record(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	return recorder
+}
+
+// assertJSONContains checks that every top level field of the expected JSON object is present in the actual JSON
+// object with the same value, so that the tests don't break whenever a field is added to the response
+func assertJSONContains(t *testing.T, expected string, actual []byte) {
+	t.Helper()
+	var expectedFields, actualFields map[string]any
+	if err := json.Unmarshal([]byte(expected), &expectedFields); err != nil {
+		t.Fatalf("invalid expected JSON: %v", err)
+	}
+	if err := json.Unmarshal(actual, &actualFields); err != nil {
+		t.Fatalf("Expected JSON, Got:%s", actual)
+	}
+	for key, value := range expectedFields {
+		if !reflect.DeepEqual(value, actualFields[key]) {
+			t.Fatalf("Expected %v:%v, Got:%v", key, value, actualFields[key])
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package analyser
+
+import (
+	"crypto/tls"
+	"golang.org/x/net/html"
+	"net/http"
+	"net/url"
+)
+
+// Inspector is an analysis rule of the HTML page. The inspectors are created once along with the analyser and
+// shared across the analyses, so any state of an analysis is kept in the Inspection returned by Inspect.
+type Inspector interface {
+	// Name returns the unique name of the inspector, used to enable it via the config and to name its report
+	Name() string
+	// Inspect starts the inspection of the page
+	Inspect(page *Page) Inspection
+}
+
+// Inspection is the inspection of a single HTML page, it is visited with each node of the page during a single
+// walk of the page and then finished with the summary to contribute its results to
+type Inspection interface {
+	// Visit inspects the node, the nodes are visited in document order
+	Visit(n *html.Node)
+	// Finish sets the results of the inspection in the summary, either in the fields of the summary or as a report
+	Finish(summary *Summary)
+}
+
+// Page represents the loaded HTML page being inspected
+type Page struct {
+	URL    *url.URL             // URL represents the final URL the page was loaded from
+	Header http.Header          // Header represents the http headers of the response
+	TLS    *tls.ConnectionState // TLS represents the state of the connection, nil if the page was loaded over http
+}
+
+// enabledInspectors returns the inspectors whose names are listed, all the inspectors are enabled if none is listed
+func enabledInspectors(inspectors []Inspector, names []string) []Inspector {
+	if len(names) == 0 {
+		return inspectors
+	}
+
+	enabled := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		enabled[name] = struct{}{}
+	}
+
+	var filtered []Inspector
+	for _, inspector := range inspectors {
+		if _, ok := enabled[inspector.Name()]; ok {
+			filtered = append(filtered, inspector)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,109 @@
+package analyser_test
+
+import (
+	"context"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/net/html"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"web-analyser/api/backend/analyser"
+	"web-analyser/config"
+	"web-analyser/mocks"
+)
+
+func TestAnalyserImpl_Analyse_Inspectors(t *testing.T) {
+	tests := []*struct {
+		name               string
+		inspectors         []string
+		expectedTitle      string
+		expectedHeaders    map[string]int
+		expectedLinksCount int
+	}{
+		{
+			name:               "Should run the registered inspector along with the built-in inspectors",
+			expectedTitle:      "Title",
+			expectedHeaders:    map[string]int{"h1": 1},
+			expectedLinksCount: 1,
+		},
+		{
+			name:            "Should run only the enabled inspectors",
+			inspectors:      []string{analyser.InspectorTitle, "images"},
+			expectedTitle:   "Title",
+			expectedHeaders: map[string]int{},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockClient := mocks.NewMockClient(ctrl)
+			mockLinkChecker := mocks.NewMockLinkChecker(ctrl)
+			mockInspector := mocks.NewMockInspector(ctrl)
+			mockInspection := mocks.NewMockInspection(ctrl)
+
+			d := "<html><title>Title</title><h1>Heading</h1><a href='/a'><img src='/a.png'></a><img src='/b.png'></html>"
+			mockClient.EXPECT().Get(gomock.Any(), "https://google.com").Return(&http.Response{
+				Body:       io.NopCloser(strings.NewReader(d)),
+				StatusCode: 200,
+				Header:     http.Header{"Content-Type": []string{"text/html"}},
+			}, nil)
+			if tc.expectedLinksCount > 0 {
+				mockLinkChecker.EXPECT().Check(gomock.Any(), gomock.Len(tc.expectedLinksCount), gomock.Any()).Return(
+					map[string]*analyser.LinkStatus{})
+			}
+
+			// the inspector counts the images of the page
+			images := 0
+			mockInspector.EXPECT().Name().Return("images").AnyTimes()
+			mockInspector.EXPECT().Inspect(gomock.Any()).DoAndReturn(func(page *analyser.Page) analyser.Inspection {
+				if page.URL.String() != "https://google.com" || page.Header.Get("Content-Type") != "text/html" {
+					t.Fatalf("Expected:%v, Got:%v %v", "https://google.com text/html", page.URL, page.Header)
+				}
+				return mockInspection
+			})
+			mockInspection.EXPECT().Visit(gomock.Any()).Do(func(n *html.Node) {
+				if n.Type == html.ElementNode && n.Data == "img" {
+					images++
+				}
+			}).AnyTimes()
+			mockInspection.EXPECT().Finish(gomock.Any()).Do(func(summary *analyser.Summary) {
+				report := analyser.NewReport("images")
+				report.SetValue("count", images)
+				report.AddFinding(analyser.SeverityWarning, "images without alt text")
+				summary.AddReport(report)
+			})
+
+			conf := &config.AnalyserConf{SameSitePolicy: "host", MaxRedirects: 10, Inspectors: tc.inspectors}
+			a := analyser.NewAnalyser(mockClient, mockLinkChecker, conf, mockInspector)
+
+			u, _ := url.Parse("https://google.com")
+			summary, err, _ := a.Analyse(context.Background(), u)
+			if err != nil {
+				t.Fatalf("Expected:%v, Got:%v", nil, err)
+			}
+			if summary.Title != tc.expectedTitle {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedTitle, summary.Title)
+			}
+			if !reflect.DeepEqual(tc.expectedHeaders, summary.HeadersCount) {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedHeaders, summary.HeadersCount)
+			}
+			if len(summary.InternalLinksMap) != tc.expectedLinksCount {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedLinksCount, len(summary.InternalLinksMap))
+			}
+
+			expectedReport := &analyser.Report{
+				Name:     "images",
+				Values:   map[string]any{"count": 2},
+				Findings: []*analyser.Finding{{Severity: analyser.SeverityWarning, Message: "images without alt text"}},
+			}
+			if !reflect.DeepEqual(expectedReport, summary.Report("images")) {
+				t.Fatalf("Expected:%+v, Got:%+v", expectedReport, summary.Report("images"))
+			}
+		})
+	}
+}
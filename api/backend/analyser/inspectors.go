@@ -0,0 +1,338 @@
+package analyser
+
+import (
+	"golang.org/x/net/html"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"web-analyser/config"
+	iHtml "web-analyser/internal/utils/html"
+	iHttp "web-analyser/internal/utils/http"
+)
+
+// names of the built-in inspectors
+const (
+	InspectorVersion   = "version"
+	InspectorTitle     = "title"
+	InspectorHeadings  = "headings"
+	InspectorLinks     = "links"
+	InspectorLoginForm = "login_form"
+	InspectorForms     = "forms"
+	InspectorSEO       = "seo"
+	InspectorA11y      = "a11y"
+	InspectorSecurity  = "security"
+)
+
+// BuiltInInspectors returns the inspectors setting the fields of the summary and the audits of the page
+func BuiltInInspectors(conf *config.AnalyserConf) []Inspector {
+	return []Inspector{
+		&versionInspector{},
+		&titleInspector{},
+		&headingsInspector{},
+		&linksInspector{conf: conf},
+		&loginFormInspector{},
+		&formsInspector{},
+		&seoInspector{},
+		&a11yInspector{},
+		&securityInspector{},
+	}
+}
+
+// versionInspector sets the HTML version of the page from its doctype
+type versionInspector struct{}
+
+func (i *versionInspector) Name() string { return InspectorVersion }
+
+func (i *versionInspector) Inspect(page *Page) Inspection { return &versionInspection{} }
+
+type versionInspection struct {
+	version string
+}
+
+func (i *versionInspection) Visit(n *html.Node) {
+	if n.Type == html.DoctypeNode {
+		i.version = strings.ToUpper(iHtml.Version(n))
+	}
+}
+
+func (i *versionInspection) Finish(summary *Summary) {
+	summary.SetVersion(i.version)
+}
+
+// titleInspector sets the title of the page, the last title element wins
+type titleInspector struct{}
+
+func (i *titleInspector) Name() string { return InspectorTitle }
+
+func (i *titleInspector) Inspect(page *Page) Inspection { return &titleInspection{} }
+
+type titleInspection struct {
+	title string
+}
+
+func (i *titleInspection) Visit(n *html.Node) {
+	if n.Type == html.ElementNode && n.Data == "title" {
+		i.title = iHtml.Text(n)
+	}
+}
+
+func (i *titleInspection) Finish(summary *Summary) {
+	summary.SetTitle(i.title)
+}
+
+// headingsInspector counts the headings of each level of the page
+type headingsInspector struct{}
+
+func (i *headingsInspector) Name() string { return InspectorHeadings }
+
+func (i *headingsInspector) Inspect(page *Page) Inspection { return &headingsInspection{} }
+
+type headingsInspection struct {
+	headings []string
+}
+
+func (i *headingsInspection) Visit(n *html.Node) {
+	if n.Type != html.ElementNode {
+		return
+	}
+	switch n.Data {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		i.headings = append(i.headings, n.Data)
+	}
+}
+
+func (i *headingsInspection) Finish(summary *Summary) {
+	for _, heading := range i.headings {
+		summary.IncrementHeadersCount(heading)
+	}
+}
+
+// linksInspector resolves the links of the anchors of the page and classifies them as internal or external links
+type linksInspector struct {
+	conf *config.AnalyserConf
+}
+
+func (i *linksInspector) Name() string { return InspectorLinks }
+
+func (i *linksInspector) Inspect(page *Page) Inspection {
+	return &linksInspection{conf: i.conf, page: page}
+}
+
+type linksInspection struct {
+	conf     *config.AnalyserConf
+	page     *Page
+	baseHref string
+	links    []string
+}
+
+func (i *linksInspection) Visit(n *html.Node) {
+	if n.Type != html.ElementNode {
+		return
+	}
+	switch n.Data {
+	case "base":
+		// the links are resolved against the href of the first base element, wherever the links are
+		if i.baseHref == "" {
+			i.baseHref = iHtml.BaseHref(n)
+		}
+	case "a":
+		for _, attr := range n.Attr {
+			if attr.Key == "href" {
+				i.links = append(i.links, attr.Val)
+				break
+			}
+		}
+	}
+}
+
+func (i *linksInspection) Finish(summary *Summary) {
+	// the links are resolved against the href of the base element if present, otherwise against the page url
+	baseURL := i.page.URL
+	if i.baseHref != "" {
+		if u, err := i.page.URL.Parse(i.baseHref); err == nil {
+			baseURL = u
+		}
+	}
+
+	for _, link := range i.links {
+		i.addLink(summary, baseURL, link)
+	}
+}
+
+// addLink resolves the link against the base URL and adds the normalised link to the internal or external links as
+// per the same site policy compared to the page URL, the links which can't be parsed are added to the inaccessible
+// links. The normalised link only deduplicates the links, the resolved link is the one loaded, see checkLinks.
+func (i *linksInspection) addLink(summary *Summary, baseURL *url.URL, link string) {
+	u, err := baseURL.Parse(strings.TrimSpace(link))
+	if err != nil {
+		summary.AddInaccessibleLink(link)
+		return
+	}
+	if u.Scheme == "mailto" || u.Scheme == "tel" || u.Scheme == "javascript" {
+		return
+	}
+
+	// the fragment is never sent to the server
+	u.Fragment, u.RawFragment = "", ""
+	summary.AddResolvedLink(link, u.String())
+	normalisedLink := iHttp.NormaliseURL(u, i.conf.SortQueryParams).String()
+	if iHttp.IsSameSite(iHttp.SameSitePolicy(i.conf.SameSitePolicy), i.page.URL.Hostname(), u.Hostname(),
+		i.conf.SiteAliases) {
+		summary.AddInternalLink(normalisedLink)
+	} else {
+		summary.AddExternalLink(normalisedLink)
+	}
+}
+
+// loginFormInspector classifies the forms of the page as login, signup, password reset or search forms, along with
+// the fields of a form without a form element, such as a password field grouped with a button in a div
+type loginFormInspector struct{}
+
+func (i *loginFormInspector) Name() string { return InspectorLoginForm }
+
+func (i *loginFormInspector) Inspect(page *Page) Inspection {
+	return &loginFormInspection{labels: map[string]string{}}
+}
+
+type loginFormInspection struct {
+	forms      []*html.Node
+	containers []*html.Node
+	// labels are the labels of the document, collected while visiting it so that the forms share them
+	labels map[string]string
+}
+
+func (i *loginFormInspection) Visit(n *html.Node) {
+	if n.Type != html.ElementNode || n.Namespace != "" {
+		return
+	}
+	switch n.Data {
+	case "label":
+		iHtml.AddLabel(n, i.labels)
+	case "form":
+		i.forms = append(i.forms, n)
+	case "input":
+		if !isFormlessLoginField(n) {
+			return
+		}
+		container := iHtml.FormContainer(n)
+		if container != nil && !slices.Contains(i.containers, container) {
+			i.containers = append(i.containers, container)
+		}
+	}
+}
+
+func (i *loginFormInspection) Finish(summary *Summary) {
+	for _, n := range append(i.forms, i.containers...) {
+		if class := iHtml.ClassifyForm(n, i.labels); class != nil {
+			summary.AddDetectedForm(&DetectedForm{Selector: iHtml.Selector(n), Kind: class.Kind,
+				Confidence: class.Confidence, Reasons: class.Reasons})
+		}
+	}
+}
+
+// csrfFieldNames are the lower cased names of the hidden fields holding a CSRF token in the common frameworks, the
+// names are matched exactly since the other hidden fields often hold a token as well, such as a page token
+var csrfFieldNames = map[string]struct{}{
+	"csrf": {}, "_csrf": {}, "csrf_token": {}, "_csrf_token": {}, "csrftoken": {}, "csrfmiddlewaretoken": {},
+	"xsrf": {}, "_xsrf": {}, "xsrf_token": {}, "authenticity_token": {}, "__requestverificationtoken": {},
+	"_token": {}, "yii_csrf_token": {}, "__csrf_magic": {}, "form_key": {},
+}
+
+// formsInspector lists the forms of the page with the URL they are submitted to and their fields
+type formsInspector struct{}
+
+func (i *formsInspector) Name() string { return InspectorForms }
+
+func (i *formsInspector) Inspect(page *Page) Inspection { return &formsInspection{page: page} }
+
+type formsInspection struct {
+	page     *Page
+	baseHref string
+	forms    []*html.Node
+}
+
+func (i *formsInspection) Visit(n *html.Node) {
+	if n.Type != html.ElementNode || n.Namespace != "" {
+		return
+	}
+	switch n.Data {
+	case "base":
+		// the actions are resolved against the href of the first base element, wherever the forms are
+		if i.baseHref == "" {
+			i.baseHref = iHtml.BaseHref(n)
+		}
+	case "form":
+		i.forms = append(i.forms, n)
+	}
+}
+
+func (i *formsInspection) Finish(summary *Summary) {
+	baseURL := i.page.URL
+	if i.baseHref != "" {
+		if u, err := i.page.URL.Parse(i.baseHref); err == nil {
+			baseURL = u
+		}
+	}
+
+	for _, n := range i.forms {
+		form := &Form{Selector: iHtml.Selector(n), Method: http.MethodGet, Fields: []*FormField{}}
+		if method, _ := iHtml.Attr(n, "method"); strings.EqualFold(strings.TrimSpace(method), http.MethodPost) ||
+			strings.EqualFold(strings.TrimSpace(method), "dialog") {
+			form.Method = strings.ToUpper(strings.TrimSpace(method))
+		}
+		i.setAction(form, n, baseURL)
+
+		for _, field := range iHtml.InputFields(n) {
+			form.Fields = append(form.Fields, &FormField{Type: field.Type, Name: field.Name, Required: field.Required,
+				Autocomplete: field.Autocomplete})
+			switch {
+			case field.Type == "file":
+				form.HasFileUpload = true
+			case field.Type == "hidden" && isCSRFField(field.Name):
+				form.HasCSRFToken = true
+			}
+		}
+		summary.AddForm(form)
+	}
+}
+
+// setAction resolves the action of the form, a form without an action is submitted to the page URL while the other
+// actions are resolved against the base URL, and flags the http actions submitted to another origin or over http
+func (i *formsInspection) setAction(form *Form, n *html.Node, baseURL *url.URL) {
+	u := i.page.URL
+	if action, _ := iHtml.Attr(n, "action"); strings.TrimSpace(action) != "" {
+		var err error
+		if u, err = baseURL.Parse(strings.TrimSpace(action)); err != nil {
+			form.Action = action
+			return
+		}
+	}
+
+	form.Action = u.String()
+	if u.Scheme == "http" || u.Scheme == "https" {
+		form.CrossOrigin = !iHttp.IsSameOrigin(i.page.URL, u)
+		form.Insecure = u.Scheme == "http"
+	}
+}
+
+// isCSRFField returns whether the name is the name of a hidden field holding a CSRF token, see csrfFieldNames
+func isCSRFField(name string) bool {
+	_, ok := csrfFieldNames[strings.ToLower(strings.TrimSpace(name))]
+	return ok
+}
+
+// isFormlessLoginField returns whether the input is a password or username field which isn't in a form element
+func isFormlessLoginField(n *html.Node) bool {
+	inputType, _ := iHtml.Attr(n, "type")
+	autocomplete, _ := iHtml.Attr(n, "autocomplete")
+	if !strings.EqualFold(inputType, "password") && !strings.Contains(strings.ToLower(autocomplete), "username") {
+		return false
+	}
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && p.Data == "form" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,182 @@
+package analyser
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	netUrl "net/url"
+	"sort"
+	"sync"
+	"time"
+	"web-analyser/config"
+	iHttp "web-analyser/internal/utils/http"
+)
+
+// LinkChecker checks whether the links found in the HTML page can be loaded
+type LinkChecker interface {
+	// Check returns the status of each link, the requests are bound to the context. The progress func is optional
+	// and called with the number of links checked so far out of the links to load, every time a link is checked
+	Check(ctx context.Context, links []string, progress func(checked int, total int)) map[string]*LinkStatus
+}
+
+type LinkCheckerImpl struct {
+	httpClient iHttp.Client
+	conf       *config.ClientConf
+}
+
+func NewLinkChecker(httpClient iHttp.Client, conf *config.ClientConf) *LinkCheckerImpl {
+	return &LinkCheckerImpl{
+		httpClient: httpClient,
+		conf:       conf,
+	}
+}
+
+// Check loads the given absolute links using a bounded pool of workers and returns the status of each link.
+// At most LinkCheckMaxLinks links are loaded, the rest are marked as skipped. Links which are not loaded
+// within LinkCheckDeadline, or before the context is done, are marked as timed out.
+func (c *LinkCheckerImpl) Check(ctx context.Context, links []string,
+	progress func(checked int, total int)) map[string]*LinkStatus {
+	if progress == nil {
+		progress = func(int, int) {}
+	}
+	statuses := make(map[string]*LinkStatus, len(links))
+
+	// sort the links so that the same links are skipped on every run when there are more than the max links
+	links = append([]string(nil), links...)
+	sort.Strings(links)
+	if len(links) > c.conf.LinkCheckMaxLinks {
+		for _, link := range links[c.conf.LinkCheckMaxLinks:] {
+			statuses[link] = &LinkStatus{ErrorClass: LinkErrorSkipped}
+		}
+		links = links[:c.conf.LinkCheckMaxLinks]
+	}
+
+	// one semaphore per host to limit the concurrent requests sent to the same host
+	hostSemaphores := make(map[string]chan struct{})
+	for _, link := range links {
+		host := hostOf(link)
+		if _, ok := hostSemaphores[host]; !ok {
+			hostSemaphores[host] = make(chan struct{}, max(c.conf.LinkCheckPerHost, 1))
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.conf.LinkCheckDeadline)
+	defer cancel()
+
+	progress(0, len(links))
+	checked := 0
+	linkChan := make(chan string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < min(max(c.conf.LinkCheckConcurrency, 1), len(links)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for link := range linkChan {
+				sem := hostSemaphores[hostOf(link)]
+
+				var status *LinkStatus
+				select {
+				case sem <- struct{}{}:
+					status = c.check(ctx, link)
+					<-sem
+				case <-ctx.Done():
+					status = &LinkStatus{ErrorClass: LinkErrorTimeout}
+				}
+
+				mu.Lock()
+				statuses[link] = status
+				checked++
+				progress(checked, len(links))
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, link := range links {
+		linkChan <- link
+	}
+	close(linkChan)
+	wg.Wait()
+
+	return statuses
+}
+
+// check loads the link with a HEAD request and falls back to a GET request if the server refuses the HEAD request,
+// since some servers don't implement HEAD properly
+func (c *LinkCheckerImpl) check(ctx context.Context, link string) *LinkStatus {
+	start := time.Now()
+	statusCode, err := c.request(ctx, http.MethodHead, link)
+	if err == nil && statusCode >= http.StatusBadRequest {
+		statusCode, err = c.request(ctx, http.MethodGet, link)
+	}
+
+	status := &LinkStatus{
+		StatusCode: statusCode,
+		Latency:    time.Since(start),
+	}
+	if err != nil {
+		status.ErrorClass = classifyLinkError(err)
+	} else if statusCode >= http.StatusBadRequest {
+		status.ErrorClass = LinkErrorHttpStatus
+	}
+	return status
+}
+
+// request sends a request with the per link timeout and returns the http status code, the body is not read
+func (c *LinkCheckerImpl) request(ctx context.Context, method string, link string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.conf.LinkCheckTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, link, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// classifyLinkError maps the error returned by the http client to a LinkErrorClass
+func classifyLinkError(err error) LinkErrorClass {
+	var netErr net.Error
+	var dnsErr *net.DNSError
+	var certErr *tls.CertificateVerificationError
+	var hostnameErr x509.HostnameError
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var certInvalidErr x509.CertificateInvalidError
+	var tlsRecordErr tls.RecordHeaderError
+	var opErr *net.OpError
+
+	switch {
+	case errors.Is(err, iHttp.ErrBlockedAddress):
+		return LinkErrorBlocked
+	case errors.As(err, &dnsErr):
+		return LinkErrorDNS
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return LinkErrorTimeout
+	case errors.As(err, &certErr), errors.As(err, &hostnameErr), errors.As(err, &unknownAuthorityErr),
+		errors.As(err, &certInvalidErr), errors.As(err, &tlsRecordErr):
+		return LinkErrorTLS
+	case errors.As(err, &opErr):
+		return LinkErrorConnection
+	default:
+		return LinkErrorUnknown
+	}
+}
+
+// hostOf returns the host of the absolute link
+func hostOf(link string) string {
+	u, err := netUrl.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
@@ -0,0 +1,148 @@
+package analyser_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+	"web-analyser/api/backend/analyser"
+	"web-analyser/config"
+	iHttp "web-analyser/internal/utils/http"
+)
+
+func TestLinkCheckerImpl_Check(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusOK)
+		case "/head-not-allowed":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []*struct {
+		name             string
+		conf             *config.ClientConf
+		links            []string
+		expectedStatuses map[string]*analyser.LinkStatus
+	}{
+		{
+			name: "Should return the status code of each link",
+			links: []string{
+				server.URL + "/ok",
+				server.URL + "/head-not-allowed",
+				server.URL + "/missing",
+			},
+			expectedStatuses: map[string]*analyser.LinkStatus{
+				server.URL + "/ok":               {StatusCode: http.StatusOK},
+				server.URL + "/head-not-allowed": {StatusCode: http.StatusOK},
+				server.URL + "/missing":          {StatusCode: http.StatusNotFound, ErrorClass: analyser.LinkErrorHttpStatus},
+			},
+		},
+		{
+			name:  "Should mark the link as timed out if it doesn't respond within the link timeout",
+			links: []string{server.URL + "/slow"},
+			conf: &config.ClientConf{
+				LinkCheckTimeout:     50 * time.Millisecond,
+				LinkCheckDeadline:    time.Second,
+				LinkCheckMaxLinks:    10,
+				LinkCheckConcurrency: 2,
+				LinkCheckPerHost:     1,
+			},
+			expectedStatuses: map[string]*analyser.LinkStatus{
+				server.URL + "/slow": {ErrorClass: analyser.LinkErrorTimeout},
+			},
+		},
+		{
+			name:  "Should mark the links as timed out if they are not loaded within the deadline",
+			links: []string{server.URL + "/slow", server.URL + "/slow?page=2"},
+			conf: &config.ClientConf{
+				LinkCheckTimeout:     time.Second,
+				LinkCheckDeadline:    50 * time.Millisecond,
+				LinkCheckMaxLinks:    10,
+				LinkCheckConcurrency: 2,
+				LinkCheckPerHost:     1,
+			},
+			expectedStatuses: map[string]*analyser.LinkStatus{
+				server.URL + "/slow":        {ErrorClass: analyser.LinkErrorTimeout},
+				server.URL + "/slow?page=2": {ErrorClass: analyser.LinkErrorTimeout},
+			},
+		},
+		{
+			name:  "Should skip the links over the max links limit",
+			links: []string{server.URL + "/ok?page=2", server.URL + "/ok?page=1"},
+			conf: &config.ClientConf{
+				LinkCheckTimeout:     time.Second,
+				LinkCheckDeadline:    time.Second,
+				LinkCheckMaxLinks:    1,
+				LinkCheckConcurrency: 2,
+				LinkCheckPerHost:     1,
+			},
+			expectedStatuses: map[string]*analyser.LinkStatus{
+				server.URL + "/ok?page=1": {StatusCode: http.StatusOK},
+				server.URL + "/ok?page=2": {ErrorClass: analyser.LinkErrorSkipped},
+			},
+		},
+		{
+			name:  "Should classify the error of the links which can't be reached",
+			links: []string{"http://invalid.invalid/"},
+			expectedStatuses: map[string]*analyser.LinkStatus{
+				"http://invalid.invalid/": {ErrorClass: analyser.LinkErrorDNS},
+			},
+		},
+		{
+			name:  "Should mark the links pointing to internal addresses as blocked",
+			links: []string{"http://169.254.169.254/latest/meta-data", "http://[::1]/"},
+			expectedStatuses: map[string]*analyser.LinkStatus{
+				"http://169.254.169.254/latest/meta-data": {ErrorClass: analyser.LinkErrorBlocked},
+				"http://[::1]/": {ErrorClass: analyser.LinkErrorBlocked},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			conf := tc.conf
+			if conf == nil {
+				conf = &config.ClientConf{
+					LinkCheckTimeout:     time.Second,
+					LinkCheckDeadline:    2 * time.Second,
+					LinkCheckMaxLinks:    10,
+					LinkCheckConcurrency: 2,
+					LinkCheckPerHost:     1,
+				}
+			}
+			// the test server listens on the loopback address which is blocked by default
+			httpClient, _ := iHttp.NewHttpClient(&config.ClientConf{AllowedAddresses: []string{"127.0.0.1"}})
+			linkChecker := analyser.NewLinkChecker(httpClient, conf)
+
+			// the progress ends with all the links to load checked
+			var checked, total int
+			statuses := linkChecker.Check(context.Background(), tc.links, func(c int, t int) { checked, total = c, t })
+			if checked != total || total != min(len(tc.links), conf.LinkCheckMaxLinks) {
+				t.Fatalf("Expected:%v of %v, Got:%v of %v", total, min(len(tc.links), conf.LinkCheckMaxLinks),
+					checked, total)
+			}
+			// the latency differs on every run, so it is only checked for the links which were loaded
+			for link, status := range statuses {
+				if status.StatusCode != 0 && status.Latency <= 0 {
+					t.Fatalf("Expected latency for %v, Got:%v", link, status.Latency)
+				}
+				status.Latency = 0
+			}
+			if !reflect.DeepEqual(tc.expectedStatuses, statuses) {
+				t.Fatalf("Expected:%+v, Got:%+v", tc.expectedStatuses, statuses)
+			}
+		})
+	}
+}
@@ -1,20 +1,258 @@
 package analyser
 
-import "net/url"
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"time"
+	iError "web-analyser/internal/utils/error"
+	iHtml "web-analyser/internal/utils/html"
+)
 
 // Summary represents the summary of HTML page.
 type Summary struct {
 	// Using struct{} in map since it doesn't occupy memory, and we are interested in unique elements,
 	// so it can be used as signals whether the element is present or not
 	// https://dave.cheney.net/2014/03/25/the-empty-struct
-	URL                  *url.URL            // URL represents the URL of the summarised HTML page
-	Version              string              // Version represents the HTML Version
-	Title                string              // Title represents the HTML page Title
-	HeadersCount         map[string]int      // HeadersCount represents the count of each header type
-	InternalLinksMap     map[string]struct{} // InternalLinksMap represents internal links found in the HTML page
-	ExternalLinksMap     map[string]struct{} // ExternalLinksMap represents external links found in the HTML page
-	InaccessibleLinksMap map[string]struct{} // InaccessibleLinksMap represents inaccessible links found in the HTML page
-	HasLoginForm         bool                // HasLoginForm represents if the HTML page contains a login form
+	URL                  *url.URL            `json:"-"`            // URL represents the URL of the summarised HTML page
+	Version              string              `json:"version"`      // Version represents the HTML Version
+	Title                string              `json:"title"`        // Title represents the HTML page Title
+	HeadersCount         map[string]int      `json:"headersCount"` // HeadersCount represents the count of each header type
+	InternalLinksMap     map[string]struct{} `json:"-"`            // InternalLinksMap represents internal links found in the HTML page
+	ExternalLinksMap     map[string]struct{} `json:"-"`            // ExternalLinksMap represents external links found in the HTML page
+	InaccessibleLinksMap map[string]struct{} `json:"-"`            // InaccessibleLinksMap represents links which failed to parse or load
+	HasLoginForm         bool                `json:"hasLoginForm"` // HasLoginForm represents if the HTML page contains a login form
+	// ResolvedLinks represents the links as found in the HTML page mapped to the resolved links, which are loaded
+	// while their normalised links are stored in the InternalLinksMap and ExternalLinksMap
+	ResolvedLinks map[string]string `json:"resolvedLinks"`
+	// LinkStatuses represents the result of loading each internal and external link
+	LinkStatuses map[string]*LinkStatus `json:"linkStatuses"`
+	// FinalURL represents the URL the HTML page was loaded from after following the redirects
+	FinalURL *url.URL `json:"-"`
+	// RedirectChain represents the redirects followed from the URL to the FinalURL in order
+	RedirectChain []*RedirectHop `json:"redirectChain"`
+	// Reports represents the results of the inspectors which have no dedicated field, in the inspectors order
+	Reports []*Report `json:"reports"`
+	// DetectedForms represents the login, signup, password reset and search forms found in the HTML page, the form
+	// elements come first and then the fields grouped without a form element, HasLoginForm is set if any of them is a
+	// login form
+	DetectedForms []*DetectedForm `json:"detectedForms"`
+	// Forms represents the inventory of the forms of the HTML page, in the document order
+	Forms []*Form `json:"forms"`
+	// Encoding represents the character encoding the HTML page was decoded with and the charsets it declares
+	Encoding *Encoding `json:"encoding"`
+	// Content represents the media type and the size of the HTML page
+	Content *Content `json:"content"`
+	// Fetch represents the attempts made to load the HTML page
+	Fetch *Fetch `json:"fetch"`
+}
+
+// Fetch represents the requests sent to load the HTML page
+type Fetch struct {
+	// Attempts represents the number of requests sent, including the redirects and the retries of the transient errors
+	Attempts int `json:"attempts"`
+	// Duration represents the total time taken to load the page, including the delays between the retries
+	Duration time.Duration `json:"duration"`
+}
+
+// Content represents the media type and the size of the body of the HTML page
+type Content struct {
+	// Type represents the media type the page is parsed as, text/html or application/xhtml+xml, an XHTML page which
+	// isn't well-formed is parsed as text/html
+	Type         string `json:"type"`
+	DeclaredType string `json:"declaredType,omitempty"` // DeclaredType represents the media type of the header
+	SniffedType  string `json:"sniffedType"`            // SniffedType represents the media type sniffed from the body
+	Size         int64  `json:"size"`                   // Size represents the size of the body in bytes
+	// Truncated represents if the body was cut short by the max body size or the max compression ratio of the client,
+	// the page is analysed up to the limit and the Size is the size read
+	Truncated bool `json:"truncated"`
+}
+
+// EncodingSource represents how the character encoding of the HTML page was found
+type EncodingSource string
+
+const (
+	EncodingSourceBOM     EncodingSource = "bom"     // the page starts with a byte order mark
+	EncodingSourceHeader  EncodingSource = "header"  // the charset of the Content-Type header
+	EncodingSourceMeta    EncodingSource = "meta"    // the charset of a meta element of the page
+	EncodingSourceDefault EncodingSource = "default" // none is declared, UTF-8 if the page is valid, else windows-1252
+)
+
+// Encoding represents the character encoding of the HTML page, the charsets are the canonical names of the encodings,
+// such as shift_jis or windows-1251, unless they are unknown
+type Encoding struct {
+	Charset       string         `json:"charset"`                 // Charset represents the encoding the page is read with
+	Source        EncodingSource `json:"source"`                  // Source represents how the encoding was found
+	HeaderCharset string         `json:"headerCharset,omitempty"` // HeaderCharset represents the charset of the header
+	MetaCharset   string         `json:"metaCharset,omitempty"`   // MetaCharset represents the charset of the meta
+	// Mismatch represents if the Content-Type header and the meta element declare different charsets
+	Mismatch bool `json:"mismatch"`
+}
+
+// Form represents a form of the HTML page along with its fields
+type Form struct {
+	Selector string `json:"selector"` // Selector represents the CSS selector path of the form
+	Method   string `json:"method"`   // Method represents the http method the form is submitted with, GET by default
+	// Action represents the URL the form is submitted to, resolved against the base href or the page URL
+	Action        string       `json:"action"`
+	CrossOrigin   bool         `json:"crossOrigin"`   // CrossOrigin represents if the form is submitted to another origin
+	Insecure      bool         `json:"insecure"`      // Insecure represents if the form is submitted over plain http
+	HasCSRFToken  bool         `json:"hasCsrfToken"`  // HasCSRFToken represents if the form has a hidden CSRF token
+	HasFileUpload bool         `json:"hasFileUpload"` // HasFileUpload represents if the form has a file upload field
+	Fields        []*FormField `json:"fields"`        // Fields represents the input, select and textarea fields
+}
+
+// FormField represents a field of a form
+type FormField struct {
+	Type         string `json:"type"`                   // Type represents the input type, or select and textarea
+	Name         string `json:"name"`                   // Name represents the name the field is submitted with
+	Required     bool   `json:"required"`               // Required represents if the field must be filled in
+	Autocomplete string `json:"autocomplete,omitempty"` // Autocomplete represents the autocomplete tokens
+}
+
+// DetectedForm represents a form of the HTML page classified by the login form inspector
+type DetectedForm struct {
+	Selector   string         `json:"selector"`   // Selector represents the CSS selector path of the form
+	Kind       iHtml.FormKind `json:"kind"`       // Kind represents the purpose of the form
+	Confidence int            `json:"confidence"` // Confidence represents the points of the form for its kind, up to 100
+	Reasons    []string       `json:"reasons"`    // Reasons represents the hints the form is of the kind
+}
+
+// Severity represents how severe a finding is
+type Severity string
+
+const (
+	SeverityInfo    Severity = "info"    // the finding is informative
+	SeverityWarning Severity = "warning" // the finding should be looked into
+	SeverityError   Severity = "error"   // the finding has to be fixed
+)
+
+// Finding represents an issue found by an inspector
+type Finding struct {
+	Severity Severity `json:"severity"`           // Severity represents how severe the issue is
+	Message  string   `json:"message"`            // Message represents the description of the issue
+	Selector string   `json:"selector,omitempty"` // Selector represents the CSS selector path of the offending element
+}
+
+// CheckStatus represents the outcome of a check of an audit
+type CheckStatus string
+
+const (
+	CheckPass CheckStatus = "pass" // the page meets the rule
+	CheckWarn CheckStatus = "warn" // the page partially meets the rule
+	CheckFail CheckStatus = "fail" // the page doesn't meet the rule
+)
+
+// checkScores are the points scored by each outcome of a check, out of 100
+var checkScores = map[CheckStatus]int{CheckPass: 100, CheckWarn: 50, CheckFail: 0}
+
+// Check represents the outcome of a rule checked by an audit inspector
+type Check struct {
+	Name    string      `json:"name"`    // Name represents the rule checked
+	Status  CheckStatus `json:"status"`  // Status represents the outcome of the check
+	Message string      `json:"message"` // Message represents the details of the outcome
+}
+
+// Report represents the results contributed by an inspector to the summary
+type Report struct {
+	Name     string         `json:"name"`             // Name represents the name of the inspector
+	Values   map[string]any `json:"values"`           // Values represents the named values measured by the inspector
+	Findings []*Finding     `json:"findings"`         // Findings represents the issues found by the inspector
+	Checks   []*Check       `json:"checks,omitempty"` // Checks represents the rules checked by an audit inspector
+	Score    *int           `json:"score,omitempty"`  // Score represents the average score of the checks out of 100
+}
+
+// NewReport creates a new instance of Report
+func NewReport(name string) *Report {
+	return &Report{
+		Name:     name,
+		Values:   map[string]any{},
+		Findings: []*Finding{},
+	}
+}
+
+// SetValue sets the named value
+func (r *Report) SetValue(name string, value any) {
+	r.Values[name] = value
+}
+
+// AddFinding adds the issue to the Findings
+func (r *Report) AddFinding(severity Severity, message string) {
+	r.Findings = append(r.Findings, &Finding{Severity: severity, Message: message})
+}
+
+// AddElementFinding adds the issue of the element with the selector path to the Findings
+func (r *Report) AddElementFinding(severity Severity, message string, selector string) {
+	r.Findings = append(r.Findings, &Finding{Severity: severity, Message: message, Selector: selector})
+}
+
+// AddCheck adds the outcome of the rule to the Checks and updates the Score, a passed check scores 100, a warning 50
+// and a failed check 0
+func (r *Report) AddCheck(name string, status CheckStatus, message string) {
+	r.Checks = append(r.Checks, &Check{Name: name, Status: status, Message: message})
+
+	total := 0
+	for _, check := range r.Checks {
+		total += checkScores[check.Status]
+	}
+	// rounded to the nearest integer
+	score := (total + len(r.Checks)/2) / len(r.Checks)
+	r.Score = &score
+}
+
+// RedirectHop represents a redirect response received while loading the HTML page
+type RedirectHop struct {
+	URL        string        `json:"url"`                 // URL represents the URL which responded with the redirect
+	StatusCode int           `json:"statusCode"`          // StatusCode represents the redirect http status code
+	Location   string        `json:"location"`            // Location represents the Location header of the response
+	Duration   time.Duration `json:"duration"`            // Duration represents the time taken to get the response
+	Downgrade  bool          `json:"downgrade,omitempty"` // Downgrade represents if the redirect goes from https to http
+}
+
+// Stage represents a step of the analysis
+type Stage string
+
+const (
+	StageFetched       Stage = "fetched"        // the page was loaded, following the redirects
+	StageParsed        Stage = "parsed"         // the page was parsed and inspected
+	StageCheckingLinks Stage = "checking_links" // the links of the page are being loaded
+)
+
+// Progress represents how far an analysis has progressed
+type Progress struct {
+	Stage        Stage `json:"stage"`        // Stage represents the last step reached by the analysis
+	LinksChecked int   `json:"linksChecked"` // LinksChecked represents the number of links loaded so far
+	LinksTotal   int   `json:"linksTotal"`   // LinksTotal represents the number of links to load
+}
+
+// ProgressFunc is called with the progress of an analysis, from the goroutine running the analysis or checking the
+// links, so it must be safe for concurrent use and return quickly
+type ProgressFunc func(progress *Progress)
+
+// LinkErrorClass represents the reason why a link failed to load
+type LinkErrorClass string
+
+const (
+	LinkErrorHttpStatus LinkErrorClass = "http_status" // the link responded with a 4xx or 5xx http status code
+	LinkErrorTimeout    LinkErrorClass = "timeout"     // the link didn't respond within the timeout
+	LinkErrorDNS        LinkErrorClass = "dns"         // the host of the link couldn't be resolved
+	LinkErrorTLS        LinkErrorClass = "tls"         // the TLS handshake or certificate verification failed
+	LinkErrorConnection LinkErrorClass = "connection"  // the connection was refused or reset
+	LinkErrorBlocked    LinkErrorClass = "blocked"     // the link points to an internal or denied address
+	LinkErrorUnknown    LinkErrorClass = "unknown"     // the link failed to load for any other reason
+	LinkErrorSkipped    LinkErrorClass = "skipped"     // the link wasn't loaded since the max links limit was hit
+)
+
+// LinkStatus represents the result of loading a link found in the HTML page
+type LinkStatus struct {
+	StatusCode int            `json:"statusCode,omitempty"` // StatusCode represents the final http status code
+	ErrorClass LinkErrorClass `json:"errorClass,omitempty"` // ErrorClass represents why the link failed to load
+	Latency    time.Duration  `json:"latency"`              // Latency represents the time taken to load the link
+}
+
+// IsInaccessible returns whether the link failed to load, skipped links are not considered inaccessible
+func (l *LinkStatus) IsInaccessible() bool {
+	return l.ErrorClass != "" && l.ErrorClass != LinkErrorSkipped
 }
 
 // NewSummary creates a new instance of Summary
@@ -25,6 +263,13 @@ func NewSummary(url *url.URL) *Summary {
 		InternalLinksMap:     map[string]struct{}{},
 		ExternalLinksMap:     map[string]struct{}{},
 		InaccessibleLinksMap: map[string]struct{}{},
+		ResolvedLinks:        map[string]string{},
+		LinkStatuses:         map[string]*LinkStatus{},
+		FinalURL:             url,
+		RedirectChain:        []*RedirectHop{},
+		Reports:              []*Report{},
+		DetectedForms:        []*DetectedForm{},
+		Forms:                []*Form{},
 	}
 }
 
@@ -69,7 +314,165 @@ func (s *Summary) AddInaccessibleLink(link string) {
 	}
 }
 
+// AddResolvedLink maps the link as found in the HTML page to the resolved link
+func (s *Summary) AddResolvedLink(link string, resolvedLink string) {
+	s.ResolvedLinks[link] = resolvedLink
+}
+
+// SetLinkStatus sets the status of the link, and adds the link to the InaccessibleLinksMap if it failed to load
+func (s *Summary) SetLinkStatus(link string, status *LinkStatus) {
+	s.LinkStatuses[link] = status
+	if status.IsInaccessible() {
+		s.AddInaccessibleLink(link)
+	}
+}
+
+// SetFinalURL sets the FinalURL
+func (s *Summary) SetFinalURL(url *url.URL) {
+	s.FinalURL = url
+}
+
+// AddRedirectHop adds the redirect to the end of the RedirectChain
+func (s *Summary) AddRedirectHop(hop *RedirectHop) {
+	s.RedirectChain = append(s.RedirectChain, hop)
+}
+
+// HasDowngradeRedirect returns whether any of the redirects goes from https to http
+func (s *Summary) HasDowngradeRedirect() bool {
+	for _, hop := range s.RedirectChain {
+		if hop.Downgrade {
+			return true
+		}
+	}
+	return false
+}
+
+// AddReport adds the report of an inspector to the Reports
+func (s *Summary) AddReport(report *Report) {
+	s.Reports = append(s.Reports, report)
+}
+
+// Report returns the report of the inspector with the name, nil if there is none
+func (s *Summary) Report(name string) *Report {
+	for _, report := range s.Reports {
+		if report.Name == name {
+			return report
+		}
+	}
+	return nil
+}
+
+// AddDetectedForm adds the form to the DetectedForms, and sets the has login form flag if it's a login form
+func (s *Summary) AddDetectedForm(form *DetectedForm) {
+	s.DetectedForms = append(s.DetectedForms, form)
+	if form.Kind == iHtml.FormLogin {
+		s.HasLoginForm = true
+	}
+}
+
+// SetContent sets the Content
+func (s *Summary) SetContent(content *Content) {
+	s.Content = content
+}
+
+// SetFetch sets the Fetch
+func (s *Summary) SetFetch(fetch *Fetch) {
+	s.Fetch = fetch
+}
+
+// SetEncoding sets the Encoding
+func (s *Summary) SetEncoding(encoding *Encoding) {
+	s.Encoding = encoding
+}
+
+// AddForm adds the form to the Forms
+func (s *Summary) AddForm(form *Form) {
+	s.Forms = append(s.Forms, form)
+}
+
 // SetHasLoginForm sets the has login form flag
 func (s *Summary) SetHasLoginForm(status bool) {
 	s.HasLoginForm = status
 }
+
+// MarshalJSON encodes the Summary as JSON, the URLs are encoded as strings and the link maps as sorted lists
+func (s Summary) MarshalJSON() ([]byte, error) {
+	// summary has the same fields as Summary but none of its methods, so encoding it doesn't recurse into MarshalJSON
+	type summary Summary
+
+	var u, finalURL string
+	if s.URL != nil {
+		u = s.URL.String()
+	}
+	if s.FinalURL != nil {
+		finalURL = s.FinalURL.String()
+	}
+
+	return json.Marshal(&struct {
+		URL      string `json:"url"`
+		FinalURL string `json:"finalUrl"`
+		*summary
+		InternalLinks     []string `json:"internalLinks"`
+		ExternalLinks     []string `json:"externalLinks"`
+		InaccessibleLinks []string `json:"inaccessibleLinks"`
+	}{
+		URL:               u,
+		FinalURL:          finalURL,
+		summary:           (*summary)(&s),
+		InternalLinks:     sortedKeys(s.InternalLinksMap),
+		ExternalLinks:     sortedKeys(s.ExternalLinksMap),
+		InaccessibleLinks: sortedKeys(s.InaccessibleLinksMap),
+	})
+}
+
+// UnmarshalJSON decodes the Summary encoded by MarshalJSON, so that the stored summaries can be loaded back
+func (s *Summary) UnmarshalJSON(data []byte) error {
+	type summary Summary
+	aux := &struct {
+		URL      string `json:"url"`
+		FinalURL string `json:"finalUrl"`
+		*summary
+		InternalLinks     []string `json:"internalLinks"`
+		ExternalLinks     []string `json:"externalLinks"`
+		InaccessibleLinks []string `json:"inaccessibleLinks"`
+	}{summary: (*summary)(s)}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+
+	var err error
+	if s.URL, err = url.Parse(aux.URL); err != nil {
+		return err
+	}
+	if s.FinalURL, err = url.Parse(aux.FinalURL); err != nil {
+		return err
+	}
+	s.InternalLinksMap = toSet(aux.InternalLinks)
+	s.ExternalLinksMap = toSet(aux.ExternalLinks)
+	s.InaccessibleLinksMap = toSet(aux.InaccessibleLinks)
+	return nil
+}
+
+// toSet returns the set of the keys
+func toSet(keys []string) map[string]struct{} {
+	m := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		m[k] = struct{}{}
+	}
+	return m
+}
+
+// sortedKeys returns the keys of the map in sorted order, it never returns nil so that it's encoded as an empty list
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ErrorResponse is the JSON body returned by the api when the analysis fails
+type ErrorResponse struct {
+	Error iError.CustomError `json:"error"`
+}
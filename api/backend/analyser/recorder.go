@@ -0,0 +1,10 @@
+package analyser
+
+import "time"
+
+// Recorder records the successful analyses, for example: to keep the history of the analyses of a URL
+type Recorder interface {
+	// Record records the summary of the analysis of the request along with the http status code of the page and the
+	// time taken to analyse it
+	Record(requestID string, summary *Summary, statusCode int, duration time.Duration) error
+}
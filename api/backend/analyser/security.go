@@ -0,0 +1,431 @@
+package analyser
+
+import (
+	"crypto/tls"
+	"fmt"
+	"golang.org/x/net/html"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	iHtml "web-analyser/internal/utils/html"
+)
+
+const (
+	// hstsMinMaxAge is the min max-age of the HSTS header in seconds, 180 days
+	hstsMinMaxAge = 180 * 24 * 60 * 60
+	// certificateExpiryWarning is how long before the expiry of the certificate it is reported
+	certificateExpiryWarning = 30 * 24 * time.Hour
+)
+
+// mixedContentAttrs are the attributes loading a resource of each element, the resources loaded over http by an https
+// page are mixed content. The active resources, such as the scripts, can alter the page and are blocked by the
+// browsers, the passive resources, such as the images, are loaded with a warning.
+var mixedContentAttrs = map[string]*struct {
+	attrs  []string
+	active bool
+}{
+	"script": {attrs: []string{"src"}, active: true},
+	"iframe": {attrs: []string{"src"}, active: true},
+	"frame":  {attrs: []string{"src"}, active: true},
+	"embed":  {attrs: []string{"src"}, active: true},
+	"object": {attrs: []string{"data"}, active: true},
+	"form":   {attrs: []string{"action"}, active: true},
+	"img":    {attrs: []string{"src", "srcset"}},
+	"audio":  {attrs: []string{"src"}},
+	"video":  {attrs: []string{"src", "poster"}},
+	"source": {attrs: []string{"src", "srcset"}},
+	"track":  {attrs: []string{"src"}},
+}
+
+// mixedContentLinks are the rel values of the link elements loading a resource, along with whether it is active
+var mixedContentLinks = map[string]bool{
+	"stylesheet":       true,
+	"modulepreload":    true,
+	"preload":          false,
+	"icon":             false,
+	"apple-touch-icon": false,
+}
+
+// securityInspector audits the response of the page and its connection: the security headers, the cookie flags, the
+// resources loaded over http by an https page and the TLS version, cipher suite and certificate expiry
+type securityInspector struct{}
+
+func (i *securityInspector) Name() string { return InspectorSecurity }
+
+func (i *securityInspector) Inspect(page *Page) Inspection {
+	return &securityInspection{page: page, https: page.URL.Scheme == "https"}
+}
+
+// mixedContent represents a resource loaded over http by an https page
+type mixedContent struct {
+	url      string
+	element  string
+	active   bool
+	selector string
+}
+
+type securityInspection struct {
+	page         *Page
+	https        bool
+	mixedContent []*mixedContent
+}
+
+func (i *securityInspection) Visit(n *html.Node) {
+	if !i.https || n.Type != html.ElementNode || n.Namespace != "" {
+		return
+	}
+
+	if n.Data == "link" {
+		rel, _ := iHtml.Attr(n, "rel")
+		for _, value := range strings.Fields(strings.ToLower(rel)) {
+			if active, ok := mixedContentLinks[value]; ok {
+				href, _ := iHtml.Attr(n, "href")
+				i.addMixedContent(n, href, active)
+				return
+			}
+		}
+		return
+	}
+
+	resource, ok := mixedContentAttrs[n.Data]
+	if !ok {
+		return
+	}
+	for _, key := range resource.attrs {
+		value, _ := iHtml.Attr(n, key)
+		if key != "srcset" {
+			i.addMixedContent(n, value, resource.active)
+			continue
+		}
+		// the srcset lists the candidate images separated by commas, each followed by its size
+		for _, candidate := range strings.Split(value, ",") {
+			if fields := strings.Fields(candidate); len(fields) > 0 {
+				i.addMixedContent(n, fields[0], resource.active)
+			}
+		}
+	}
+}
+
+// addMixedContent adds the resource of the element if it is loaded over http, the relative and the protocol relative
+// URLs are loaded over https as the page
+func (i *securityInspection) addMixedContent(n *html.Node, url string, active bool) {
+	url = strings.TrimSpace(url)
+	if !strings.HasPrefix(strings.ToLower(url), "http://") {
+		return
+	}
+	i.mixedContent = append(i.mixedContent, &mixedContent{url: url, element: n.Data, active: active,
+		selector: iHtml.Selector(n)})
+}
+
+func (i *securityInspection) Finish(summary *Summary) {
+	report := NewReport(InspectorSecurity)
+	csp := parseCSP(i.page.Header.Values("Content-Security-Policy"))
+
+	i.checkTransport(report)
+	i.checkHSTS(report)
+	i.checkCSP(report, csp)
+	i.checkFrameOptions(report, csp)
+	i.checkContentTypeOptions(report)
+	i.checkReferrerPolicy(report)
+	i.checkPermissionsPolicy(report)
+	i.checkCookies(report)
+	i.checkMixedContent(report)
+	summary.AddReport(report)
+}
+
+// checkTransport checks that the page is served over https, along with the TLS version, the cipher suite and the
+// expiry of the certificate of the connection
+func (i *securityInspection) checkTransport(report *Report) {
+	if !i.https {
+		report.AddCheck("https", CheckFail, "the page is not served over https")
+		return
+	}
+	report.AddCheck("https", CheckPass, "the page is served over https")
+
+	state := i.page.TLS
+	if state == nil {
+		return
+	}
+
+	version, cipherSuite := tls.VersionName(state.Version), tls.CipherSuiteName(state.CipherSuite)
+	report.SetValue("tlsVersion", version)
+	report.SetValue("cipherSuite", cipherSuite)
+	var problems []string
+	status := CheckPass
+	if state.Version < tls.VersionTLS12 {
+		problems = append(problems, version+" is deprecated")
+		status = CheckFail
+	}
+	for _, suite := range tls.InsecureCipherSuites() {
+		if suite.ID == state.CipherSuite {
+			problems = append(problems, "the cipher suite "+cipherSuite+" is insecure")
+			status = CheckFail
+		}
+	}
+
+	if len(state.PeerCertificates) > 0 {
+		expiry := state.PeerCertificates[0].NotAfter
+		report.SetValue("certificateExpiry", expiry.UTC().Format(time.RFC3339))
+		switch remaining := time.Until(expiry); {
+		case remaining <= 0:
+			problems = append(problems, "the certificate expired on "+expiry.UTC().Format(time.DateOnly))
+			status = CheckFail
+		case remaining < certificateExpiryWarning:
+			problems = append(problems, "the certificate expires on "+expiry.UTC().Format(time.DateOnly))
+			if status == CheckPass {
+				status = CheckWarn
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		report.AddCheck("tls", status, strings.Join(problems, ", "))
+		return
+	}
+	report.AddCheck("tls", CheckPass, fmt.Sprintf("the connection uses %v with %v", version, cipherSuite))
+}
+
+// checkHSTS checks the Strict-Transport-Security header, the browsers ignore it on the pages served over http
+func (i *securityInspection) checkHSTS(report *Report) {
+	if !i.https {
+		return
+	}
+
+	value := i.page.Header.Get("Strict-Transport-Security")
+	if value == "" {
+		report.AddCheck("hsts", CheckFail, "the Strict-Transport-Security header is not set")
+		return
+	}
+	report.SetValue("hsts", value)
+
+	maxAge := -1
+	for _, directive := range strings.Split(value, ";") {
+		name, val, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		if strings.EqualFold(name, "max-age") {
+			if age, err := strconv.Atoi(strings.Trim(val, `" `)); err == nil {
+				maxAge = age
+			}
+		}
+	}
+	switch {
+	case maxAge <= 0:
+		report.AddCheck("hsts", CheckFail, "the Strict-Transport-Security header has no valid max-age")
+	case maxAge < hstsMinMaxAge:
+		report.AddCheck("hsts", CheckWarn, fmt.Sprintf("the Strict-Transport-Security max-age is %d seconds, "+
+			"shorter than %d seconds", maxAge, hstsMinMaxAge))
+	default:
+		report.AddCheck("hsts", CheckPass, "the Strict-Transport-Security header is set")
+	}
+}
+
+// checkCSP checks the Content-Security-Policy header, the scripts are restricted by the script-src directive or the
+// default-src directive if there is none
+func (i *securityInspection) checkCSP(report *Report, csp map[string][]string) {
+	if len(csp) == 0 {
+		if i.page.Header.Get("Content-Security-Policy-Report-Only") != "" {
+			report.AddCheck("csp", CheckWarn, "the Content-Security-Policy is only reported, not enforced")
+			return
+		}
+		report.AddCheck("csp", CheckWarn, "the Content-Security-Policy header is not set")
+		return
+	}
+	report.SetValue("csp", strings.Join(i.page.Header.Values("Content-Security-Policy"), ", "))
+
+	sources, ok := csp["script-src"]
+	if !ok {
+		sources, ok = csp["default-src"]
+	}
+	if !ok {
+		report.AddCheck("csp", CheckWarn, "the Content-Security-Policy doesn't restrict the scripts")
+		return
+	}
+
+	var unsafe []string
+	for _, source := range sources {
+		switch source {
+		case "'unsafe-inline'", "'unsafe-eval'":
+			unsafe = append(unsafe, source)
+		}
+	}
+	if len(unsafe) > 0 {
+		report.AddCheck("csp", CheckWarn, "the Content-Security-Policy allows "+strings.Join(unsafe, " and ")+
+			" scripts")
+		return
+	}
+	report.AddCheck("csp", CheckPass, "the Content-Security-Policy restricts the scripts")
+}
+
+// checkFrameOptions checks that the page can't be framed by other sites, by the frame-ancestors directive of the
+// Content-Security-Policy, which takes precedence, or by the X-Frame-Options header
+func (i *securityInspection) checkFrameOptions(report *Report, csp map[string][]string) {
+	if ancestors, ok := csp["frame-ancestors"]; ok {
+		for _, source := range ancestors {
+			if source == "*" || source == "https:" || source == "http:" {
+				report.AddCheck("frame_options", CheckFail, "the frame-ancestors directive allows any site")
+				return
+			}
+		}
+		report.AddCheck("frame_options", CheckPass, "the frame-ancestors directive is "+strings.Join(ancestors, " "))
+		return
+	}
+
+	value := strings.ToUpper(strings.TrimSpace(i.page.Header.Get("X-Frame-Options")))
+	switch {
+	case value == "":
+		report.AddCheck("frame_options", CheckFail, "the X-Frame-Options header and the frame-ancestors directive "+
+			"are not set")
+	case value == "DENY" || value == "SAMEORIGIN":
+		report.AddCheck("frame_options", CheckPass, "the X-Frame-Options header is "+value)
+	case strings.HasPrefix(value, "ALLOW-FROM"):
+		report.AddCheck("frame_options", CheckWarn, "the X-Frame-Options ALLOW-FROM value is not supported by the "+
+			"browsers, use the frame-ancestors directive instead")
+	default:
+		report.AddCheck("frame_options", CheckFail, "the X-Frame-Options header is invalid: "+value)
+	}
+}
+
+func (i *securityInspection) checkContentTypeOptions(report *Report) {
+	value := strings.TrimSpace(i.page.Header.Get("X-Content-Type-Options"))
+	switch {
+	case strings.EqualFold(value, "nosniff"):
+		report.AddCheck("content_type_options", CheckPass, "the X-Content-Type-Options header is nosniff")
+	case value == "":
+		report.AddCheck("content_type_options", CheckFail, "the X-Content-Type-Options header is not set")
+	default:
+		report.AddCheck("content_type_options", CheckFail, "the X-Content-Type-Options header is invalid: "+value)
+	}
+}
+
+// checkReferrerPolicy checks the Referrer-Policy header, the last policy of the list which the browser supports is
+// used, so the last one is checked
+func (i *securityInspection) checkReferrerPolicy(report *Report) {
+	var policy string
+	for _, value := range i.page.Header.Values("Referrer-Policy") {
+		for _, p := range strings.Split(value, ",") {
+			if p = strings.ToLower(strings.TrimSpace(p)); p != "" {
+				policy = p
+			}
+		}
+	}
+
+	switch policy {
+	case "":
+		report.AddCheck("referrer_policy", CheckWarn, "the Referrer-Policy header is not set")
+	case "unsafe-url", "no-referrer-when-downgrade":
+		report.SetValue("referrerPolicy", policy)
+		report.AddCheck("referrer_policy", CheckWarn, "the Referrer-Policy "+policy+" leaks the full URL")
+	default:
+		report.SetValue("referrerPolicy", policy)
+		report.AddCheck("referrer_policy", CheckPass, "the Referrer-Policy is "+policy)
+	}
+}
+
+func (i *securityInspection) checkPermissionsPolicy(report *Report) {
+	switch {
+	case i.page.Header.Get("Permissions-Policy") != "":
+		report.AddCheck("permissions_policy", CheckPass, "the Permissions-Policy header is set")
+	case i.page.Header.Get("Feature-Policy") != "":
+		report.AddCheck("permissions_policy", CheckWarn, "the Feature-Policy header is deprecated, use the "+
+			"Permissions-Policy header instead")
+	default:
+		report.AddCheck("permissions_policy", CheckWarn, "the Permissions-Policy header is not set")
+	}
+}
+
+// checkCookies checks the flags of the cookies set by the response, each cookie missing a flag is reported as a
+// finding
+func (i *securityInspection) checkCookies(report *Report) {
+	cookies := (&http.Response{Header: i.page.Header}).Cookies()
+	report.SetValue("cookies", len(cookies))
+	if len(cookies) == 0 {
+		report.AddCheck("cookies", CheckPass, "the response sets no cookies")
+		return
+	}
+
+	status := CheckPass
+	for _, cookie := range cookies {
+		var failed, warned []string
+		if i.https && !cookie.Secure {
+			failed = append(failed, "Secure")
+		}
+		if !cookie.HttpOnly {
+			warned = append(warned, "HttpOnly")
+		}
+		switch {
+		case cookie.SameSite == http.SameSiteNoneMode && !cookie.Secure:
+			failed = append(failed, "Secure, required by SameSite=None")
+		case cookie.SameSite == 0:
+			warned = append(warned, "SameSite")
+		}
+
+		if len(failed) > 0 {
+			status = CheckFail
+			report.AddFinding(SeverityError, fmt.Sprintf("the cookie %q is not flagged %v", cookie.Name,
+				strings.Join(failed, ", ")))
+		}
+		if len(warned) > 0 {
+			if status == CheckPass {
+				status = CheckWarn
+			}
+			report.AddFinding(SeverityWarning, fmt.Sprintf("the cookie %q is not flagged %v", cookie.Name,
+				strings.Join(warned, ", ")))
+		}
+	}
+
+	if status == CheckPass {
+		report.AddCheck("cookies", CheckPass, fmt.Sprintf("the %d cookies are flagged", len(cookies)))
+		return
+	}
+	report.AddCheck("cookies", status, fmt.Sprintf("some of the %d cookies are not flagged", len(cookies)))
+}
+
+// checkMixedContent checks the resources loaded over http by an https page, each resource is reported as a finding
+// along with the selector path of its element
+func (i *securityInspection) checkMixedContent(report *Report) {
+	if !i.https {
+		return
+	}
+
+	active, passive := 0, 0
+	for _, content := range i.mixedContent {
+		if content.active {
+			active++
+			report.AddElementFinding(SeverityError, fmt.Sprintf("the %v loads %v over http", content.element,
+				content.url), content.selector)
+			continue
+		}
+		passive++
+		report.AddElementFinding(SeverityWarning, fmt.Sprintf("the %v loads %v over http", content.element,
+			content.url), content.selector)
+	}
+
+	switch {
+	case active > 0:
+		report.AddCheck("mixed_content", CheckFail, fmt.Sprintf("the page loads %d active and %d passive resources "+
+			"over http", active, passive))
+	case passive > 0:
+		report.AddCheck("mixed_content", CheckWarn, fmt.Sprintf("the page loads %d passive resources over http",
+			passive))
+	default:
+		report.AddCheck("mixed_content", CheckPass, "the page loads every resource over https")
+	}
+}
+
+// parseCSP parses the directives of the Content-Security-Policy headers into their lowercase sources by their
+// lowercase name, the first occurrence of a directive wins as per the specification
+func parseCSP(values []string) map[string][]string {
+	directives := map[string][]string{}
+	for _, value := range values {
+		for _, directive := range strings.Split(value, ";") {
+			fields := strings.Fields(strings.ToLower(directive))
+			if len(fields) == 0 {
+				continue
+			}
+			if _, ok := directives[fields[0]]; !ok {
+				directives[fields[0]] = fields[1:]
+			}
+		}
+	}
+	return directives
+}
@@ -0,0 +1,165 @@
+package analyser_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"web-analyser/api/backend/analyser"
+)
+
+// secureHeader returns the headers of a response meeting every security check
+func secureHeader() http.Header {
+	return http.Header{
+		"Strict-Transport-Security": []string{"max-age=31536000; includeSubDomains"},
+		"Content-Security-Policy":   []string{"default-src 'self'; frame-ancestors 'none'"},
+		"X-Content-Type-Options":    []string{"nosniff"},
+		"Referrer-Policy":           []string{"no-referrer, strict-origin-when-cross-origin"},
+		"Permissions-Policy":        []string{"camera=()"},
+		"Set-Cookie":                []string{"id=1; Secure; HttpOnly; SameSite=Lax"},
+	}
+}
+
+// connectionState returns the state of a connection with the version, the cipher suite and a certificate expiring
+// after the duration
+func connectionState(version uint16, cipherSuite uint16, expiresIn time.Duration) *tls.ConnectionState {
+	return &tls.ConnectionState{
+		Version:          version,
+		CipherSuite:      cipherSuite,
+		PeerCertificates: []*x509.Certificate{{NotAfter: time.Now().Add(expiresIn)}},
+	}
+}
+
+func TestSecurityInspector(t *testing.T) {
+	tests := []*struct {
+		name             string
+		url              string
+		document         string
+		header           http.Header
+		tls              *tls.ConnectionState
+		expectedStatuses map[string]analyser.CheckStatus
+		expectedScore    int
+		expectedFindings []*analyser.Finding
+		expectedValues   map[string]any
+	}{
+		{
+			name: "Should pass every check of a secure page",
+			url:  "https://google.com/",
+			document: `<html><head><link rel="stylesheet" href="/a.css"><link rel="alternate" href="http://google.de">` +
+				`</head><body><img src="//cdn.google.com/a.png"><a href="http://google.de">Google</a></body></html>`,
+			header: secureHeader(),
+			tls:    connectionState(tls.VersionTLS13, tls.TLS_AES_128_GCM_SHA256, 365*24*time.Hour),
+			expectedStatuses: map[string]analyser.CheckStatus{
+				"https": analyser.CheckPass, "tls": analyser.CheckPass, "hsts": analyser.CheckPass,
+				"csp": analyser.CheckPass, "frame_options": analyser.CheckPass,
+				"content_type_options": analyser.CheckPass, "referrer_policy": analyser.CheckPass,
+				"permissions_policy": analyser.CheckPass, "cookies": analyser.CheckPass,
+				"mixed_content": analyser.CheckPass,
+			},
+			expectedScore:    100,
+			expectedFindings: []*analyser.Finding{},
+			expectedValues: map[string]any{
+				"tlsVersion": "TLS 1.3", "cipherSuite": "TLS_AES_128_GCM_SHA256",
+				"referrerPolicy": "strict-origin-when-cross-origin", "cookies": 1,
+			},
+		},
+		{
+			name: "Should grade the weak headers, cookies, connection and the mixed content of an https page",
+			url:  "https://google.com/",
+			document: `<html><head><script src="http://cdn.google.com/a.js"></script></head>` +
+				`<body><img src="/a.png" srcset="/b.png 2x, http://cdn.google.com/c.png 3x"></body></html>`,
+			header: http.Header{
+				"Strict-Transport-Security": []string{"max-age=300"},
+				"Content-Security-Policy":   []string{"script-src 'self' 'unsafe-inline' 'unsafe-eval'"},
+				"X-Frame-Options":           []string{"ALLOW-FROM https://google.de"},
+				"Referrer-Policy":           []string{"unsafe-url"},
+				"Feature-Policy":            []string{"camera 'none'"},
+				"Set-Cookie":                []string{"id=1", "lang=de; Secure; HttpOnly; SameSite=None"},
+			},
+			tls: connectionState(tls.VersionTLS10, tls.TLS_RSA_WITH_RC4_128_SHA, -time.Hour),
+			expectedStatuses: map[string]analyser.CheckStatus{
+				"https": analyser.CheckPass, "tls": analyser.CheckFail, "hsts": analyser.CheckWarn,
+				"csp": analyser.CheckWarn, "frame_options": analyser.CheckWarn,
+				"content_type_options": analyser.CheckFail, "referrer_policy": analyser.CheckWarn,
+				"permissions_policy": analyser.CheckWarn, "cookies": analyser.CheckFail,
+				"mixed_content": analyser.CheckFail,
+			},
+			expectedScore: 35,
+			expectedFindings: []*analyser.Finding{
+				{Severity: analyser.SeverityError, Message: `the cookie "id" is not flagged Secure`},
+				{Severity: analyser.SeverityWarning, Message: `the cookie "id" is not flagged HttpOnly, SameSite`},
+				{Severity: analyser.SeverityError, Message: "the script loads http://cdn.google.com/a.js over http",
+					Selector: "html > head > script"},
+				{Severity: analyser.SeverityWarning, Message: "the img loads http://cdn.google.com/c.png over http",
+					Selector: "html > body > img"},
+			},
+			expectedValues: map[string]any{"tlsVersion": "TLS 1.0", "cipherSuite": "TLS_RSA_WITH_RC4_128_SHA"},
+		},
+		{
+			name:     "Should warn about the certificate expiring soon",
+			url:      "https://google.com/",
+			document: "<html></html>",
+			header:   secureHeader(),
+			tls:      connectionState(tls.VersionTLS12, tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, 10*24*time.Hour),
+			expectedStatuses: map[string]analyser.CheckStatus{
+				"https": analyser.CheckPass, "tls": analyser.CheckWarn, "hsts": analyser.CheckPass,
+				"csp": analyser.CheckPass, "frame_options": analyser.CheckPass,
+				"content_type_options": analyser.CheckPass, "referrer_policy": analyser.CheckPass,
+				"permissions_policy": analyser.CheckPass, "cookies": analyser.CheckPass,
+				"mixed_content": analyser.CheckPass,
+			},
+			expectedScore:    95,
+			expectedFindings: []*analyser.Finding{},
+		},
+		{
+			name:     "Should fail the transport of a page served over http without checking the https headers",
+			url:      "http://google.com/",
+			document: `<html><body><script src="http://cdn.google.com/a.js"></script></body></html>`,
+			header: http.Header{
+				"X-Frame-Options": []string{"deny"},
+				"Set-Cookie":      []string{"id=1; HttpOnly; SameSite=Strict"},
+			},
+			expectedStatuses: map[string]analyser.CheckStatus{
+				"https": analyser.CheckFail, "csp": analyser.CheckWarn, "frame_options": analyser.CheckPass,
+				"content_type_options": analyser.CheckFail, "referrer_policy": analyser.CheckWarn,
+				"permissions_policy": analyser.CheckWarn, "cookies": analyser.CheckPass,
+			},
+			expectedScore:    50,
+			expectedFindings: []*analyser.Finding{},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			report := inspectResponse(t, analyser.InspectorSecurity, tc.url, &http.Response{
+				Body:       io.NopCloser(strings.NewReader(tc.document)),
+				StatusCode: 200,
+				Header:     tc.header,
+				TLS:        tc.tls,
+			})
+			if report == nil {
+				t.Fatalf("Expected:%v, Got:%v", analyser.InspectorSecurity, nil)
+			}
+			if !reflect.DeepEqual(tc.expectedStatuses, checkStatuses(report)) {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedStatuses, checkStatuses(report))
+			}
+			if report.Score == nil || *report.Score != tc.expectedScore {
+				t.Fatalf("Expected:%v, Got:%+v", tc.expectedScore, report)
+			}
+			if !reflect.DeepEqual(tc.expectedFindings, report.Findings) {
+				for _, finding := range report.Findings {
+					t.Logf("%+v", finding)
+				}
+				t.Fatalf("Expected:%v, Got:%v", len(tc.expectedFindings), len(report.Findings))
+			}
+			for name, value := range tc.expectedValues {
+				if !reflect.DeepEqual(value, report.Values[name]) {
+					t.Fatalf("Expected:%v=%v, Got:%v", name, value, report.Values[name])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,346 @@
+package analyser
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+	iHtml "web-analyser/internal/utils/html"
+	iHttp "web-analyser/internal/utils/http"
+)
+
+// recommended lengths of the title and the meta description, in characters
+const (
+	minTitleLength       = 10
+	maxTitleLength       = 60
+	minDescriptionLength = 50
+	maxDescriptionLength = 160
+)
+
+// openGraphProperties are the properties required by the Open Graph protocol
+var openGraphProperties = []string{"og:title", "og:type", "og:image", "og:url"}
+
+// robotsUserAgents are the user agent tokens of the search engine bots, which can prefix the robots directives
+var robotsUserAgents = map[string]struct{}{
+	"googlebot": {}, "googlebot-news": {}, "googlebot-image": {}, "googlebot-video": {}, "storebot-google": {},
+	"google-inspectiontool": {}, "adsbot-google": {}, "mediapartners-google": {}, "bingbot": {}, "msnbot": {},
+	"yandex": {}, "yandexbot": {}, "baiduspider": {}, "duckduckbot": {}, "slurp": {}, "applebot": {},
+}
+
+// hreflangRegex matches a language code, optionally followed by a script and a region, or x-default
+var hreflangRegex = regexp.MustCompile(`^(?i:[a-z]{2,3}(-[a-z0-9]{2,8})*|x-default)$`)
+
+// seoInspector audits the signals used by the search engines to index and present the page: the title, the meta
+// description, the headings, the canonical link, the robots directives, the hreflang alternates, the Open Graph and
+// Twitter card tags and the alt text of the images
+type seoInspector struct{}
+
+func (i *seoInspector) Name() string { return InspectorSEO }
+
+func (i *seoInspector) Inspect(page *Page) Inspection {
+	return &seoInspection{page: page, hreflang: map[string]string{}, openGraph: map[string]string{}}
+}
+
+type seoInspection struct {
+	page            *Page
+	title           string
+	hasTitle        bool
+	descriptions    []string
+	h1Count         int
+	lastLevel       int
+	headingSkips    []string
+	canonicals      []string
+	robots          []string
+	hreflang        map[string]string
+	invalidHreflang []string
+	openGraph       map[string]string
+	twitterCard     string
+	images          int
+	imagesWithAlt   int
+}
+
+func (i *seoInspection) Visit(n *html.Node) {
+	// the elements of the embedded svg and math documents, such as the svg title, are not part of the page metadata
+	if n.Type != html.ElementNode || n.Namespace != "" {
+		return
+	}
+
+	switch n.Data {
+	case "title":
+		// the browsers and the search engines use the first title
+		if !i.hasTitle {
+			i.title = strings.TrimSpace(iHtml.Text(n))
+			i.hasTitle = true
+		}
+	case "meta":
+		i.visitMeta(n)
+	case "link":
+		i.visitLink(n)
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		level := int(n.Data[1] - '0')
+		if level == 1 {
+			i.h1Count++
+		}
+		if i.lastLevel > 0 && level > i.lastLevel+1 {
+			i.headingSkips = append(i.headingSkips, fmt.Sprintf("h%d→h%d", i.lastLevel, level))
+		}
+		i.lastLevel = level
+	case "img":
+		i.images++
+		// an empty alt text is valid, it marks the image as decorative
+		if _, ok := iHtml.Attr(n, "alt"); ok {
+			i.imagesWithAlt++
+		}
+	}
+}
+
+// visitMeta collects the description, the robots directives, the Twitter card and the Open Graph properties
+func (i *seoInspection) visitMeta(n *html.Node) {
+	name, _ := iHtml.Attr(n, "name")
+	property, _ := iHtml.Attr(n, "property")
+	content, _ := iHtml.Attr(n, "content")
+	name, property, content = strings.ToLower(name), strings.ToLower(property), strings.TrimSpace(content)
+
+	switch {
+	case name == "description":
+		i.descriptions = append(i.descriptions, content)
+	case name == "robots":
+		i.robots = append(i.robots, content)
+	case name == "twitter:card" || property == "twitter:card":
+		i.twitterCard = content
+	case strings.HasPrefix(property, "og:"):
+		if _, ok := i.openGraph[property]; !ok {
+			i.openGraph[property] = content
+		}
+	}
+}
+
+// visitLink collects the canonical links and the hreflang alternates
+func (i *seoInspection) visitLink(n *html.Node) {
+	rel, _ := iHtml.Attr(n, "rel")
+	href, _ := iHtml.Attr(n, "href")
+	for _, value := range strings.Fields(strings.ToLower(rel)) {
+		switch value {
+		case "canonical":
+			i.canonicals = append(i.canonicals, strings.TrimSpace(href))
+		case "alternate":
+			lang, ok := iHtml.Attr(n, "hreflang")
+			if !ok {
+				continue
+			}
+			if !hreflangRegex.MatchString(lang) {
+				i.invalidHreflang = append(i.invalidHreflang, lang)
+				continue
+			}
+			i.hreflang[strings.ToLower(lang)] = strings.TrimSpace(href)
+		}
+	}
+}
+
+func (i *seoInspection) Finish(summary *Summary) {
+	report := NewReport(InspectorSEO)
+	i.checkTitle(report)
+	i.checkDescription(report)
+	i.checkHeadings(report)
+	i.checkCanonical(report)
+	i.checkRobots(report)
+	i.checkHreflang(report)
+	i.checkSocialTags(report)
+	i.checkImageAlt(report)
+	summary.AddReport(report)
+}
+
+func (i *seoInspection) checkTitle(report *Report) {
+	length := utf8.RuneCountInString(i.title)
+	report.SetValue("titleLength", length)
+	switch {
+	case length == 0:
+		report.AddCheck("title", CheckFail, "the page has no title")
+	case length < minTitleLength || length > maxTitleLength:
+		report.AddCheck("title", CheckWarn, fmt.Sprintf("the title is %d characters long, the recommended length "+
+			"is %d to %d characters", length, minTitleLength, maxTitleLength))
+	default:
+		report.AddCheck("title", CheckPass, fmt.Sprintf("the title is %d characters long", length))
+	}
+}
+
+func (i *seoInspection) checkDescription(report *Report) {
+	if len(i.descriptions) == 0 || i.descriptions[0] == "" {
+		report.AddCheck("meta_description", CheckFail, "the page has no meta description")
+		return
+	}
+
+	length := utf8.RuneCountInString(i.descriptions[0])
+	report.SetValue("descriptionLength", length)
+	switch {
+	case len(i.descriptions) > 1:
+		report.AddCheck("meta_description", CheckWarn,
+			fmt.Sprintf("the page has %d meta descriptions", len(i.descriptions)))
+	case length < minDescriptionLength || length > maxDescriptionLength:
+		report.AddCheck("meta_description", CheckWarn, fmt.Sprintf("the meta description is %d characters long, "+
+			"the recommended length is %d to %d characters", length, minDescriptionLength, maxDescriptionLength))
+	default:
+		report.AddCheck("meta_description", CheckPass,
+			fmt.Sprintf("the meta description is %d characters long", length))
+	}
+}
+
+func (i *seoInspection) checkHeadings(report *Report) {
+	report.SetValue("h1Count", i.h1Count)
+	switch i.h1Count {
+	case 0:
+		report.AddCheck("h1", CheckFail, "the page has no h1 heading")
+	case 1:
+		report.AddCheck("h1", CheckPass, "the page has a single h1 heading")
+	default:
+		report.AddCheck("h1", CheckWarn, fmt.Sprintf("the page has %d h1 headings", i.h1Count))
+	}
+
+	if len(i.headingSkips) > 0 {
+		report.AddCheck("heading_hierarchy", CheckWarn,
+			"the heading levels are skipped: "+strings.Join(i.headingSkips, ", "))
+		return
+	}
+	report.AddCheck("heading_hierarchy", CheckPass, "the heading levels are not skipped")
+}
+
+func (i *seoInspection) checkCanonical(report *Report) {
+	if len(i.canonicals) == 0 {
+		report.AddCheck("canonical", CheckWarn, "the page has no canonical link")
+		return
+	}
+	if len(i.canonicals) > 1 {
+		report.AddCheck("canonical", CheckFail, fmt.Sprintf("the page has %d canonical links", len(i.canonicals)))
+		return
+	}
+
+	canonical, err := i.page.URL.Parse(i.canonicals[0])
+	if i.canonicals[0] == "" || err != nil {
+		report.AddCheck("canonical", CheckFail, fmt.Sprintf("the canonical link %q is invalid", i.canonicals[0]))
+		return
+	}
+
+	report.SetValue("canonical", canonical.String())
+	if iHttp.NormaliseURL(canonical, false).String() != iHttp.NormaliseURL(i.page.URL, false).String() {
+		report.AddCheck("canonical", CheckWarn, "the canonical link points to another page: "+canonical.String())
+		return
+	}
+	report.AddCheck("canonical", CheckPass, "the canonical link points to the page")
+}
+
+// checkRobots checks the directives of the robots meta tags and the X-Robots-Tag headers, the directives prefixed
+// with a user agent apply to it only but are considered as well
+func (i *seoInspection) checkRobots(report *Report) {
+	var directives []string
+	for _, value := range append(i.robots, i.page.Header.Values("X-Robots-Tag")...) {
+		for _, directive := range strings.Split(value, ",") {
+			if directive = robotsDirective(directive); directive != "" {
+				directives = append(directives, directive)
+			}
+		}
+	}
+	if len(directives) > 0 {
+		report.SetValue("robots", strings.Join(directives, ", "))
+	}
+
+	noIndex, noFollow := false, false
+	for _, directive := range directives {
+		switch directive {
+		case "noindex":
+			noIndex = true
+		case "nofollow":
+			noFollow = true
+		case "none":
+			noIndex, noFollow = true, true
+		}
+	}
+	switch {
+	case noIndex:
+		report.AddCheck("robots", CheckFail, "the page is not indexed by the search engines")
+	case noFollow:
+		report.AddCheck("robots", CheckWarn, "the links of the page are not followed by the search engines")
+	default:
+		report.AddCheck("robots", CheckPass, "the page can be indexed by the search engines")
+	}
+}
+
+// robotsDirective returns the directive without its user agent prefix, if any, with its name lower cased, for example:
+// googlebot: max-snippet:-1 becomes max-snippet:-1. Only the known bots are taken as a prefix, since the directives
+// have values after a colon as well, for example: unavailable_after: 2025-01-01T00:00:00Z.
+func robotsDirective(directive string) string {
+	if bot, rest, found := strings.Cut(directive, ":"); found {
+		if _, ok := robotsUserAgents[strings.ToLower(strings.TrimSpace(bot))]; ok {
+			directive = rest
+		}
+	}
+	name, value, found := strings.Cut(strings.TrimSpace(directive), ":")
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !found {
+		return name
+	}
+	return name + ":" + strings.TrimSpace(value)
+}
+
+// checkHreflang checks the language codes of the hreflang alternates, the check is skipped if the page has none
+func (i *seoInspection) checkHreflang(report *Report) {
+	if len(i.hreflang) == 0 && len(i.invalidHreflang) == 0 {
+		return
+	}
+
+	report.SetValue("hreflang", i.hreflang)
+	if len(i.invalidHreflang) > 0 {
+		report.AddCheck("hreflang", CheckWarn,
+			"the hreflang language codes are invalid: "+strings.Join(i.invalidHreflang, ", "))
+		return
+	}
+	report.AddCheck("hreflang", CheckPass, fmt.Sprintf("the page has %d hreflang alternates", len(i.hreflang)))
+}
+
+// checkSocialTags checks the Open Graph tags and the Twitter card used to preview the page when it is shared
+func (i *seoInspection) checkSocialTags(report *Report) {
+	var missing []string
+	for _, property := range openGraphProperties {
+		if i.openGraph[property] == "" {
+			missing = append(missing, property)
+		}
+	}
+	switch {
+	case len(i.openGraph) == 0:
+		report.AddCheck("open_graph", CheckWarn, "the page has no Open Graph tags")
+	case len(missing) > 0:
+		report.SetValue("openGraph", i.openGraph)
+		report.AddCheck("open_graph", CheckWarn, "the Open Graph tags are missing: "+strings.Join(missing, ", "))
+	default:
+		report.SetValue("openGraph", i.openGraph)
+		report.AddCheck("open_graph", CheckPass, "the page has the required Open Graph tags")
+	}
+
+	if i.twitterCard == "" {
+		report.AddCheck("twitter_card", CheckWarn, "the page has no Twitter card")
+		return
+	}
+	report.SetValue("twitterCard", i.twitterCard)
+	report.AddCheck("twitter_card", CheckPass, "the Twitter card is "+i.twitterCard)
+}
+
+// checkImageAlt checks the share of the images with an alt text, it fails if less than half of them have one
+func (i *seoInspection) checkImageAlt(report *Report) {
+	report.SetValue("images", i.images)
+	if i.images == 0 {
+		report.AddCheck("image_alt", CheckPass, "the page has no images")
+		return
+	}
+
+	coverage := i.imagesWithAlt * 100 / i.images
+	report.SetValue("altCoverage", coverage)
+	missing := i.images - i.imagesWithAlt
+	switch {
+	case missing == 0:
+		report.AddCheck("image_alt", CheckPass, "every image has an alt text")
+	case coverage < 50:
+		report.AddCheck("image_alt", CheckFail, fmt.Sprintf("%d of the %d images have no alt text", missing, i.images))
+	default:
+		report.AddCheck("image_alt", CheckWarn, fmt.Sprintf("%d of the %d images have no alt text", missing, i.images))
+	}
+}
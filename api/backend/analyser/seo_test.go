@@ -0,0 +1,209 @@
+package analyser_test
+
+import (
+	"context"
+	"go.uber.org/mock/gomock"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"web-analyser/api/backend/analyser"
+	"web-analyser/config"
+	"web-analyser/mocks"
+)
+
+// inspect analyses the document served with the header at https://google.com/ with the inspector only and returns
+// its report
+func inspect(t *testing.T, inspector string, document string, header http.Header) *analyser.Report {
+	return inspectResponse(t, inspector, "https://google.com/", &http.Response{
+		Body:       io.NopCloser(strings.NewReader(document)),
+		StatusCode: 200,
+		Header:     header,
+	})
+}
+
+// inspectResponse analyses the page of the response served at the url with the inspector only and returns its report
+func inspectResponse(t *testing.T, inspector string, u string, resp *http.Response) *analyser.Report {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mocks.NewMockClient(ctrl)
+	mockClient.EXPECT().Get(gomock.Any(), u).Return(resp, nil)
+	mockLinkChecker := mocks.NewMockLinkChecker(ctrl)
+	mockLinkChecker.EXPECT().Check(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+		map[string]*analyser.LinkStatus{}).AnyTimes()
+
+	conf := &config.AnalyserConf{SameSitePolicy: "host", MaxRedirects: 10, Inspectors: []string{inspector}}
+	a := analyser.NewAnalyser(mockClient, mockLinkChecker, conf)
+
+	parsedUrl, _ := url.Parse(u)
+	summary, err, _ := a.Analyse(context.Background(), parsedUrl)
+	if err != nil {
+		t.Fatalf("Expected:%v, Got:%v", nil, err)
+	}
+	return summary.Report(inspector)
+}
+
+// checkStatuses returns the status of each check of the report by the name of the check
+func checkStatuses(report *analyser.Report) map[string]analyser.CheckStatus {
+	statuses := make(map[string]analyser.CheckStatus, len(report.Checks))
+	for _, check := range report.Checks {
+		statuses[check.Name] = check.Status
+	}
+	return statuses
+}
+
+func TestSEOInspector(t *testing.T) {
+	const head = `<title>Google Search - the search engine</title>` +
+		`<meta name="description" content="Search the world's information, including webpages, images and videos.">` +
+		`<link rel="canonical" href="https://google.com/">` +
+		`<meta property="og:title" content="Google"><meta property="og:type" content="website">` +
+		`<meta property="og:image" content="/logo.png"><meta property="og:url" content="https://google.com/">` +
+		`<meta name="twitter:card" content="summary">`
+
+	tests := []*struct {
+		name             string
+		document         string
+		header           http.Header
+		expectedStatuses map[string]analyser.CheckStatus
+		expectedScore    int
+		expectedValues   map[string]any
+	}{
+		{
+			name: "Should pass every check of a well optimised page",
+			document: "<html><head>" + head +
+				`<link rel="alternate" hreflang="de-DE" href="https://google.de/">` +
+				`<link rel="alternate" hreflang="x-default" href="https://google.com/">` +
+				`</head><body><h1>Google</h1><h2>Search</h2><img src="/a.png" alt="Logo"><img src="/b.png" alt="">` +
+				`<svg><title>Icon</title></svg></body></html>`,
+			expectedStatuses: map[string]analyser.CheckStatus{
+				"title": analyser.CheckPass, "meta_description": analyser.CheckPass, "h1": analyser.CheckPass,
+				"heading_hierarchy": analyser.CheckPass, "canonical": analyser.CheckPass, "robots": analyser.CheckPass,
+				"hreflang": analyser.CheckPass, "open_graph": analyser.CheckPass, "twitter_card": analyser.CheckPass,
+				"image_alt": analyser.CheckPass,
+			},
+			expectedScore: 100,
+			expectedValues: map[string]any{
+				"titleLength": 33, "descriptionLength": 70, "h1Count": 1, "canonical": "https://google.com/",
+				"hreflang": map[string]string{"de-de": "https://google.de/", "x-default": "https://google.com/"},
+				"openGraph": map[string]string{"og:title": "Google", "og:type": "website", "og:image": "/logo.png",
+					"og:url": "https://google.com/"},
+				"twitterCard": "summary", "images": 2, "altCoverage": 100,
+			},
+		},
+		{
+			name:     "Should fail the checks of a page without metadata",
+			document: `<html><head></head><body><h2>Search</h2><img src="/a.png"></body></html>`,
+			expectedStatuses: map[string]analyser.CheckStatus{
+				"title": analyser.CheckFail, "meta_description": analyser.CheckFail, "h1": analyser.CheckFail,
+				"heading_hierarchy": analyser.CheckPass, "canonical": analyser.CheckWarn, "robots": analyser.CheckPass,
+				"open_graph": analyser.CheckWarn, "twitter_card": analyser.CheckWarn, "image_alt": analyser.CheckFail,
+			},
+			expectedScore: 39,
+			expectedValues: map[string]any{
+				"titleLength": 0, "h1Count": 0, "images": 1, "altCoverage": 0,
+			},
+		},
+		{
+			name: "Should warn about the length of the title and the description, the headings and the tags",
+			document: `<html><head><title>Google</title><meta name="description" content="Search">` +
+				`<link rel="canonical" href="/search"><meta property="og:title" content="Google">` +
+				`<link rel="alternate" hreflang="de_DE" href="https://google.de/">` +
+				`<meta name="robots" content="nofollow"></head>` +
+				`<body><h1>Google</h1><h2>Search</h2><h4>Results</h4><h1>Footer</h1>` +
+				`<img src="/a.png" alt="Logo"><img src="/b.png"></body></html>`,
+			expectedStatuses: map[string]analyser.CheckStatus{
+				"title": analyser.CheckWarn, "meta_description": analyser.CheckWarn, "h1": analyser.CheckWarn,
+				"heading_hierarchy": analyser.CheckWarn, "canonical": analyser.CheckWarn, "robots": analyser.CheckWarn,
+				"hreflang": analyser.CheckWarn, "open_graph": analyser.CheckWarn, "twitter_card": analyser.CheckWarn,
+				"image_alt": analyser.CheckWarn,
+			},
+			expectedScore: 50,
+			expectedValues: map[string]any{
+				"titleLength": 6, "descriptionLength": 6, "h1Count": 2, "canonical": "https://google.com/search",
+				"robots": "nofollow", "hreflang": map[string]string{}, "openGraph": map[string]string{"og:title": "Google"},
+				"images": 2, "altCoverage": 50,
+			},
+		},
+		{
+			name:     "Should fail the robots check of a page excluded by the X-Robots-Tag header",
+			document: "<html><head>" + head + "</head><body><h1>Google</h1></body></html>",
+			header:   http.Header{"X-Robots-Tag": []string{"googlebot: noindex, nofollow"}},
+			expectedStatuses: map[string]analyser.CheckStatus{
+				"title": analyser.CheckPass, "meta_description": analyser.CheckPass, "h1": analyser.CheckPass,
+				"heading_hierarchy": analyser.CheckPass, "canonical": analyser.CheckPass, "robots": analyser.CheckFail,
+				"open_graph": analyser.CheckPass, "twitter_card": analyser.CheckPass, "image_alt": analyser.CheckPass,
+			},
+			expectedScore: 89,
+		},
+		{
+			name: "Should keep the values of the robots directives while removing the user agents of the bots",
+			document: "<html><head>" + head + `<meta name="robots" ` +
+				`content="max-snippet:-1, max-image-preview:large, unavailable_after: 2025-01-01T00:00:00Z">` +
+				"</head><body><h1>Google</h1></body></html>",
+			header: http.Header{"X-Robots-Tag": []string{"googlebot: max-video-preview:-1, nofollow",
+				"BingBot: noarchive"}},
+			expectedStatuses: map[string]analyser.CheckStatus{
+				"title": analyser.CheckPass, "meta_description": analyser.CheckPass, "h1": analyser.CheckPass,
+				"heading_hierarchy": analyser.CheckPass, "canonical": analyser.CheckPass, "robots": analyser.CheckWarn,
+				"open_graph": analyser.CheckPass, "twitter_card": analyser.CheckPass, "image_alt": analyser.CheckPass,
+			},
+			expectedScore: 94,
+			expectedValues: map[string]any{
+				"robots": "max-snippet:-1, max-image-preview:large, unavailable_after:2025-01-01T00:00:00Z, " +
+					"max-video-preview:-1, nofollow, noarchive",
+			},
+		},
+		{
+			name: "Should fail the canonical check of a page with several canonical links",
+			document: `<html><head><link rel="canonical" href="https://google.com/">` +
+				`<link rel="canonical" href="https://google.de/"></head></html>`,
+			expectedStatuses: map[string]analyser.CheckStatus{
+				"title": analyser.CheckFail, "meta_description": analyser.CheckFail, "h1": analyser.CheckFail,
+				"heading_hierarchy": analyser.CheckPass, "canonical": analyser.CheckFail, "robots": analyser.CheckPass,
+				"open_graph": analyser.CheckWarn, "twitter_card": analyser.CheckWarn, "image_alt": analyser.CheckPass,
+			},
+			expectedScore: 44,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			report := inspect(t, analyser.InspectorSEO, tc.document, tc.header)
+			if report == nil {
+				t.Fatalf("Expected:%v, Got:%v", analyser.InspectorSEO, nil)
+			}
+			if !reflect.DeepEqual(tc.expectedStatuses, checkStatuses(report)) {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedStatuses, checkStatuses(report))
+			}
+			if report.Score == nil || *report.Score != tc.expectedScore {
+				t.Fatalf("Expected:%v, Got:%+v", tc.expectedScore, report)
+			}
+			for name, value := range tc.expectedValues {
+				if !reflect.DeepEqual(value, report.Values[name]) {
+					t.Fatalf("Expected:%v=%v, Got:%v", name, value, report.Values[name])
+				}
+			}
+		})
+	}
+}
+
+func TestReport_AddCheck(t *testing.T) {
+	report := analyser.NewReport("audit")
+	if report.Score != nil {
+		t.Fatalf("Expected:%v, Got:%v", nil, *report.Score)
+	}
+
+	report.AddCheck("a", analyser.CheckPass, "passed")
+	report.AddCheck("b", analyser.CheckWarn, "warned")
+	report.AddCheck("c", analyser.CheckWarn, "warned")
+	if *report.Score != 67 {
+		t.Fatalf("Expected:%v, Got:%v", 67, *report.Score)
+	}
+
+	report.AddCheck("d", analyser.CheckFail, "failed")
+	if *report.Score != 50 || len(report.Checks) != 4 {
+		t.Fatalf("Expected:%v, Got:%v %v", 50, *report.Score, len(report.Checks))
+	}
+}
@@ -0,0 +1,79 @@
+package batch
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// csvColumns are the columns of the CSV report
+var csvColumns = []string{"url", "status", "final_url", "status_code", "version", "title", "has_login_form",
+	"headings", "internal_links", "external_links", "inaccessible_links", "duration_ms", "error"}
+
+// WriteCSV writes the rows of the report as CSV, one row per URL in the submitted order followed by the totals row
+// of the report if it has its totals
+func WriteCSV(w io.Writer, report *Report) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvColumns); err != nil {
+		return err
+	}
+
+	for _, row := range report.Rows {
+		var message string
+		if row.Error != nil {
+			message = row.Error.Message
+		}
+		record := []string{
+			row.URL,
+			string(row.Status),
+			row.FinalURL,
+			strconv.Itoa(row.StatusCode),
+			row.Version,
+			row.Title,
+			strconv.FormatBool(row.HasLoginForm),
+			strconv.Itoa(row.Headings),
+			strconv.Itoa(row.InternalLinks),
+			strconv.Itoa(row.ExternalLinks),
+			strconv.Itoa(row.InaccessibleLinks),
+			strconv.FormatInt(row.Duration.Milliseconds(), 10),
+			message,
+		}
+		if err := writeRecord(writer, record); err != nil {
+			return err
+		}
+	}
+
+	if totals := report.Totals; totals != nil {
+		// the totals row sums the login forms and the links like the footer of the batch page
+		record := make([]string, len(csvColumns))
+		record[0] = "Total"
+		record[6] = strconv.Itoa(totals.WithLoginForm)
+		record[8] = strconv.Itoa(totals.InternalLinks)
+		record[9] = strconv.Itoa(totals.ExternalLinks)
+		record[10] = strconv.Itoa(totals.InaccessibleLinks)
+		if err := writeRecord(writer, record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeRecord writes the record with its fields escaped, see escapeFormula
+func writeRecord(writer *csv.Writer, record []string) error {
+	for i, field := range record {
+		record[i] = escapeFormula(field)
+	}
+	return writer.Write(record)
+}
+
+// escapeFormula prefixes the field with a quote if it starts like a formula, since the titles come from the
+// analysed pages and the spreadsheets would evaluate them when opening the report
+func escapeFormula(field string) string {
+	if field != "" && strings.ContainsRune("=+-@\t\r", rune(field[0])) {
+		return "'" + field
+	}
+	return field
+}
@@ -0,0 +1,40 @@
+package batch_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+	"web-analyser/api/backend/batch"
+	iError "web-analyser/internal/utils/error"
+)
+
+func TestWriteCSV(t *testing.T) {
+	report := &batch.Report{Rows: []*batch.Row{
+		{
+			URL: "https://google.com", Status: batch.RowDone, FinalURL: "https://www.google.com/", StatusCode: 200,
+			Version: "HTML 5", Title: "=HYPERLINK(\"https://evil.com\")", HasLoginForm: true, Headings: 3,
+			InternalLinks: 10, ExternalLinks: 5, InaccessibleLinks: 1, Duration: 1500 * time.Millisecond,
+		},
+		{
+			URL: "https://google.de", Status: batch.RowFailed, StatusCode: 404,
+			Error: &iError.CustomError{Message: "unreachable", HttpStatusCode: 404},
+		},
+	}, Totals: &batch.Totals{URLs: 2, Finished: 2, Succeeded: 1, Failed: 1, WithLoginForm: 1, InternalLinks: 10,
+		ExternalLinks: 5, InaccessibleLinks: 1}}
+
+	var buf bytes.Buffer
+	if err := batch.WriteCSV(&buf, report); err != nil {
+		t.Fatalf("Expected:%v, Got:%v", nil, err)
+	}
+
+	// the title starting like a formula is escaped, the totals row follows the rows
+	expected := "url,status,final_url,status_code,version,title,has_login_form,headings,internal_links," +
+		"external_links,inaccessible_links,duration_ms,error\n" +
+		"https://google.com,done,https://www.google.com/,200,HTML 5,\"'=HYPERLINK(\"\"https://evil.com\"\")\"," +
+		"true,3,10,5,1,1500,\n" +
+		"https://google.de,failed,,404,,,false,0,0,0,0,0,unreachable\n" +
+		"Total,,,,,,1,,10,5,1,,\n"
+	if buf.String() != expected {
+		t.Fatalf("Expected:%v, Got:%v", expected, buf.String())
+	}
+}
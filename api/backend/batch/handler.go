@@ -0,0 +1,241 @@
+package batch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"web-analyser/api/backend/analyser"
+	"web-analyser/config"
+	iCtx "web-analyser/internal/utils/ctx"
+	iError "web-analyser/internal/utils/error"
+	iHttp "web-analyser/internal/utils/http"
+	"web-analyser/internal/utils/render"
+)
+
+const (
+	// ContentTypeCSV is the media type of the CSV reports
+	ContentTypeCSV = "text/csv"
+
+	formatCSV  = "csv"
+	formatJSON = "json"
+)
+
+type Handler interface {
+	Submit(w http.ResponseWriter, r *http.Request)
+	Page(w http.ResponseWriter, r *http.Request)
+	Create(w http.ResponseWriter, r *http.Request)
+	Show(w http.ResponseWriter, r *http.Request)
+}
+
+type HandlerImpl struct {
+	logger *zerolog.Logger
+	tpl    analyser.Template
+	runner Runner
+	conf   *config.BatchConf
+}
+
+// batchRequest is the JSON body accepted by the batches api
+type batchRequest struct {
+	URLs []string `json:"urls"`
+}
+
+func NewHandler(logger *zerolog.Logger, tpl analyser.Template, runner Runner, conf *config.BatchConf) *HandlerImpl {
+	return &HandlerImpl{
+		logger: logger,
+		tpl:    tpl,
+		runner: runner,
+		conf:   conf,
+	}
+}
+
+// Submit starts the batch of the URLs entered in the urls field and uploaded in the file field, then redirects to
+// the page of the batch. It responds with the report as JSON instead if the client accepts JSON.
+func (h *HandlerImpl) Submit(w http.ResponseWriter, r *http.Request) {
+	report, statusCode, customError := h.submit(w, r)
+
+	if iHttp.AcceptsJSON(r) {
+		h.renderReportJSON(w, r, report, statusCode, customError)
+		return
+	}
+
+	if customError != nil {
+		render.Template(w, r, h.logger, h.tpl, "error.gohtml", *customError)
+		return
+	}
+	http.Redirect(w, r, "/batch/"+report.ID, http.StatusSeeOther)
+}
+
+// Page renders the report of the batch, refreshed until every URL is analysed. The report is downloaded instead if
+// the format query value is csv or json, and it is returned as JSON if the client accepts JSON.
+func (h *HandlerImpl) Page(w http.ResponseWriter, r *http.Request) {
+	report, statusCode, customError := h.get(r)
+
+	if format := r.URL.Query().Get("format"); format != "" || iHttp.AcceptsJSON(r) {
+		h.renderReport(w, r, report, statusCode, customError)
+		return
+	}
+
+	if customError != nil {
+		render.Template(w, r, h.logger, h.tpl, "error.gohtml", *customError)
+		return
+	}
+	render.Template(w, r, h.logger, h.tpl, "batch.gohtml", report)
+}
+
+// Create starts the batch of the URLs and responds with the report as JSON. The URLs are read from the JSON body in
+// case of a JSON request, otherwise from the repeated url values, the urls field and the uploaded file.
+func (h *HandlerImpl) Create(w http.ResponseWriter, r *http.Request) {
+	report, statusCode, customError := h.submit(w, r)
+	if customError == nil {
+		w.Header().Set("Location", "/api/v1/batches/"+report.ID)
+	}
+	h.renderReportJSON(w, r, report, statusCode, customError)
+}
+
+// Show returns the report of the batch as JSON, or as CSV if the format query value is csv.
+func (h *HandlerImpl) Show(w http.ResponseWriter, r *http.Request) {
+	report, statusCode, customError := h.get(r)
+	h.renderReport(w, r, report, statusCode, customError)
+}
+
+// submit reads the URLs of the request and starts their batch. In case of failure, it logs the error and returns
+// the http status code to respond with along with the error to be conveyed to the user
+func (h *HandlerImpl) submit(w http.ResponseWriter, r *http.Request) (*Report, int, *iError.CustomError) {
+	reqID := iCtx.RequestID(r.Context())
+
+	urls, statusCode, customError := h.readURLs(w, r)
+	if customError != nil {
+		h.logger.Error().Str(iCtx.KeyRequestID, reqID).Msg(customError.Message)
+		return nil, statusCode, customError
+	}
+	return h.runner.Submit(urls, reqID), http.StatusAccepted, nil
+}
+
+// readURLs returns the URLs of the request, without the repeated URLs, along with the http status code and the
+// error to respond with if they can't be read, there are none or there are too many
+func (h *HandlerImpl) readURLs(w http.ResponseWriter, r *http.Request) ([]string, int, *iError.CustomError) {
+	r.Body = http.MaxBytesReader(w, r.Body, h.conf.MaxUploadSize)
+	list := NewURLList()
+
+	var err error
+	isJSON := mediaTypeIsJSON(r)
+	if isJSON {
+		var body batchRequest
+		if err = json.NewDecoder(r.Body).Decode(&body); err == nil {
+			for _, url := range body.URLs {
+				list.Add(url)
+			}
+		}
+	} else {
+		err = h.readForm(r, list)
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return nil, http.StatusRequestEntityTooLarge, &iError.CustomError{
+			Message: string(iError.URLListTooLargeError) + strconv.FormatInt(h.conf.MaxUploadSize, 10)}
+	case err != nil && isJSON:
+		return nil, http.StatusBadRequest, &iError.CustomError{Message: string(iError.InvalidBatchRequestError)}
+	case err != nil:
+		return nil, http.StatusBadRequest, &iError.CustomError{Message: string(iError.InvalidURLListError)}
+	case len(list.URLs()) == 0:
+		return nil, http.StatusBadRequest, &iError.CustomError{Message: string(iError.EmptyBatchError)}
+	case len(list.URLs()) > h.conf.MaxURLs:
+		return nil, http.StatusBadRequest, &iError.CustomError{
+			Message: string(iError.TooManyURLsError) + strconv.Itoa(h.conf.MaxURLs)}
+	}
+	return list.URLs(), http.StatusOK, nil
+}
+
+// readForm adds the URLs of the repeated url values, of the urls field, one per line, and of the uploaded file, read
+// as CSV if it is named .csv or sent as text/csv, otherwise as plain text
+func (h *HandlerImpl) readForm(r *http.Request, list *URLList) error {
+	if err := r.ParseMultipartForm(h.conf.MaxUploadSize); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		return err
+	}
+
+	for _, url := range r.Form["url"] {
+		list.Add(url)
+	}
+	if err := list.ReadText(strings.NewReader(r.FormValue("urls"))); err != nil {
+		return err
+	}
+
+	file, header, err := r.FormFile("file")
+	if errors.Is(err, http.ErrMissingFile) || errors.Is(err, http.ErrNotMultipart) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if isCSV(header) {
+		return list.ReadCSV(file)
+	}
+	return list.ReadText(file)
+}
+
+// get returns the report of the batch with the id of the route. In case there is no batch with the id, it returns
+// the http status code to respond with along with the error to be conveyed to the user
+func (h *HandlerImpl) get(r *http.Request) (*Report, int, *iError.CustomError) {
+	report, err := h.runner.Get(chi.URLParam(r, "id"))
+	if err != nil {
+		return nil, http.StatusNotFound, &iError.CustomError{Message: string(iError.BatchNotFoundError)}
+	}
+	return report, http.StatusOK, nil
+}
+
+// renderReport writes the report as a CSV or JSON download as per the format query value, or as JSON if there is
+// none, the error is always written as JSON
+func (h *HandlerImpl) renderReport(w http.ResponseWriter, r *http.Request, report *Report, statusCode int,
+	customError *iError.CustomError) {
+	format := r.URL.Query().Get("format")
+	if customError != nil || (format != formatCSV && format != formatJSON) {
+		h.renderReportJSON(w, r, report, statusCode, customError)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="batch-%s.%s"`, report.ID, format))
+	if format == formatJSON {
+		render.JSON(w, r, h.logger, statusCode, report)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentTypeCSV+"; charset=utf-8")
+	w.WriteHeader(statusCode)
+	if err := WriteCSV(w, report); err != nil {
+		h.logger.Error().Str(iCtx.KeyRequestID, iCtx.RequestID(r.Context())).Err(err).Msg("csv error")
+	}
+}
+
+// renderReportJSON writes either the report or the error response as JSON
+func (h *HandlerImpl) renderReportJSON(w http.ResponseWriter, r *http.Request, report *Report, statusCode int,
+	customError *iError.CustomError) {
+	if customError != nil {
+		render.JSON(w, r, h.logger, statusCode, analyser.ErrorResponse{Error: *customError})
+		return
+	}
+	render.JSON(w, r, h.logger, statusCode, report)
+}
+
+// mediaTypeIsJSON returns whether the body of the request is JSON
+func mediaTypeIsJSON(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == iHttp.ContentTypeJSON
+}
+
+// isCSV returns whether the uploaded file is a CSV file, by its name or its media type
+func isCSV(header *multipart.FileHeader) bool {
+	mediaType, _, _ := mime.ParseMediaType(header.Header.Get("Content-Type"))
+	return strings.EqualFold(filepath.Ext(header.Filename), ".csv") || mediaType == ContentTypeCSV
+}
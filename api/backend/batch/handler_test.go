@@ -0,0 +1,325 @@
+package batch_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/mock/gomock"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"web-analyser/api/backend/analyser"
+	"web-analyser/api/backend/batch"
+	"web-analyser/config"
+	iError "web-analyser/internal/utils/error"
+	iHttp "web-analyser/internal/utils/http"
+	l "web-analyser/internal/utils/logger"
+	"web-analyser/mocks"
+)
+
+const batchID = "0b7e4c1a-2f3d-4e5a-8b6c-7d8e9f0a1b2c"
+
+var batchConf = &config.BatchConf{Concurrency: 1, PerHost: 1, MaxURLs: 2, MaxUploadSize: 1024}
+
+// withID returns the request with the id as the route param
+func withID(r *http.Request, id string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
+
+// newReport returns a report of the batch with a row per url and the status
+func newReport(status batch.Status, urls ...string) *batch.Report {
+	report := &batch.Report{ID: batchID, Status: status, Totals: &batch.Totals{URLs: len(urls)}}
+	for _, u := range urls {
+		report.Rows = append(report.Rows, &batch.Row{URL: u, Status: batch.RowPending})
+	}
+	return report
+}
+
+// newFileRequest returns a multipart request uploading the content as the file with the name
+func newFileRequest(target, name, content string) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, _ := writer.CreateFormFile("file", name)
+	_, _ = part.Write([]byte(content))
+	_ = writer.Close()
+
+	r := httptest.NewRequest(http.MethodPost, target, &body)
+	r.Header.Set("Content-Type", writer.FormDataContentType())
+	return r
+}
+
+// newFormRequest returns a form request with the values
+func newFormRequest(target string, values url.Values) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(values.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+func TestHandlerImpl_Submit(t *testing.T) {
+	tests := []*struct {
+		name               string
+		request            *http.Request
+		setupExpectations  func(*httptest.ResponseRecorder, *mocks.MockTemplate, *mocks.MockRunner)
+		expectedStatusCode int
+		expectedLocation   string
+	}{
+		{
+			name:    "Should start the batch of the urls field and redirect to the batch page",
+			request: newFormRequest("/batch", url.Values{"urls": {"google.com\ngoogle.de"}}),
+			setupExpectations: func(w *httptest.ResponseRecorder, mockTemplate *mocks.MockTemplate,
+				mockRunner *mocks.MockRunner) {
+				mockRunner.EXPECT().Submit([]string{"google.com", "google.de"}, gomock.Any()).Return(
+					newReport(batch.StatusRunning, "google.com", "google.de"))
+			},
+			expectedStatusCode: http.StatusSeeOther,
+			expectedLocation:   "/batch/" + batchID,
+		},
+		{
+			name:    "Should render error template when there are no urls",
+			request: newFormRequest("/batch", url.Values{"urls": {"\n# comment\n"}}),
+			setupExpectations: func(w *httptest.ResponseRecorder, mockTemplate *mocks.MockTemplate,
+				mockRunner *mocks.MockRunner) {
+				mockTemplate.EXPECT().ExecuteTemplate(w, "error.gohtml", iError.CustomError{
+					Message: string(iError.EmptyBatchError)})
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockTemplate := mocks.NewMockTemplate(ctrl)
+			mockRunner := mocks.NewMockRunner(ctrl)
+			w := httptest.NewRecorder()
+			tc.setupExpectations(w, mockTemplate, mockRunner)
+
+			handler := batch.NewHandler(l.NewLogger(false), mockTemplate, mockRunner, batchConf)
+			handler.Submit(w, tc.request)
+
+			if w.Code != tc.expectedStatusCode {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedStatusCode, w.Code)
+			}
+			if location := w.Header().Get("Location"); location != tc.expectedLocation {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedLocation, location)
+			}
+		})
+	}
+}
+
+func TestHandlerImpl_Create(t *testing.T) {
+	jsonRequest := func(body string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/batches", strings.NewReader(body))
+		r.Header.Set("Content-Type", iHttp.ContentTypeJSON)
+		return r
+	}
+
+	tests := []*struct {
+		name               string
+		request            *http.Request
+		expectedURLs       []string
+		expectedStatusCode int
+		expectedLocation   string
+		expectedError      iError.Msg
+	}{
+		{
+			name:               "Should start the batch of the urls of the JSON body",
+			request:            jsonRequest(`{"urls": ["google.com", "google.de", "google.com"]}`),
+			expectedURLs:       []string{"google.com", "google.de"},
+			expectedStatusCode: http.StatusAccepted,
+			expectedLocation:   "/api/v1/batches/" + batchID,
+		},
+		{
+			name: "Should start the batch of the repeated url values and the urls field",
+			request: newFormRequest("/api/v1/batches",
+				url.Values{"url": {"google.com"}, "urls": {"google.de"}}),
+			expectedURLs:       []string{"google.com", "google.de"},
+			expectedStatusCode: http.StatusAccepted,
+			expectedLocation:   "/api/v1/batches/" + batchID,
+		},
+		{
+			name:               "Should start the batch of the urls of the uploaded CSV file",
+			request:            newFileRequest("/api/v1/batches", "urls.csv", "url,team\ngoogle.com,search\n"),
+			expectedURLs:       []string{"google.com"},
+			expectedStatusCode: http.StatusAccepted,
+			expectedLocation:   "/api/v1/batches/" + batchID,
+		},
+		{
+			name:               "Should start the batch of the urls of the uploaded text file",
+			request:            newFileRequest("/api/v1/batches", "urls.txt", "google.com\ngoogle.de\n"),
+			expectedURLs:       []string{"google.com", "google.de"},
+			expectedStatusCode: http.StatusAccepted,
+			expectedLocation:   "/api/v1/batches/" + batchID,
+		},
+		{
+			name:               "Should return bad request for an invalid JSON body",
+			request:            jsonRequest(`{`),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      iError.InvalidBatchRequestError,
+		},
+		{
+			name:               "Should return bad request when there are no urls",
+			request:            jsonRequest(`{"urls": []}`),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      iError.EmptyBatchError,
+		},
+		{
+			name:               "Should return bad request when there are too many urls",
+			request:            jsonRequest(`{"urls": ["google.com", "google.de", "google.fr"]}`),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      iError.TooManyURLsError + "2",
+		},
+		{
+			name: "Should return request entity too large when the list is larger than the limit",
+			request: newFileRequest("/api/v1/batches", "urls.txt",
+				strings.Repeat("google.com\n", 100)),
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+			expectedError:      iError.URLListTooLargeError + "1024",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRunner := mocks.NewMockRunner(ctrl)
+			if tc.expectedURLs != nil {
+				mockRunner.EXPECT().Submit(tc.expectedURLs, gomock.Any()).Return(
+					newReport(batch.StatusRunning, tc.expectedURLs...))
+			}
+			handler := batch.NewHandler(l.NewLogger(false), mocks.NewMockTemplate(ctrl), mockRunner, batchConf)
+
+			w := httptest.NewRecorder()
+			handler.Create(w, tc.request)
+
+			if w.Code != tc.expectedStatusCode {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedStatusCode, w.Code)
+			}
+			if location := w.Header().Get("Location"); location != tc.expectedLocation {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedLocation, location)
+			}
+			if tc.expectedError != "" {
+				var response analyser.ErrorResponse
+				_ = json.NewDecoder(w.Body).Decode(&response)
+				if response.Error.Message != string(tc.expectedError) {
+					t.Fatalf("Expected:%v, Got:%v", tc.expectedError, response.Error.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestHandlerImpl_Page(t *testing.T) {
+	tests := []*struct {
+		name                       string
+		target                     string
+		setupExpectations          func(*httptest.ResponseRecorder, *mocks.MockTemplate, *mocks.MockRunner)
+		expectedStatusCode         int
+		expectedContentType        string
+		expectedContentDisposition string
+	}{
+		{
+			name:   "Should render batch template",
+			target: "/batch/" + batchID,
+			setupExpectations: func(w *httptest.ResponseRecorder, mockTemplate *mocks.MockTemplate,
+				mockRunner *mocks.MockRunner) {
+				report := newReport(batch.StatusRunning, "google.com")
+				mockRunner.EXPECT().Get(batchID).Return(report, nil)
+				mockTemplate.EXPECT().ExecuteTemplate(w, "batch.gohtml", report)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:   "Should download the report as CSV",
+			target: "/batch/" + batchID + "?format=csv",
+			setupExpectations: func(w *httptest.ResponseRecorder, mockTemplate *mocks.MockTemplate,
+				mockRunner *mocks.MockRunner) {
+				mockRunner.EXPECT().Get(batchID).Return(newReport(batch.StatusDone, "google.com"), nil)
+			},
+			expectedStatusCode:         http.StatusOK,
+			expectedContentType:        batch.ContentTypeCSV + "; charset=utf-8",
+			expectedContentDisposition: `attachment; filename="batch-` + batchID + `.csv"`,
+		},
+		{
+			name:   "Should download the report as JSON",
+			target: "/batch/" + batchID + "?format=json",
+			setupExpectations: func(w *httptest.ResponseRecorder, mockTemplate *mocks.MockTemplate,
+				mockRunner *mocks.MockRunner) {
+				mockRunner.EXPECT().Get(batchID).Return(newReport(batch.StatusDone, "google.com"), nil)
+			},
+			expectedStatusCode:         http.StatusOK,
+			expectedContentType:        iHttp.ContentTypeJSON,
+			expectedContentDisposition: `attachment; filename="batch-` + batchID + `.json"`,
+		},
+		{
+			name:   "Should render error template for an unknown batch",
+			target: "/batch/" + batchID,
+			setupExpectations: func(w *httptest.ResponseRecorder, mockTemplate *mocks.MockTemplate,
+				mockRunner *mocks.MockRunner) {
+				mockRunner.EXPECT().Get(batchID).Return(nil, batch.ErrNotFound)
+				mockTemplate.EXPECT().ExecuteTemplate(w, "error.gohtml", iError.CustomError{
+					Message: string(iError.BatchNotFoundError)})
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:   "Should return not found as JSON for the download of an unknown batch",
+			target: "/batch/" + batchID + "?format=csv",
+			setupExpectations: func(w *httptest.ResponseRecorder, mockTemplate *mocks.MockTemplate,
+				mockRunner *mocks.MockRunner) {
+				mockRunner.EXPECT().Get(batchID).Return(nil, batch.ErrNotFound)
+			},
+			expectedStatusCode:  http.StatusNotFound,
+			expectedContentType: iHttp.ContentTypeJSON,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockTemplate := mocks.NewMockTemplate(ctrl)
+			mockRunner := mocks.NewMockRunner(ctrl)
+			w := httptest.NewRecorder()
+			tc.setupExpectations(w, mockTemplate, mockRunner)
+
+			handler := batch.NewHandler(l.NewLogger(false), mockTemplate, mockRunner, batchConf)
+			handler.Page(w, withID(httptest.NewRequest(http.MethodGet, tc.target, nil), batchID))
+
+			if w.Code != tc.expectedStatusCode {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedStatusCode, w.Code)
+			}
+			if contentType := w.Header().Get("Content-Type"); contentType != tc.expectedContentType {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedContentType, contentType)
+			}
+			if disposition := w.Header().Get("Content-Disposition"); disposition != tc.expectedContentDisposition {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedContentDisposition, disposition)
+			}
+		})
+	}
+}
+
+func TestHandlerImpl_Show(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRunner := mocks.NewMockRunner(ctrl)
+	mockRunner.EXPECT().Get(batchID).Return(newReport(batch.StatusDone, "google.com"), nil)
+	handler := batch.NewHandler(l.NewLogger(false), mocks.NewMockTemplate(ctrl), mockRunner, batchConf)
+
+	w := httptest.NewRecorder()
+	handler.Show(w, withID(httptest.NewRequest(http.MethodGet, "/api/v1/batches/"+batchID, nil), batchID))
+
+	var response batch.Report
+	_ = json.NewDecoder(w.Body).Decode(&response)
+	if w.Code != http.StatusOK || response.Status != batch.StatusDone || len(response.Rows) != 1 {
+		t.Fatalf("Expected:%v, Got:%v %+v", batch.StatusDone, w.Code, response)
+	}
+}
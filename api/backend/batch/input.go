@@ -0,0 +1,71 @@
+package batch
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+)
+
+// csvHeader is the header of the url column skipped from the CSV files
+const csvHeader = "url"
+
+// URLList collects the URLs of a batch in the submitted order, skipping the repeated URLs
+type URLList struct {
+	urls []string
+	seen map[string]struct{}
+}
+
+func NewURLList() *URLList {
+	return &URLList{seen: make(map[string]struct{})}
+}
+
+// URLs returns the URLs in the submitted order
+func (l *URLList) URLs() []string {
+	return l.urls
+}
+
+// Add adds the URL unless it is blank, a comment starting with # or already added
+func (l *URLList) Add(url string) {
+	url = strings.TrimSpace(url)
+	if url == "" || strings.HasPrefix(url, "#") {
+		return
+	}
+	if _, ok := l.seen[url]; ok {
+		return
+	}
+	l.seen[url] = struct{}{}
+	l.urls = append(l.urls, url)
+}
+
+// ReadText adds the URLs of the plain text, one per line
+func (l *URLList) ReadText(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		l.Add(scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// ReadCSV adds the URLs of the first column of the CSV, the header of the column is skipped if it is url
+func (l *URLList) ReadCSV(r io.Reader) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.LazyQuotes = true
+
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if first && strings.EqualFold(strings.TrimSpace(record[0]), csvHeader) {
+			continue
+		}
+		l.Add(record[0])
+	}
+}
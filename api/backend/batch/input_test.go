@@ -0,0 +1,59 @@
+package batch_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"web-analyser/api/backend/batch"
+)
+
+func TestURLList(t *testing.T) {
+	tests := []*struct {
+		name         string
+		read         func(list *batch.URLList) error
+		expectedURLs []string
+	}{
+		{
+			name: "Should read a URL per line skipping the blank lines, the comments and the repeated URLs",
+			read: func(list *batch.URLList) error {
+				return list.ReadText(strings.NewReader(
+					"https://google.com\n\n# comment\n  https://google.de  \nhttps://google.com\n"))
+			},
+			expectedURLs: []string{"https://google.com", "https://google.de"},
+		},
+		{
+			name: "Should read the first column of the CSV skipping the url header",
+			read: func(list *batch.URLList) error {
+				return list.ReadCSV(strings.NewReader(
+					"URL,team\nhttps://google.com,search\n\"https://google.de\",\nhttps://google.com,maps\n"))
+			},
+			expectedURLs: []string{"https://google.com", "https://google.de"},
+		},
+		{
+			name: "Should read the first row of the CSV without a header",
+			read: func(list *batch.URLList) error {
+				return list.ReadCSV(strings.NewReader("https://google.com\nhttps://google.de"))
+			},
+			expectedURLs: []string{"https://google.com", "https://google.de"},
+		},
+		{
+			name: "Should merge the URLs of several sources in the submitted order",
+			read: func(list *batch.URLList) error {
+				list.Add("https://google.de")
+				return list.ReadText(strings.NewReader("https://google.com\nhttps://google.de"))
+			},
+			expectedURLs: []string{"https://google.de", "https://google.com"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			list := batch.NewURLList()
+			if err := tc.read(list); err != nil {
+				t.Fatalf("Expected:%v, Got:%v", nil, err)
+			}
+			if !reflect.DeepEqual(tc.expectedURLs, list.URLs()) {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedURLs, list.URLs())
+			}
+		})
+	}
+}
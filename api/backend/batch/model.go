@@ -0,0 +1,114 @@
+package batch
+
+import (
+	"net/url"
+	"time"
+	"web-analyser/api/backend/analyser"
+	iError "web-analyser/internal/utils/error"
+)
+
+// Status represents the state of a batch
+type Status string
+
+const (
+	StatusRunning Status = "running" // the URLs are being analysed
+	StatusDone    Status = "done"    // every URL was analysed or failed
+)
+
+// RowStatus represents the state of the analysis of a URL of the batch
+type RowStatus string
+
+const (
+	RowPending RowStatus = "pending" // the URL is waiting to be analysed
+	RowDone    RowStatus = "done"    // the URL was analysed, the summary fields are set
+	RowFailed  RowStatus = "failed"  // the URL is invalid or couldn't be analysed, the error is set
+)
+
+// Row represents the summary of the analysis of a URL of the batch
+type Row struct {
+	URL               string              `json:"url"`                  // URL represents the URL as submitted
+	Status            RowStatus           `json:"status"`               // Status represents the state of the row
+	FinalURL          string              `json:"finalUrl,omitempty"`   // FinalURL represents the URL after redirects
+	StatusCode        int                 `json:"statusCode,omitempty"` // StatusCode represents the http status code
+	Version           string              `json:"version,omitempty"`    // Version represents the HTML Version
+	Title             string              `json:"title,omitempty"`      // Title represents the HTML page Title
+	HasLoginForm      bool                `json:"hasLoginForm"`         // HasLoginForm represents the login form
+	Headings          int                 `json:"headings"`             // Headings represents the count of headers
+	InternalLinks     int                 `json:"internalLinks"`        // InternalLinks represents the internal links
+	ExternalLinks     int                 `json:"externalLinks"`        // ExternalLinks represents the external links
+	InaccessibleLinks int                 `json:"inaccessibleLinks"`    // InaccessibleLinks represents the broken links
+	Duration          time.Duration       `json:"duration"`             // Duration represents the time to analyse
+	Error             *iError.CustomError `json:"error,omitempty"`      // Error represents why the analysis failed
+
+	url *url.URL
+}
+
+// setSummary sets the fields of the row from the summary of the analysis
+func (r *Row) setSummary(summary *analyser.Summary) {
+	r.Status = RowDone
+	r.FinalURL = summary.FinalURL.String()
+	r.Version = summary.Version
+	r.Title = summary.Title
+	r.HasLoginForm = summary.HasLoginForm
+	for _, count := range summary.HeadersCount {
+		r.Headings += count
+	}
+	r.InternalLinks = len(summary.InternalLinksMap)
+	r.ExternalLinks = len(summary.ExternalLinksMap)
+	r.InaccessibleLinks = len(summary.InaccessibleLinksMap)
+}
+
+// Totals represents the totals of the rows of the batch
+type Totals struct {
+	URLs              int `json:"urls"`              // URLs represents the number of URLs of the batch
+	Finished          int `json:"finished"`          // Finished represents the number of URLs analysed or failed
+	Succeeded         int `json:"succeeded"`         // Succeeded represents the number of URLs analysed
+	Failed            int `json:"failed"`            // Failed represents the number of URLs which failed
+	WithLoginForm     int `json:"withLoginForm"`     // WithLoginForm represents the pages with a login form
+	InternalLinks     int `json:"internalLinks"`     // InternalLinks represents the internal links of all pages
+	ExternalLinks     int `json:"externalLinks"`     // ExternalLinks represents the external links of all pages
+	InaccessibleLinks int `json:"inaccessibleLinks"` // InaccessibleLinks represents the broken links of all pages
+}
+
+// Report represents the combined report of the analyses of the URLs of a batch
+type Report struct {
+	ID         string    `json:"id"`         // ID represents the unique id of the batch
+	RequestID  string    `json:"requestId"`  // RequestID represents the id of the request submitting the batch
+	Status     Status    `json:"status"`     // Status represents the state of the batch
+	CreatedAt  time.Time `json:"createdAt"`  // CreatedAt represents when the batch was submitted
+	FinishedAt time.Time `json:"finishedAt"` // FinishedAt represents when the last URL was analysed
+	Rows       []*Row    `json:"rows"`       // Rows represents the URLs in the submitted order
+	Totals     *Totals   `json:"totals"`     // Totals represents the totals of the rows
+}
+
+// IsFinished returns whether every URL of the batch was analysed or failed
+func (r *Report) IsFinished() bool {
+	return r.Status == StatusDone
+}
+
+// copy returns a copy of the report with a copy of its rows and their totals
+func (r *Report) copy() *Report {
+	c := *r
+	c.Rows = make([]*Row, 0, len(r.Rows))
+	c.Totals = &Totals{URLs: len(r.Rows)}
+	for _, row := range r.Rows {
+		rowCopy := *row
+		c.Rows = append(c.Rows, &rowCopy)
+
+		switch row.Status {
+		case RowDone:
+			c.Totals.Finished++
+			c.Totals.Succeeded++
+			c.Totals.InternalLinks += row.InternalLinks
+			c.Totals.ExternalLinks += row.ExternalLinks
+			c.Totals.InaccessibleLinks += row.InaccessibleLinks
+			if row.HasLoginForm {
+				c.Totals.WithLoginForm++
+			}
+		case RowFailed:
+			c.Totals.Finished++
+			c.Totals.Failed++
+		}
+	}
+	return &c
+}
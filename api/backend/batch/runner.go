@@ -0,0 +1,239 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"sync"
+	"time"
+	"web-analyser/api/backend/analyser"
+	"web-analyser/config"
+	iCtx "web-analyser/internal/utils/ctx"
+	iError "web-analyser/internal/utils/error"
+	iHttp "web-analyser/internal/utils/http"
+)
+
+// ErrNotFound is returned when there is no batch with the id
+var ErrNotFound = errors.New("batch not found")
+
+// Runner analyses the URLs of the batches in the background
+type Runner interface {
+	// Submit starts analysing the URLs and returns the report of the batch, the URLs are expected to be unique
+	Submit(urls []string, requestID string) *Report
+	// Get returns a snapshot of the report of the batch with the id, ErrNotFound if there is none
+	Get(id string) (*Report, error)
+}
+
+// RunnerImpl keeps the reports in memory and analyses the URLs of each batch concurrently
+type RunnerImpl struct {
+	analyser analyser.Analyser
+	recorder analyser.Recorder
+	conf     *config.BatchConf
+	logger   *zerolog.Logger
+	now      func() time.Time
+
+	mu      sync.RWMutex
+	ctx     context.Context
+	reports map[string]*Report
+
+	limits *limits
+}
+
+// limits counts the URLs being analysed across the batches, so that the concurrency and the per host limit apply to
+// all the batches together rather than to each batch
+type limits struct {
+	mu sync.Mutex
+	// freed is signalled when an analysis finishes or the context of a batch is done
+	freed   *sync.Cond
+	running int
+	hosts   map[string]int
+}
+
+func NewRunner(analyser analyser.Analyser, recorder analyser.Recorder, conf *config.BatchConf,
+	logger *zerolog.Logger) *RunnerImpl {
+	limits := &limits{hosts: make(map[string]int)}
+	limits.freed = sync.NewCond(&limits.mu)
+	return &RunnerImpl{
+		analyser: analyser,
+		recorder: recorder,
+		conf:     conf,
+		logger:   logger,
+		now:      time.Now,
+		ctx:      context.Background(),
+		reports:  make(map[string]*Report),
+		limits:   limits,
+	}
+}
+
+// Start binds the analyses of the batches to the context, the URLs which are not analysed yet once it is done fail
+func (b *RunnerImpl) Start(ctx context.Context) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.ctx = ctx
+}
+
+func (b *RunnerImpl) Submit(urls []string, requestID string) *Report {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.removeExpired()
+	report := &Report{
+		ID:        uuid.New().String(),
+		RequestID: requestID,
+		Status:    StatusRunning,
+		CreatedAt: b.now().UTC(),
+		Rows:      make([]*Row, 0, len(urls)),
+	}
+	for _, url := range urls {
+		row := &Row{URL: url, Status: RowPending}
+		// the invalid URLs fail right away with the reason why they are invalid
+		if parsedUrl, err := iHttp.ValidateURL(url); err != nil {
+			row.Status = RowFailed
+			row.Error = &iError.CustomError{Message: err.Error()}
+		} else {
+			row.url = parsedUrl
+		}
+		report.Rows = append(report.Rows, row)
+	}
+	b.reports[report.ID] = report
+
+	go b.run(iCtx.SetRequestID(b.ctx, requestID), report)
+	return report.copy()
+}
+
+func (b *RunnerImpl) Get(id string) (*Report, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	report, ok := b.reports[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return report.copy(), nil
+}
+
+// run analyses the pending rows of the report, at most Concurrency rows at a time and at most PerHost rows of the
+// same host at a time across all the batches. The hosts take turns, so that a host with many URLs doesn't delay the
+// URLs of the other hosts.
+func (b *RunnerImpl) run(ctx context.Context, report *Report) {
+	// the pending rows grouped by host, the hosts in the submitted order
+	var hosts []string
+	pending := make(map[string][]*Row)
+	left := 0
+	for _, row := range report.Rows {
+		if row.Status != RowPending {
+			continue
+		}
+		if _, ok := pending[row.url.Host]; !ok {
+			hosts = append(hosts, row.url.Host)
+		}
+		pending[row.url.Host] = append(pending[row.url.Host], row)
+		left++
+	}
+
+	// the batch waiting for a free slot stops waiting once the context is done
+	stop := context.AfterFunc(ctx, func() {
+		b.limits.mu.Lock()
+		defer b.limits.mu.Unlock()
+		b.limits.freed.Broadcast()
+	})
+	defer stop()
+
+	turn, running := 0, 0
+	// next returns the host whose turn it is among the hosts with pending rows and below the per host limit
+	next := func() (string, bool) {
+		for i := 0; i < len(hosts); i++ {
+			host := hosts[(turn+i)%len(hosts)]
+			if len(pending[host]) > 0 && b.limits.hosts[host] < max(b.conf.PerHost, 1) {
+				turn = (turn + i + 1) % len(hosts)
+				return host, true
+			}
+		}
+		return "", false
+	}
+
+	b.limits.mu.Lock()
+	for {
+		for b.limits.running < max(b.conf.Concurrency, 1) && ctx.Err() == nil {
+			host, ok := next()
+			if !ok {
+				break
+			}
+			row := pending[host][0]
+			pending[host] = pending[host][1:]
+			left--
+			b.limits.hosts[host]++
+			b.limits.running++
+			running++
+			go func() {
+				b.analyse(ctx, report.RequestID, row)
+
+				b.limits.mu.Lock()
+				defer b.limits.mu.Unlock()
+				if b.limits.hosts[host]--; b.limits.hosts[host] == 0 {
+					delete(b.limits.hosts, host)
+				}
+				b.limits.running--
+				running--
+				b.limits.freed.Broadcast()
+			}()
+		}
+		if running == 0 && (left == 0 || ctx.Err() != nil) {
+			break
+		}
+		b.limits.freed.Wait()
+	}
+	b.limits.mu.Unlock()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	// the rows left pending were not started because the context is done
+	for _, rows := range pending {
+		for _, row := range rows {
+			row.Status = RowFailed
+			row.Error = &iError.CustomError{Message: string(iError.AnalysisCancelledError)}
+		}
+	}
+	report.Status = StatusDone
+	report.FinishedAt = b.now().UTC()
+}
+
+// analyse analyses the URL of the row, sets the fields of the row and records the summary in the history
+func (b *RunnerImpl) analyse(ctx context.Context, requestID string, row *Row) {
+	start := b.now()
+	summary, err, statusCode := b.analyser.Analyse(ctx, row.url)
+	duration := b.now().Sub(start)
+
+	if err != nil {
+		_, customError := analyser.AnalysisError(err, statusCode)
+		b.logger.Error().Str(iCtx.KeyRequestID, requestID).Str("url", row.URL).Err(err).Msg(customError.Message)
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		row.Status = RowFailed
+		row.StatusCode = statusCode
+		row.Duration = duration
+		row.Error = customError
+		return
+	}
+
+	// the analysis is reported even if it can't be recorded
+	if err := b.recorder.Record(requestID, summary, statusCode, duration); err != nil {
+		b.logger.Error().Str(iCtx.KeyRequestID, requestID).Err(err).Msg("unable to record the analysis")
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	row.setSummary(summary)
+	row.StatusCode = statusCode
+	row.Duration = duration
+}
+
+// removeExpired removes the reports of the batches which finished more than the TTL ago, the caller must hold the
+// lock
+func (b *RunnerImpl) removeExpired() {
+	for id, report := range b.reports {
+		if report.IsFinished() && b.now().Sub(report.FinishedAt) > b.conf.TTL {
+			delete(b.reports, id)
+		}
+	}
+}
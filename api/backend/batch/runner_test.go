@@ -0,0 +1,299 @@
+package batch_test
+
+import (
+	"context"
+	"errors"
+	"go.uber.org/mock/gomock"
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+	"web-analyser/api/backend/analyser"
+	"web-analyser/api/backend/batch"
+	"web-analyser/api/backend/history"
+	"web-analyser/config"
+	iError "web-analyser/internal/utils/error"
+	l "web-analyser/internal/utils/logger"
+	"web-analyser/mocks"
+)
+
+// newSummary returns the summary of the url with the title, a login form and a link of each kind
+func newSummary(u *url.URL, title string) *analyser.Summary {
+	summary := analyser.NewSummary(u)
+	summary.SetTitle(title)
+	summary.SetHasLoginForm(true)
+	summary.IncrementHeadersCount("h1")
+	summary.AddInternalLink("/about")
+	summary.AddExternalLink("https://example.com")
+	summary.AddInaccessibleLink("/broken")
+	return summary
+}
+
+// wait returns the report of the batch with the id once it is finished
+func wait(t *testing.T, r batch.Runner, id string) *batch.Report {
+	timeout := time.After(time.Second)
+	for {
+		report, err := r.Get(id)
+		if err != nil {
+			t.Fatalf("Expected:%v, Got:%v", nil, err)
+		}
+		if report.IsFinished() {
+			return report
+		}
+		select {
+		case <-time.After(time.Millisecond):
+		case <-timeout:
+			t.Fatalf("Expected:%v, Got:%v", "the batch to finish", "timeout")
+		}
+	}
+}
+
+// statuses returns the statuses of the rows of the report
+func statuses(report *batch.Report) []batch.RowStatus {
+	var s []batch.RowStatus
+	for _, row := range report.Rows {
+		s = append(s, row.Status)
+	}
+	return s
+}
+
+func TestRunnerImpl_Run(t *testing.T) {
+	tests := []*struct {
+		name              string
+		urls              []string
+		setupExpectations func(*mocks.MockAnalyser)
+		expectedStatuses  []batch.RowStatus
+		expectedErrors    []*iError.CustomError
+		expectedTotals    *batch.Totals
+		expectedRecords   int
+	}{
+		{
+			name: "Should analyse the urls, record the summaries and total the rows",
+			urls: []string{"https://google.com", "https://google.de"},
+			setupExpectations: func(mockAnalyser *mocks.MockAnalyser) {
+				mockAnalyser.EXPECT().Analyse(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, u *url.URL) (*analyser.Summary, error, int) {
+						return newSummary(u, "Google"), nil, 200
+					}).Times(2)
+			},
+			expectedStatuses: []batch.RowStatus{batch.RowDone, batch.RowDone},
+			expectedErrors:   []*iError.CustomError{nil, nil},
+			expectedTotals: &batch.Totals{URLs: 2, Finished: 2, Succeeded: 2, WithLoginForm: 2, InternalLinks: 2,
+				ExternalLinks: 2, InaccessibleLinks: 2},
+			expectedRecords: 1,
+		},
+		{
+			name: "Should fail the rows of the invalid urls and of the urls which can't be analysed",
+			urls: []string{"ftp://google.com", "https://google.com"},
+			setupExpectations: func(mockAnalyser *mocks.MockAnalyser) {
+				mockAnalyser.EXPECT().Analyse(gomock.Any(), gomock.Any()).Return(nil, errors.New("error"), 404)
+			},
+			expectedStatuses: []batch.RowStatus{batch.RowFailed, batch.RowFailed},
+			expectedErrors: []*iError.CustomError{
+				{Message: string(iError.UnsupportedSchemeError)},
+				{Message: string(iError.UnreachableURLError), HttpStatusCode: 404},
+			},
+			expectedTotals: &batch.Totals{URLs: 2, Finished: 2, Failed: 2},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockAnalyser := mocks.NewMockAnalyser(ctrl)
+			tc.setupExpectations(mockAnalyser)
+			store := history.NewMemoryStore(&config.HistoryConf{})
+
+			r := batch.NewRunner(mockAnalyser, history.NewRecorder(store),
+				&config.BatchConf{Concurrency: 2, PerHost: 1, TTL: time.Hour}, l.NewLogger(false))
+			submitted := r.Submit(tc.urls, "request")
+			if submitted.RequestID != "request" || len(submitted.Rows) != len(tc.urls) {
+				t.Fatalf("Expected:%v, Got:%+v", tc.urls, submitted)
+			}
+
+			finished := wait(t, r, submitted.ID)
+			if !reflect.DeepEqual(tc.expectedStatuses, statuses(finished)) {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedStatuses, statuses(finished))
+			}
+			for i, row := range finished.Rows {
+				if !reflect.DeepEqual(tc.expectedErrors[i], row.Error) {
+					t.Fatalf("Expected:%+v, Got:%+v", tc.expectedErrors[i], row.Error)
+				}
+			}
+			if !reflect.DeepEqual(tc.expectedTotals, finished.Totals) {
+				t.Fatalf("Expected:%+v, Got:%+v", tc.expectedTotals, finished.Totals)
+			}
+
+			records, _ := store.List("https://google.com/")
+			if len(records) != tc.expectedRecords {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedRecords, len(records))
+			}
+		})
+	}
+}
+
+func TestRunnerImpl_Run_HostsTakeTurns(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var mu sync.Mutex
+	var analysed []string
+	mockAnalyser := mocks.NewMockAnalyser(ctrl)
+	mockAnalyser.EXPECT().Analyse(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, u *url.URL) (*analyser.Summary, error, int) {
+			mu.Lock()
+			defer mu.Unlock()
+			analysed = append(analysed, u.String())
+			return newSummary(u, "Google"), nil, 200
+		}).Times(4)
+	mockRecorder := mocks.NewMockRecorder(ctrl)
+	mockRecorder.EXPECT().Record(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(4)
+
+	// a single URL at a time, so the order of the analyses is the order of the turns
+	r := batch.NewRunner(mockAnalyser, mockRecorder, &config.BatchConf{Concurrency: 1, PerHost: 1, TTL: time.Hour},
+		l.NewLogger(false))
+	submitted := r.Submit([]string{"https://google.com/1", "https://google.com/2", "https://google.com/3",
+		"https://google.de/1"}, "request")
+	wait(t, r, submitted.ID)
+
+	expected := []string{"https://google.com/1", "https://google.de/1", "https://google.com/2", "https://google.com/3"}
+	if !reflect.DeepEqual(expected, analysed) {
+		t.Fatalf("Expected:%v, Got:%v", expected, analysed)
+	}
+}
+
+func TestRunnerImpl_Run_PerHost(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var mu sync.Mutex
+	active, maxActive := 0, 0
+	mockAnalyser := mocks.NewMockAnalyser(ctrl)
+	mockAnalyser.EXPECT().Analyse(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, u *url.URL) (*analyser.Summary, error, int) {
+			mu.Lock()
+			active++
+			maxActive = max(maxActive, active)
+			mu.Unlock()
+
+			time.Sleep(5 * time.Millisecond)
+
+			mu.Lock()
+			active--
+			mu.Unlock()
+			return newSummary(u, "Google"), nil, 200
+		}).Times(3)
+	mockRecorder := mocks.NewMockRecorder(ctrl)
+	mockRecorder.EXPECT().Record(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(3)
+
+	r := batch.NewRunner(mockAnalyser, mockRecorder, &config.BatchConf{Concurrency: 4, PerHost: 1, TTL: time.Hour},
+		l.NewLogger(false))
+	submitted := r.Submit([]string{"https://google.com/1", "https://google.com/2", "https://google.com/3"},
+		"request")
+	wait(t, r, submitted.ID)
+
+	if maxActive != 1 {
+		t.Fatalf("Expected:%v, Got:%v", 1, maxActive)
+	}
+}
+
+func TestRunnerImpl_Run_SharedLimits(t *testing.T) {
+	tests := []*struct {
+		name      string
+		conf      *config.BatchConf
+		batches   [][]string
+		maxActive int
+	}{
+		{
+			name:      "Should analyse at most Concurrency urls at a time across the batches",
+			conf:      &config.BatchConf{Concurrency: 1, PerHost: 2, TTL: time.Hour},
+			batches:   [][]string{{"https://google.com/1"}, {"https://google.de/1"}, {"https://google.fr/1"}},
+			maxActive: 1,
+		},
+		{
+			name:      "Should analyse at most PerHost urls of the same host at a time across the batches",
+			conf:      &config.BatchConf{Concurrency: 4, PerHost: 1, TTL: time.Hour},
+			batches:   [][]string{{"https://google.com/1"}, {"https://google.com/2"}, {"https://google.com/3"}},
+			maxActive: 1,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			var mu sync.Mutex
+			active, maxActive := 0, 0
+			mockAnalyser := mocks.NewMockAnalyser(ctrl)
+			mockAnalyser.EXPECT().Analyse(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, u *url.URL) (*analyser.Summary, error, int) {
+					mu.Lock()
+					active++
+					maxActive = max(maxActive, active)
+					mu.Unlock()
+
+					time.Sleep(5 * time.Millisecond)
+
+					mu.Lock()
+					active--
+					mu.Unlock()
+					return newSummary(u, "Google"), nil, 200
+				}).Times(len(tc.batches))
+			mockRecorder := mocks.NewMockRecorder(ctrl)
+			mockRecorder.EXPECT().Record(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).
+				Times(len(tc.batches))
+
+			r := batch.NewRunner(mockAnalyser, mockRecorder, tc.conf, l.NewLogger(false))
+			var submitted []*batch.Report
+			for _, urls := range tc.batches {
+				submitted = append(submitted, r.Submit(urls, "request"))
+			}
+			for _, report := range submitted {
+				wait(t, r, report.ID)
+			}
+
+			if maxActive != tc.maxActive {
+				t.Fatalf("Expected:%v, Got:%v", tc.maxActive, maxActive)
+			}
+		})
+	}
+}
+
+func TestRunnerImpl_Run_Cancelled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// the analyser is not expected to be called since the context is done
+	r := batch.NewRunner(mocks.NewMockAnalyser(ctrl), mocks.NewMockRecorder(ctrl),
+		&config.BatchConf{Concurrency: 1, PerHost: 1, TTL: time.Hour}, l.NewLogger(false))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r.Start(ctx)
+
+	submitted := r.Submit([]string{"https://google.com", "https://google.de"}, "request")
+	finished := wait(t, r, submitted.ID)
+
+	expected := &iError.CustomError{Message: string(iError.AnalysisCancelledError)}
+	for _, row := range finished.Rows {
+		if row.Status != batch.RowFailed || !reflect.DeepEqual(expected, row.Error) {
+			t.Fatalf("Expected:%v, Got:%v, %+v", batch.RowFailed, row.Status, row.Error)
+		}
+	}
+}
+
+func TestRunnerImpl_Get(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r := batch.NewRunner(mocks.NewMockAnalyser(ctrl), mocks.NewMockRecorder(ctrl),
+		&config.BatchConf{Concurrency: 1, PerHost: 1, TTL: time.Hour}, l.NewLogger(false))
+	// the invalid URL fails right away, so the batch finishes without analysing
+	submitted := r.Submit([]string{"ftp://google.com"}, "request")
+	wait(t, r, submitted.ID)
+
+	if _, err := r.Get("unknown"); !errors.Is(err, batch.ErrNotFound) {
+		t.Fatalf("Expected:%v, Got:%v", batch.ErrNotFound, err)
+	}
+}
@@ -0,0 +1,155 @@
+package compare
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"web-analyser/api/backend/analyser"
+)
+
+// Diff represents what changed from a summary to another summary, the fields which didn't change are left empty
+type Diff struct {
+	From string `json:"from"` // From represents the URL of the older summary
+	To   string `json:"to"`   // To represents the URL of the newer summary
+	// Version represents the HTML version change
+	Version *StringChange `json:"version,omitempty"`
+	// Title represents the title change
+	Title *StringChange `json:"title,omitempty"`
+	// HasLoginForm represents the login form appearing or disappearing
+	HasLoginForm *BoolChange `json:"hasLoginForm,omitempty"`
+	// HeadersCount represents the change of each header type whose count changed
+	HeadersCount map[string]*CountChange `json:"headersCount"`
+	// InternalLinks represents the internal links added and removed, the links of the host of the page are compared
+	// by their path and query, so that a staging website can be compared against the production one
+	InternalLinks *LinksChange `json:"internalLinks"`
+	// ExternalLinks represents the external links added and removed
+	ExternalLinks *LinksChange `json:"externalLinks"`
+	// InaccessibleLinks represents the inaccessible links added and removed
+	InaccessibleLinks *LinksChange `json:"inaccessibleLinks"`
+}
+
+// StringChange represents a changed text value
+type StringChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// BoolChange represents a changed flag
+type BoolChange struct {
+	From bool `json:"from"`
+	To   bool `json:"to"`
+}
+
+// CountChange represents a changed count, Delta is negative if the count decreased
+type CountChange struct {
+	From  int `json:"from"`
+	To    int `json:"to"`
+	Delta int `json:"delta"`
+}
+
+// LinksChange represents the links added and removed, both sorted
+type LinksChange struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+// HasChanges returns whether any link was added or removed
+func (l *LinksChange) HasChanges() bool {
+	return len(l.Added) > 0 || len(l.Removed) > 0
+}
+
+// Summaries returns the diff of the summaries, from being the older summary and to the newer one
+func Summaries(from *analyser.Summary, to *analyser.Summary) *Diff {
+	d := &Diff{
+		From:              urlString(from),
+		To:                urlString(to),
+		HeadersCount:      headersCountChanges(from.HeadersCount, to.HeadersCount),
+		InternalLinks:     linksChange(internalLinks(from), internalLinks(to)),
+		ExternalLinks:     linksChange(from.ExternalLinksMap, to.ExternalLinksMap),
+		InaccessibleLinks: linksChange(from.InaccessibleLinksMap, to.InaccessibleLinksMap),
+	}
+	if from.Version != to.Version {
+		d.Version = &StringChange{From: from.Version, To: to.Version}
+	}
+	if from.Title != to.Title {
+		d.Title = &StringChange{From: from.Title, To: to.Title}
+	}
+	if from.HasLoginForm != to.HasLoginForm {
+		d.HasLoginForm = &BoolChange{From: from.HasLoginForm, To: to.HasLoginForm}
+	}
+	return d
+}
+
+// HasChanges returns whether anything changed from a summary to the other
+func (d *Diff) HasChanges() bool {
+	return d.Version != nil || d.Title != nil || d.HasLoginForm != nil || len(d.HeadersCount) > 0 ||
+		d.InternalLinks.HasChanges() || d.ExternalLinks.HasChanges() || d.InaccessibleLinks.HasChanges()
+}
+
+// urlString returns the URL of the summary, empty if there is none
+func urlString(summary *analyser.Summary) string {
+	if summary.URL == nil {
+		return ""
+	}
+	return summary.URL.String()
+}
+
+// internalLinks returns the internal links of the summary, the links of the host of the page, ignoring the www.
+// prefix, are replaced by their path and query while the links of the other hosts of the site are kept absolute
+func internalLinks(summary *analyser.Summary) map[string]struct{} {
+	page := summary.FinalURL
+	if page == nil {
+		page = summary.URL
+	}
+	if page == nil {
+		return summary.InternalLinksMap
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(page.Hostname()), "www.")
+	links := make(map[string]struct{}, len(summary.InternalLinksMap))
+	for link := range summary.InternalLinksMap {
+		if u, err := url.Parse(link); err == nil && strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.") == host {
+			link = u.RequestURI()
+		}
+		links[link] = struct{}{}
+	}
+	return links
+}
+
+// headersCountChanges returns the change of each header whose count differs, a missing header is counted as 0
+func headersCountChanges(from map[string]int, to map[string]int) map[string]*CountChange {
+	changes := make(map[string]*CountChange)
+	for header, count := range from {
+		if to[header] != count {
+			changes[header] = &CountChange{From: count, To: to[header], Delta: to[header] - count}
+		}
+	}
+	for header, count := range to {
+		if _, ok := from[header]; !ok && count != 0 {
+			changes[header] = &CountChange{To: count, Delta: count}
+		}
+	}
+	return changes
+}
+
+// linksChange returns the links of to which are not in from as added, and the links of from which are not in to
+// as removed
+func linksChange(from map[string]struct{}, to map[string]struct{}) *LinksChange {
+	return &LinksChange{
+		Added:   difference(to, from),
+		Removed: difference(from, to),
+	}
+}
+
+// difference returns the sorted keys of a which are not in b, it never returns nil so that it's encoded as an
+// empty list
+func difference(a map[string]struct{}, b map[string]struct{}) []string {
+	keys := []string{}
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,92 @@
+package compare_test
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"web-analyser/api/backend/analyser"
+	"web-analyser/api/backend/compare"
+)
+
+// newSummary returns the summary of the url with the title, version, login form, headers and internal links
+func newSummary(page string, title string, version string, hasLoginForm bool, headers []string,
+	links []string) *analyser.Summary {
+	u, _ := url.Parse(page)
+	summary := analyser.NewSummary(u)
+	summary.SetTitle(title)
+	summary.SetVersion(version)
+	summary.SetHasLoginForm(hasLoginForm)
+	for _, header := range headers {
+		summary.IncrementHeadersCount(header)
+	}
+	for _, link := range links {
+		summary.AddInternalLink(link)
+	}
+	return summary
+}
+
+func TestSummaries(t *testing.T) {
+	tests := []*struct {
+		name               string
+		from               *analyser.Summary
+		to                 *analyser.Summary
+		expectedDiff       *compare.Diff
+		expectedHasChanges bool
+	}{
+		{
+			name: "Should return no changes for the same summaries",
+			from: newSummary("https://google.com", "Google", "HTML 5", false, []string{"h1"},
+				[]string{"https://google.com/a"}),
+			to: newSummary("https://google.com", "Google", "HTML 5", false, []string{"h1"},
+				[]string{"https://google.com/a"}),
+			expectedDiff: &compare.Diff{
+				From:              "https://google.com",
+				To:                "https://google.com",
+				HeadersCount:      map[string]*compare.CountChange{},
+				InternalLinks:     &compare.LinksChange{Added: []string{}, Removed: []string{}},
+				ExternalLinks:     &compare.LinksChange{Added: []string{}, Removed: []string{}},
+				InaccessibleLinks: &compare.LinksChange{Added: []string{}, Removed: []string{}},
+			},
+		},
+		{
+			name: "Should return the changed fields, the header count deltas and the added and removed links, " +
+				"comparing the internal links of the page hosts by path",
+			from: newSummary("https://staging.google.com", "Google", "HTML 4.01", false,
+				[]string{"h1", "h2", "h2", "h3"}, []string{"https://staging.google.com/a",
+					"https://staging.google.com/b?q=1", "https://google.de/e"}),
+			to: newSummary("https://google.com", "Google Search", "HTML 5", true,
+				[]string{"h1", "h2", "h4", "h4"}, []string{"https://google.com/b?q=1", "https://www.google.com/d",
+					"http://google.com/c", "https://google.de/f"}),
+			expectedDiff: &compare.Diff{
+				From:         "https://staging.google.com",
+				To:           "https://google.com",
+				Version:      &compare.StringChange{From: "HTML 4.01", To: "HTML 5"},
+				Title:        &compare.StringChange{From: "Google", To: "Google Search"},
+				HasLoginForm: &compare.BoolChange{From: false, To: true},
+				HeadersCount: map[string]*compare.CountChange{
+					"h2": {From: 2, To: 1, Delta: -1},
+					"h3": {From: 1, To: 0, Delta: -1},
+					"h4": {From: 0, To: 2, Delta: 2},
+				},
+				InternalLinks: &compare.LinksChange{
+					Added:   []string{"/c", "/d", "https://google.de/f"},
+					Removed: []string{"/a", "https://google.de/e"},
+				},
+				ExternalLinks:     &compare.LinksChange{Added: []string{}, Removed: []string{}},
+				InaccessibleLinks: &compare.LinksChange{Added: []string{}, Removed: []string{}},
+			},
+			expectedHasChanges: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			diff := compare.Summaries(tc.from, tc.to)
+			if !reflect.DeepEqual(tc.expectedDiff, diff) {
+				t.Fatalf("Expected:%+v, Got:%+v", tc.expectedDiff, diff)
+			}
+			if diff.HasChanges() != tc.expectedHasChanges {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedHasChanges, diff.HasChanges())
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package compare
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/rs/zerolog"
+	"mime"
+	"net/http"
+	"sync"
+	"web-analyser/api/backend/analyser"
+	"web-analyser/api/backend/history"
+	iCtx "web-analyser/internal/utils/ctx"
+	iError "web-analyser/internal/utils/error"
+	iHttp "web-analyser/internal/utils/http"
+	"web-analyser/internal/utils/render"
+)
+
+type Handler interface {
+	Page(w http.ResponseWriter, r *http.Request)
+	Compare(w http.ResponseWriter, r *http.Request)
+}
+
+type HandlerImpl struct {
+	logger   *zerolog.Logger
+	tpl      analyser.Template
+	analyser analyser.Analyser
+	store    history.Store
+}
+
+// compareRequest is the JSON body accepted by the compare api, each side of the comparison is either the id of a
+// stored analysis or a URL to analyse, the stored analysis wins if both are set
+type compareRequest struct {
+	FromID  string `json:"fromId"`
+	FromURL string `json:"fromUrl"`
+	ToID    string `json:"toId"`
+	ToURL   string `json:"toUrl"`
+}
+
+// isEmpty returns whether none of the sides of the comparison is set
+func (c *compareRequest) isEmpty() bool {
+	return c.FromID == "" && c.FromURL == "" && c.ToID == "" && c.ToURL == ""
+}
+
+// comparePage is the data of the compare page, Diff is nil until both sides are set
+type comparePage struct {
+	Request *compareRequest
+	Diff    *Diff
+}
+
+func NewHandler(logger *zerolog.Logger, tpl analyser.Template, analyser analyser.Analyser,
+	store history.Store) *HandlerImpl {
+	return &HandlerImpl{
+		logger:   logger,
+		tpl:      tpl,
+		analyser: analyser,
+		store:    store,
+	}
+}
+
+// Page renders the compare page, along with the diff of the analyses if the sides of the comparison are set in the
+// query values.
+func (h *HandlerImpl) Page(w http.ResponseWriter, r *http.Request) {
+	request := formRequest(r)
+	if request.isEmpty() {
+		render.Template(w, r, h.logger, h.tpl, "compare.gohtml", comparePage{Request: request})
+		return
+	}
+
+	diff, _, customError := h.compare(r, request)
+	if customError != nil {
+		render.Template(w, r, h.logger, h.tpl, "error.gohtml", *customError)
+		return
+	}
+	render.Template(w, r, h.logger, h.tpl, "compare.gohtml", comparePage{Request: request, Diff: diff})
+}
+
+// Compare returns the diff of the analyses as JSON, the sides of the comparison are read from the JSON body in case
+// of a JSON request, otherwise from the query or form values.
+func (h *HandlerImpl) Compare(w http.ResponseWriter, r *http.Request) {
+	request := formRequest(r)
+
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == iHttp.ContentTypeJSON {
+		request = &compareRequest{}
+		if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+			h.logger.Error().Str(iCtx.KeyRequestID, iCtx.RequestID(r.Context())).Err(err).Msg("invalid request body")
+			render.JSON(w, r, h.logger, http.StatusBadRequest,
+				analyser.ErrorResponse{Error: iError.CustomError{Message: string(iError.InvalidCompareRequestError)}})
+			return
+		}
+	}
+
+	diff, statusCode, customError := h.compare(r, request)
+	if customError != nil {
+		render.JSON(w, r, h.logger, statusCode, analyser.ErrorResponse{Error: *customError})
+		return
+	}
+	render.JSON(w, r, h.logger, http.StatusOK, diff)
+}
+
+// formRequest returns the sides of the comparison set in the query or form values
+func formRequest(r *http.Request) *compareRequest {
+	return &compareRequest{
+		FromID:  r.FormValue("fromId"),
+		FromURL: r.FormValue("fromUrl"),
+		ToID:    r.FormValue("toId"),
+		ToURL:   r.FormValue("toUrl"),
+	}
+}
+
+// compare loads both sides of the comparison, the live URLs being analysed concurrently, and returns their diff. In
+// case of failure, it returns the http status code to respond with along with the error of the first failing side
+func (h *HandlerImpl) compare(r *http.Request, request *compareRequest) (*Diff, int, *iError.CustomError) {
+	var from, to *analyser.Summary
+	var fromStatusCode, toStatusCode int
+	var fromError, toError *iError.CustomError
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		from, fromStatusCode, fromError = h.summary(r, request.FromID, request.FromURL)
+	}()
+	go func() {
+		defer wg.Done()
+		to, toStatusCode, toError = h.summary(r, request.ToID, request.ToURL)
+	}()
+	wg.Wait()
+
+	if fromError != nil {
+		return nil, fromStatusCode, fromError
+	}
+	if toError != nil {
+		return nil, toStatusCode, toError
+	}
+	return Summaries(from, to), http.StatusOK, nil
+}
+
+// summary returns the summary of the stored analysis with the id if set, otherwise the summary of analysing the url.
+// In case of failure, it logs the error and returns the http status code to respond with along with the error to be
+// conveyed to the user
+func (h *HandlerImpl) summary(r *http.Request, id string, url string) (*analyser.Summary, int,
+	*iError.CustomError) {
+	reqID := iCtx.RequestID(r.Context())
+
+	if id != "" {
+		record, err := h.store.Get(id)
+		if errors.Is(err, history.ErrNotFound) {
+			return nil, http.StatusNotFound, &iError.CustomError{Message: string(iError.RecordNotFoundError)}
+		}
+		if err != nil {
+			h.logger.Error().Str(iCtx.KeyRequestID, reqID).Err(err).Msg("history store error")
+			return nil, http.StatusInternalServerError,
+				&iError.CustomError{Message: string(iError.HistoryUnavailableError)}
+		}
+		return record.Summary, http.StatusOK, nil
+	}
+
+	if url == "" {
+		return nil, http.StatusBadRequest, &iError.CustomError{Message: string(iError.IncompleteComparisonError)}
+	}
+
+	parsedUrl, err := iHttp.ValidateURL(url)
+	if err != nil {
+		h.logger.Error().Str(iCtx.KeyRequestID, reqID).Str("url", url).Err(err).Msg("invalid URL")
+		return nil, http.StatusBadRequest, &iError.CustomError{Message: err.Error()}
+	}
+
+	summary, err, statusCode := h.analyser.Analyse(r.Context(), parsedUrl)
+	if err != nil {
+		responseStatusCode, customError := analyser.AnalysisError(err, statusCode)
+		h.logger.Error().Str(iCtx.KeyRequestID, reqID).Err(err).Msg(customError.Message)
+		return nil, responseStatusCode, customError
+	}
+	return summary, http.StatusOK, nil
+}
@@ -0,0 +1,195 @@
+package compare_test
+
+import (
+	"encoding/json"
+	"errors"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	netUrl "net/url"
+	"strings"
+	"testing"
+	"time"
+	"web-analyser/api/backend/analyser"
+	"web-analyser/api/backend/compare"
+	"web-analyser/api/backend/history"
+	"web-analyser/config"
+	iError "web-analyser/internal/utils/error"
+	iHttp "web-analyser/internal/utils/http"
+	l "web-analyser/internal/utils/logger"
+	"web-analyser/mocks"
+)
+
+const recordID = "9b2c1e9e-0b7a-4d0e-9d43-4a0f4c1b7a01"
+
+// newStore returns a memory store with a stored analysis of https://google.com titled Old
+func newStore() history.Store {
+	store := history.NewMemoryStore(&config.HistoryConf{})
+	_ = store.Save(&history.Record{
+		ID:        recordID,
+		URL:       "https://google.com/",
+		CreatedAt: time.Now().UTC(),
+		Summary:   newSummary("https://google.com", "Old", "", false, nil, []string{"https://google.com/a"}),
+	})
+	return store
+}
+
+func TestHandlerImpl_Page(t *testing.T) {
+	tests := []*struct {
+		name              string
+		query             string
+		setupExpectations func(*httptest.ResponseRecorder, *mocks.MockAnalyser, *mocks.MockTemplate)
+	}{
+		{
+			"Should render compare template without diff if no side is set", "",
+			func(w *httptest.ResponseRecorder, mockAnalyser *mocks.MockAnalyser, mockTemplate *mocks.MockTemplate) {
+				mockTemplate.EXPECT().ExecuteTemplate(w, "compare.gohtml", gomock.Any())
+			},
+		},
+		{
+			"Should render error template if a side is missing", "fromUrl=https://google.com",
+			func(w *httptest.ResponseRecorder, mockAnalyser *mocks.MockAnalyser, mockTemplate *mocks.MockTemplate) {
+				u, _ := netUrl.Parse("https://google.com")
+				mockAnalyser.EXPECT().Analyse(gomock.Any(), u).Return(analyser.NewSummary(u), nil, 200)
+				mockTemplate.EXPECT().ExecuteTemplate(w, "error.gohtml", iError.CustomError{
+					Message: string(iError.IncompleteComparisonError)})
+			},
+		},
+		{
+			"Should render compare template with the diff of the stored analysis and the live url",
+			"fromId=" + recordID + "&toUrl=https://google.com",
+			func(w *httptest.ResponseRecorder, mockAnalyser *mocks.MockAnalyser, mockTemplate *mocks.MockTemplate) {
+				u, _ := netUrl.Parse("https://google.com")
+				mockAnalyser.EXPECT().Analyse(gomock.Any(), u).Return(newSummary("https://google.com", "New", "", false, nil,
+					nil), nil, 200)
+				mockTemplate.EXPECT().ExecuteTemplate(w, "compare.gohtml", gomock.Any())
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockedAnalyser := mocks.NewMockAnalyser(ctrl)
+			mockedTemplate := mocks.NewMockTemplate(ctrl)
+			handler := compare.NewHandler(l.NewLogger(false), mockedTemplate, mockedAnalyser, newStore())
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/compare?"+tc.query, nil)
+			tc.setupExpectations(w, mockedAnalyser, mockedTemplate)
+			handler.Page(w, r)
+		})
+	}
+}
+
+func TestHandlerImpl_Compare(t *testing.T) {
+	tests := []*struct {
+		name               string
+		request            *http.Request
+		setupExpectations  func(*mocks.MockAnalyser)
+		expectedStatusCode int
+		expectedError      iError.Msg
+		expectedTitle      *compare.StringChange
+	}{
+		{
+			name: "Should return the diff of the live urls",
+			request: httptest.NewRequest(http.MethodGet,
+				"/api/v1/comparisons?fromUrl=https://staging.google.com&toUrl=https://google.com", nil),
+			setupExpectations: func(mockAnalyser *mocks.MockAnalyser) {
+				staging, _ := netUrl.Parse("https://staging.google.com")
+				production, _ := netUrl.Parse("https://google.com")
+				mockAnalyser.EXPECT().Analyse(gomock.Any(), staging).Return(newSummary("https://staging.google.com", "New", "",
+					false, nil, nil), nil, 200)
+				mockAnalyser.EXPECT().Analyse(gomock.Any(), production).Return(newSummary("https://google.com", "Old", "",
+					false, nil, nil), nil, 200)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedTitle:      &compare.StringChange{From: "New", To: "Old"},
+		},
+		{
+			name: "Should return the diff of the stored analysis and the live url of the JSON body",
+			request: func() *http.Request {
+				r := httptest.NewRequest(http.MethodPost, "/api/v1/comparisons",
+					strings.NewReader(`{"fromId": "`+recordID+`", "toUrl": "https://google.com"}`))
+				r.Header.Set("Content-Type", iHttp.ContentTypeJSON)
+				return r
+			}(),
+			setupExpectations: func(mockAnalyser *mocks.MockAnalyser) {
+				u, _ := netUrl.Parse("https://google.com")
+				mockAnalyser.EXPECT().Analyse(gomock.Any(), u).Return(newSummary("https://google.com", "New", "", false, nil,
+					nil), nil, 200)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedTitle:      &compare.StringChange{From: "Old", To: "New"},
+		},
+		{
+			name: "Should return bad request for an invalid JSON body",
+			request: func() *http.Request {
+				r := httptest.NewRequest(http.MethodPost, "/api/v1/comparisons", strings.NewReader(`{`))
+				r.Header.Set("Content-Type", iHttp.ContentTypeJSON)
+				return r
+			}(),
+			setupExpectations:  func(mockAnalyser *mocks.MockAnalyser) {},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      iError.InvalidCompareRequestError,
+		},
+		{
+			name: "Should return not found for an unknown stored analysis",
+			request: httptest.NewRequest(http.MethodGet,
+				"/api/v1/comparisons?fromId=unknown&toId="+recordID, nil),
+			setupExpectations:  func(mockAnalyser *mocks.MockAnalyser) {},
+			expectedStatusCode: http.StatusNotFound,
+			expectedError:      iError.RecordNotFoundError,
+		},
+		{
+			name: "Should return bad request for an invalid url",
+			request: httptest.NewRequest(http.MethodGet,
+				"/api/v1/comparisons?fromId="+recordID+"&toUrl=ftp://google.com", nil),
+			setupExpectations:  func(mockAnalyser *mocks.MockAnalyser) {},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      iError.UnsupportedSchemeError,
+		},
+		{
+			name: "Should return bad gateway if analysing the url returns error",
+			request: httptest.NewRequest(http.MethodGet,
+				"/api/v1/comparisons?fromId="+recordID+"&toUrl=https://google.com", nil),
+			setupExpectations: func(mockAnalyser *mocks.MockAnalyser) {
+				u, _ := netUrl.Parse("https://google.com")
+				mockAnalyser.EXPECT().Analyse(gomock.Any(), u).Return(nil, errors.New("error"), 0)
+			},
+			expectedStatusCode: http.StatusBadGateway,
+			expectedError:      iError.UnreachableURLError,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockedAnalyser := mocks.NewMockAnalyser(ctrl)
+			tc.setupExpectations(mockedAnalyser)
+			handler := compare.NewHandler(l.NewLogger(false), mocks.NewMockTemplate(ctrl), mockedAnalyser,
+				newStore())
+			w := httptest.NewRecorder()
+			handler.Compare(w, tc.request)
+
+			if w.Code != tc.expectedStatusCode {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedStatusCode, w.Code)
+			}
+			if tc.expectedError != "" {
+				var response analyser.ErrorResponse
+				_ = json.NewDecoder(w.Body).Decode(&response)
+				if response.Error.Message != string(tc.expectedError) {
+					t.Fatalf("Expected:%v, Got:%v", tc.expectedError, response.Error.Message)
+				}
+				return
+			}
+
+			var diff compare.Diff
+			_ = json.NewDecoder(w.Body).Decode(&diff)
+			if diff.Title == nil || *diff.Title != *tc.expectedTitle {
+				t.Fatalf("Expected:%+v, Got:%+v", tc.expectedTitle, diff.Title)
+			}
+		})
+	}
+}
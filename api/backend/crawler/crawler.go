@@ -0,0 +1,213 @@
+package crawler
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"web-analyser/api/backend/analyser"
+	"web-analyser/config"
+	iHttp "web-analyser/internal/utils/http"
+)
+
+// maxSitemapSize is the max number of bytes read from the sitemap
+const maxSitemapSize = 10 << 20
+
+type Crawler interface {
+	// Crawl crawls the website of the url, it stops once the context is done
+	Crawl(ctx context.Context, url *url.URL) *SiteSummary
+}
+
+type CrawlerImpl struct {
+	analyser     analyser.Analyser
+	httpClient   iHttp.Client
+	conf         *config.CrawlerConf
+	analyserConf *config.AnalyserConf
+}
+
+// NewCrawler returns a new crawler, the pages of the same site as the start page are crawled as per the same site
+// policy and the site aliases of the analyser config, so that the crawled pages are the internal links of the analyses
+func NewCrawler(analyser analyser.Analyser, httpClient iHttp.Client, conf *config.CrawlerConf,
+	analyserConf *config.AnalyserConf) *CrawlerImpl {
+	return &CrawlerImpl{
+		analyser:     analyser,
+		httpClient:   httpClient,
+		conf:         conf,
+		analyserConf: analyserConf,
+	}
+}
+
+// queuedPage is a page waiting to be crawled
+type queuedPage struct {
+	// url is the URL the page is loaded from, as linked or listed in the sitemap
+	url *url.URL
+	// normalisedURL is the normalised URL of the page, which deduplicates the pages
+	normalisedURL string
+	depth         int
+}
+
+// Crawl analyses the pages of the website starting at the url in a bfs manner. It follows the internal links of each
+// page, along with the links of the sitemap of the website, up to the max depth and the max pages, waiting for the
+// delay between two pages. The pages are loaded from their URL as linked, like the analyser checks the links, and
+// deduplicated by their normalised URL. Only the pages of the same site as the start page, as per the same site policy
+// and the site aliases, are crawled, in case the start page redirects the pages of the same site as the host it
+// redirects to. Once the context is done, the crawl stops and the pages crawled so far are summarised.
+func (c *CrawlerImpl) Crawl(ctx context.Context, startURL *url.URL) *SiteSummary {
+	start := c.normalise(startURL)
+	site := NewSiteSummary(start)
+
+	// siteURL is the URL the start page is loaded from after following the redirects, only the pages of its site are
+	// crawled
+	siteURL := start
+	queue := []*queuedPage{{url: startURL, normalisedURL: start.String(), depth: 0}}
+	queued := map[string]struct{}{start.String(): {}}
+	enqueue := func(u *url.URL, depth int) {
+		if depth > c.conf.MaxDepth || len(queue) >= c.conf.MaxPages || !iHttp.IsSameSite(
+			iHttp.SameSitePolicy(c.analyserConf.SameSitePolicy), siteURL.Hostname(), u.Hostname(),
+			c.analyserConf.SiteAliases) {
+			return
+		}
+		normalisedURL := c.normalise(u).String()
+		if _, ok := queued[normalisedURL]; ok {
+			return
+		}
+		queued[normalisedURL] = struct{}{}
+		queue = append(queue, &queuedPage{url: u, normalisedURL: normalisedURL, depth: depth})
+	}
+
+	for i := 0; i < len(queue); i++ {
+		// wait between two pages to not overload the website
+		if i > 0 && !sleep(ctx, c.conf.Delay) {
+			break
+		}
+
+		page := c.crawlPage(ctx, queue[i])
+		site.AddPage(page)
+		if i == 0 && page.Summary != nil {
+			siteURL = c.normalise(page.Summary.FinalURL)
+		}
+
+		for _, link := range page.resolvedLinks {
+			u, _ := url.Parse(link)
+			enqueue(u, queue[i].depth+1)
+		}
+
+		// the pages listed in the sitemap are crawled after the pages linked from the start page, so that the pages
+		// which are not linked from any page, i.e. orphan pages, are crawled as well
+		if i == 0 {
+			for _, u := range c.sitemapURLs(ctx, siteURL) {
+				enqueue(u, 1)
+			}
+		}
+	}
+
+	site.SetOrphanPages()
+	return site
+}
+
+// crawlPage analyses the page and sets its internal links
+func (c *CrawlerImpl) crawlPage(ctx context.Context, p *queuedPage) *Page {
+	page := &Page{
+		URL:   p.normalisedURL,
+		Depth: p.depth,
+	}
+
+	summary, err, statusCode := c.analyser.Analyse(ctx, p.url)
+	page.StatusCode = statusCode
+	if err != nil {
+		page.Error = err.Error()
+		return page
+	}
+	page.Summary = summary
+
+	// the internal links are already resolved and normalised by the analyser, the normalised links find the orphan
+	// pages while the resolved links are crawled, since the website may serve a different page, or none, at the
+	// normalised link, for example without the trailing slash
+	for link := range summary.InternalLinksMap {
+		if u, err := url.Parse(link); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+			page.links = append(page.links, link)
+		}
+	}
+	for _, resolvedLink := range summary.ResolvedLinks {
+		u, err := url.Parse(resolvedLink)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+		if _, ok := summary.InternalLinksMap[c.normalise(u).String()]; ok {
+			page.resolvedLinks = append(page.resolvedLinks, resolvedLink)
+		}
+	}
+	// sort the links so that the pages are crawled in the same order on every run
+	sort.Strings(page.links)
+	sort.Strings(page.resolvedLinks)
+	return page
+}
+
+// normalise returns the normalised URL, normalised like the links of the analyser so that they match
+func (c *CrawlerImpl) normalise(u *url.URL) *url.URL {
+	return iHttp.NormaliseURL(u, c.analyserConf.SortQueryParams)
+}
+
+// sitemap represents the urlset of the sitemap protocol https://www.sitemaps.org/protocol.html
+type sitemap struct {
+	URLs []struct {
+		Loc string `xml:"loc"`
+	} `xml:"url"`
+}
+
+// sitemapURLs returns the URLs listed in the /sitemap.xml of the website, the sitemap is optional so any error
+// results in no URLs
+func (c *CrawlerImpl) sitemapURLs(ctx context.Context, siteURL *url.URL) []*url.URL {
+	sitemapURL := &url.URL{Scheme: siteURL.Scheme, Host: siteURL.Host, Path: "/sitemap.xml"}
+	sm, err := c.fetchSitemap(ctx, sitemapURL.String())
+	if err != nil {
+		return nil
+	}
+
+	var urls []*url.URL
+	for _, u := range sm.URLs {
+		parsedUrl, err := url.Parse(strings.TrimSpace(u.Loc))
+		if err == nil && parsedUrl.IsAbs() {
+			urls = append(urls, parsedUrl)
+		}
+	}
+	return urls
+}
+
+func (c *CrawlerImpl) fetchSitemap(ctx context.Context, sitemapURL string) (*sitemap, error) {
+	resp, err := c.httpClient.Get(ctx, sitemapURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("http status code not 200, value: %v", resp.StatusCode))
+	}
+
+	var sm sitemap
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, maxSitemapSize)).Decode(&sm); err != nil {
+		return nil, err
+	}
+	return &sm, nil
+}
+
+// sleep waits for the duration, it returns false if the context is done before
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		// the timer and the context may be done at the same time
+		return ctx.Err() == nil
+	}
+}
@@ -0,0 +1,228 @@
+package crawler_test
+
+import (
+	"context"
+	"errors"
+	"go.uber.org/mock/gomock"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"web-analyser/api/backend/analyser"
+	"web-analyser/api/backend/crawler"
+	"web-analyser/config"
+	iHttp "web-analyser/internal/utils/http"
+	"web-analyser/mocks"
+)
+
+// newSummary returns a summary of the page with the internal links, as resolved and normalised by the analyser
+func newSummary(page string, hasLoginForm bool, links ...string) *analyser.Summary {
+	u, _ := url.Parse(page)
+	summary := analyser.NewSummary(u)
+	summary.IncrementHeadersCount("h1")
+	summary.SetHasLoginForm(hasLoginForm)
+	for _, link := range links {
+		l, _ := url.Parse(link)
+		summary.AddResolvedLink(link, link)
+		summary.AddInternalLink(iHttp.NormaliseURL(l, false).String())
+	}
+	return summary
+}
+
+// expectAnalyse sets the expectation to analyse the page
+func expectAnalyse(a *mocks.MockAnalyser, page string, summary *analyser.Summary, err error, statusCode int) {
+	u, _ := url.Parse(page)
+	a.EXPECT().Analyse(gomock.Any(), u).Return(summary, err, statusCode)
+}
+
+// sitemapResponse returns the response of the sitemap listing the pages
+func sitemapResponse(pages ...string) *http.Response {
+	body := "<?xml version=\"1.0\" encoding=\"UTF-8\"?><urlset>"
+	for _, page := range pages {
+		body += "<url><loc>" + page + "</loc></url>"
+	}
+	body += "</urlset>"
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+func TestCrawlerImpl_Crawl(t *testing.T) {
+	tests := []*struct {
+		name              string
+		url               string
+		conf              *config.CrawlerConf
+		analyserConf      *config.AnalyserConf
+		setupExpectations func(*mocks.MockAnalyser, *mocks.MockClient)
+		expectedPages     []string
+		expectedSite      *crawler.SiteSummary
+	}{
+		{
+			name: "Should crawl the linked pages and the sitemap pages up to the max depth",
+			url:  "https://Google.com:443",
+			conf: &config.CrawlerConf{MaxDepth: 1, MaxPages: 10},
+			setupExpectations: func(a *mocks.MockAnalyser, c *mocks.MockClient) {
+				expectAnalyse(a, "https://Google.com:443", newSummary("https://google.com/", false,
+					"https://google.com/b", "https://google.com/a", "https://google.com/", "ftp://google.com/a"),
+					nil, 200)
+				expectAnalyse(a, "https://google.com/a", newSummary("https://google.com/a", true,
+					"https://google.com/c"), nil, 200)
+				expectAnalyse(a, "https://google.com/b", nil, errors.New("error"), 404)
+				expectAnalyse(a, "https://google.com/orphan", newSummary("https://google.com/orphan", false), nil, 200)
+				c.EXPECT().Get(gomock.Any(), "https://google.com/sitemap.xml").Return(sitemapResponse(
+					"https://google.com/a", "https://google.com/orphan", "https://facebook.com/external"), nil)
+			},
+			expectedPages: []string{
+				"https://google.com/", "https://google.com/a", "https://google.com/b", "https://google.com/orphan",
+			},
+			expectedSite: &crawler.SiteSummary{
+				URL:                "https://google.com/",
+				TotalPages:         4,
+				PagesWithLoginForm: []string{"https://google.com/a"},
+				HeadersCount:       map[string]int{"h1": 3},
+				OrphanPages:        []string{"https://google.com/orphan"},
+				BrokenPages:        []string{"https://google.com/b"},
+			},
+		},
+		{
+			name: "Should crawl up to the max pages",
+			url:  "https://google.com/",
+			conf: &config.CrawlerConf{MaxDepth: 5, MaxPages: 2},
+			setupExpectations: func(a *mocks.MockAnalyser, c *mocks.MockClient) {
+				expectAnalyse(a, "https://google.com/", newSummary("https://google.com/", false,
+					"https://google.com/a", "https://google.com/b"), nil, 200)
+				expectAnalyse(a, "https://google.com/a", newSummary("https://google.com/a", false,
+					"https://google.com/c"), nil, 200)
+				c.EXPECT().Get(gomock.Any(), "https://google.com/sitemap.xml").Return(nil, errors.New("error"))
+			},
+			expectedPages: []string{"https://google.com/", "https://google.com/a"},
+			expectedSite: &crawler.SiteSummary{
+				URL:                "https://google.com/",
+				TotalPages:         2,
+				PagesWithLoginForm: []string{},
+				HeadersCount:       map[string]int{"h1": 2},
+				OrphanPages:        []string{},
+				BrokenPages:        []string{},
+			},
+		},
+		{
+			name: "Should crawl the pages of the host the start page redirects to",
+			url:  "http://google.com",
+			conf: &config.CrawlerConf{MaxDepth: 1, MaxPages: 10},
+			setupExpectations: func(a *mocks.MockAnalyser, c *mocks.MockClient) {
+				summary := newSummary("http://google.com/", false, "https://www.google.com/a",
+					"http://mail.google.com/b")
+				summary.SetFinalURL(&url.URL{Scheme: "https", Host: "www.google.com", Path: "/"})
+				expectAnalyse(a, "http://google.com", summary, nil, 200)
+				expectAnalyse(a, "https://www.google.com/a", newSummary("https://www.google.com/a", false), nil, 200)
+				c.EXPECT().Get(gomock.Any(), "https://www.google.com/sitemap.xml").Return(nil, errors.New("error"))
+			},
+			expectedPages: []string{"http://google.com/", "https://www.google.com/a"},
+			expectedSite: &crawler.SiteSummary{
+				URL:                "http://google.com/",
+				TotalPages:         2,
+				PagesWithLoginForm: []string{},
+				HeadersCount:       map[string]int{"h1": 2},
+				OrphanPages:        []string{},
+				BrokenPages:        []string{},
+			},
+		},
+		{
+			name:         "Should crawl the pages of the same site as per the same site policy and the site aliases",
+			url:          "https://google.com",
+			conf:         &config.CrawlerConf{MaxDepth: 1, MaxPages: 10},
+			analyserConf: &config.AnalyserConf{SameSitePolicy: "host", SiteAliases: []string{"google.de"}},
+			setupExpectations: func(a *mocks.MockAnalyser, c *mocks.MockClient) {
+				expectAnalyse(a, "https://google.com", newSummary("https://google.com/", false,
+					"https://www.google.com/a", "https://google.de/b", "https://mail.google.com/c"), nil, 200)
+				expectAnalyse(a, "https://google.de/b", newSummary("https://google.de/b", false), nil, 200)
+				expectAnalyse(a, "https://www.google.com/a", newSummary("https://www.google.com/a", false), nil, 200)
+				c.EXPECT().Get(gomock.Any(), "https://google.com/sitemap.xml").Return(nil, errors.New("error"))
+			},
+			expectedPages: []string{"https://google.com/", "https://google.de/b", "https://www.google.com/a"},
+			expectedSite: &crawler.SiteSummary{
+				URL:                "https://google.com/",
+				TotalPages:         3,
+				PagesWithLoginForm: []string{},
+				HeadersCount:       map[string]int{"h1": 3},
+				OrphanPages:        []string{},
+				BrokenPages:        []string{},
+			},
+		},
+		{
+			name: "Should load the pages as linked and deduplicate them by their normalised URL",
+			url:  "https://google.com/docs/",
+			conf: &config.CrawlerConf{MaxDepth: 1, MaxPages: 10},
+			setupExpectations: func(a *mocks.MockAnalyser, c *mocks.MockClient) {
+				expectAnalyse(a, "https://google.com/docs/", newSummary("https://google.com/docs/", false,
+					"https://google.com/docs", "https://google.com/guide/"), nil, 200)
+				expectAnalyse(a, "https://google.com/guide/", newSummary("https://google.com/guide/", false), nil, 200)
+				c.EXPECT().Get(gomock.Any(), "https://google.com/sitemap.xml").Return(sitemapResponse(
+					"https://google.com/guide"), nil)
+			},
+			expectedPages: []string{"https://google.com/docs", "https://google.com/guide"},
+			expectedSite: &crawler.SiteSummary{
+				URL:                "https://google.com/docs",
+				TotalPages:         2,
+				PagesWithLoginForm: []string{},
+				HeadersCount:       map[string]int{"h1": 2},
+				OrphanPages:        []string{},
+				BrokenPages:        []string{},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockAnalyser := mocks.NewMockAnalyser(ctrl)
+			mockClient := mocks.NewMockClient(ctrl)
+			if tc.analyserConf == nil {
+				tc.analyserConf = &config.AnalyserConf{SameSitePolicy: "host"}
+			}
+			c := crawler.NewCrawler(mockAnalyser, mockClient, tc.conf, tc.analyserConf)
+			tc.setupExpectations(mockAnalyser, mockClient)
+
+			u, _ := url.Parse(tc.url)
+			site := c.Crawl(context.Background(), u)
+
+			var pages []string
+			for _, page := range site.Pages {
+				pages = append(pages, page.URL)
+			}
+			if !reflect.DeepEqual(tc.expectedPages, pages) {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedPages, pages)
+			}
+
+			site.Pages = nil
+			if !reflect.DeepEqual(tc.expectedSite, site) {
+				t.Fatalf("Expected:%+v, Got:%+v", tc.expectedSite, site)
+			}
+		})
+	}
+}
+
+func TestCrawlerImpl_Crawl_Cancelled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	mockAnalyser := mocks.NewMockAnalyser(ctrl)
+	mockClient := mocks.NewMockClient(ctrl)
+	// the context is cancelled while the start page is analysed, so the page it links to is not crawled
+	u, _ := url.Parse("https://google.com/")
+	mockAnalyser.EXPECT().Analyse(ctx, u).DoAndReturn(
+		func(context.Context, *url.URL) (*analyser.Summary, error, int) {
+			cancel()
+			return newSummary("https://google.com/", false, "https://google.com/a"), nil, 200
+		})
+	mockClient.EXPECT().Get(ctx, "https://google.com/sitemap.xml").Return(nil, context.Canceled)
+
+	c := crawler.NewCrawler(mockAnalyser, mockClient, &config.CrawlerConf{MaxDepth: 1, MaxPages: 10},
+		&config.AnalyserConf{SameSitePolicy: "host"})
+	site := c.Crawl(ctx, u)
+	if site.TotalPages != 1 {
+		t.Fatalf("Expected:%v, Got:%v", 1, site.TotalPages)
+	}
+}
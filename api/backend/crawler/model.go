@@ -0,0 +1,83 @@
+package crawler
+
+import (
+	"net/url"
+	"sort"
+	"web-analyser/api/backend/analyser"
+)
+
+// SiteSummary represents the summary of all the pages crawled from a website
+type SiteSummary struct {
+	URL                string         `json:"url"`                // URL represents the URL the crawl started at
+	Pages              []*Page        `json:"pages"`              // Pages represents the crawled pages in crawl order
+	TotalPages         int            `json:"totalPages"`         // TotalPages represents the number of crawled pages
+	PagesWithLoginForm []string       `json:"pagesWithLoginForm"` // PagesWithLoginForm represents the pages having a login form
+	HeadersCount       map[string]int `json:"headersCount"`       // HeadersCount represents the count of each header type across the pages
+	OrphanPages        []string       `json:"orphanPages"`        // OrphanPages represents the pages no other crawled page links to
+	BrokenPages        []string       `json:"brokenPages"`        // BrokenPages represents the pages which failed to load
+}
+
+// Page represents a single crawled page, either the summary or the error is set
+type Page struct {
+	URL        string            `json:"url"`                  // URL represents the normalised URL of the page
+	Depth      int               `json:"depth"`                // Depth represents the number of links followed from the start page
+	StatusCode int               `json:"statusCode,omitempty"` // StatusCode represents the http status code of the page
+	Error      string            `json:"error,omitempty"`      // Error represents why the page failed to load
+	Summary    *analyser.Summary `json:"summary,omitempty"`    // Summary represents the summary of the page
+
+	// links represents the normalised internal links of the page, used to find the orphan pages
+	links []string
+	// resolvedLinks represents the internal links of the page as resolved, which are crawled
+	resolvedLinks []string
+}
+
+// NewSiteSummary creates a new instance of SiteSummary
+func NewSiteSummary(url *url.URL) *SiteSummary {
+	return &SiteSummary{
+		URL:                url.String(),
+		Pages:              []*Page{},
+		PagesWithLoginForm: []string{},
+		HeadersCount:       make(map[string]int),
+		OrphanPages:        []string{},
+		BrokenPages:        []string{},
+	}
+}
+
+// AddPage adds the page to the Pages and updates the site level fields
+func (s *SiteSummary) AddPage(page *Page) {
+	s.Pages = append(s.Pages, page)
+	s.TotalPages++
+
+	if page.Summary == nil {
+		s.BrokenPages = append(s.BrokenPages, page.URL)
+		return
+	}
+
+	if page.Summary.HasLoginForm {
+		s.PagesWithLoginForm = append(s.PagesWithLoginForm, page.URL)
+	}
+	for header, count := range page.Summary.HeadersCount {
+		s.HeadersCount[header] += count
+	}
+}
+
+// SetOrphanPages sets the pages, other than the start page, which are not linked from any other crawled page,
+// such pages can only be found through the sitemap
+func (s *SiteSummary) SetOrphanPages() {
+	linked := make(map[string]struct{})
+	for _, page := range s.Pages {
+		for _, link := range page.links {
+			if link != page.URL {
+				linked[link] = struct{}{}
+			}
+		}
+	}
+
+	s.OrphanPages = []string{}
+	for i, page := range s.Pages {
+		if _, ok := linked[page.URL]; !ok && i != 0 {
+			s.OrphanPages = append(s.OrphanPages, page.URL)
+		}
+	}
+	sort.Strings(s.OrphanPages)
+}
@@ -0,0 +1,192 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"web-analyser/config"
+)
+
+// FileStore stores each record as a JSON file named after its id in the directory of the config. The records are
+// indexed in memory without their summaries, so that the records are listed and pruned without reading the files,
+// only Get reads the file of the record.
+type FileStore struct {
+	mu     sync.RWMutex
+	dir    string
+	conf   *config.HistoryConf
+	logger *zerolog.Logger
+	now    func() time.Time
+	index  map[string]*Record
+}
+
+// NewFileStore creates a new instance of FileStore, creating the directory if it doesn't exist and indexing the
+// records already stored in it. The files which can't be read as a record, for example a file truncated by a crash,
+// are logged and skipped, so that they don't prevent the server from starting.
+func NewFileStore(conf *config.HistoryConf, logger *zerolog.Logger) (*FileStore, error) {
+	if err := os.MkdirAll(conf.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	s := &FileStore{
+		dir:    conf.Dir,
+		conf:   conf,
+		logger: logger,
+		now:    time.Now,
+	}
+	index, err := s.readIndex()
+	if err != nil {
+		return nil, err
+	}
+	s.index = index
+	return s, nil
+}
+
+func (s *FileStore) Save(record *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first, so that a partially written record is never read
+	tmp, err := os.CreateTemp(s.dir, record.ID+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path(record.ID)); err != nil {
+		return err
+	}
+	s.index[record.ID] = record.withoutSummary()
+
+	records := make([]*Record, 0, len(s.index))
+	for _, r := range s.index {
+		records = append(records, r)
+	}
+	for _, r := range expiredRecords(records, s.now(), s.conf) {
+		if err := os.Remove(s.path(r.ID)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		delete(s.index, r.ID)
+	}
+	return nil
+}
+
+func (s *FileStore) List(url string) ([]*Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	records := []*Record{}
+	for _, r := range s.index {
+		if r.URL == url {
+			records = append(records, r.withoutSummary())
+		}
+	}
+	sortNewestFirst(records)
+	return records, nil
+}
+
+func (s *FileStore) Get(id string) (*Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !isValidID(id) {
+		return nil, ErrNotFound
+	}
+	return s.read(s.path(id))
+}
+
+func (s *FileStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !isValidID(id) {
+		return ErrNotFound
+	}
+	if err := os.Remove(s.path(id)); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ErrNotFound
+		}
+		return err
+	}
+	delete(s.index, id)
+	return nil
+}
+
+// readIndex reads the records of the directory without their summaries, the summaries are skipped while decoding. The
+// files which aren't valid records, or whose id doesn't match their name, are skipped.
+func (s *FileStore) readIndex() (map[string]*Record, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]*Record)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		var header struct {
+			*Record
+			// the summary is decoded into an empty struct, which discards it
+			Summary struct{} `json:"summary"`
+		}
+		header.Record = &Record{}
+		if err := s.decode(filepath.Join(s.dir, entry.Name()), &header); err != nil {
+			s.logger.Error().Str("file", entry.Name()).Err(err).Msg("unable to read the record, skipping it")
+			continue
+		}
+		if header.ID+".json" != entry.Name() {
+			s.logger.Error().Str("file", entry.Name()).Str("id", header.ID).
+				Msg("the id of the record doesn't match its file, skipping it")
+			continue
+		}
+		index[header.ID] = header.Record
+	}
+	return index, nil
+}
+
+func (s *FileStore) read(path string) (*Record, error) {
+	var record Record
+	if err := s.decode(path, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// decode decodes the JSON file into v, it returns ErrNotFound if the file doesn't exist
+func (s *FileStore) decode(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ErrNotFound
+		}
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (s *FileStore) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+// isValidID checks if the id is a uuid, so that it can't be used to reach the files outside the directory
+func isValidID(id string) bool {
+	_, err := uuid.Parse(id)
+	return err == nil
+}
@@ -0,0 +1,146 @@
+package history
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+	"net/http"
+	"web-analyser/api/backend/analyser"
+	iCtx "web-analyser/internal/utils/ctx"
+	iError "web-analyser/internal/utils/error"
+	iHttp "web-analyser/internal/utils/http"
+	"web-analyser/internal/utils/render"
+)
+
+type Handler interface {
+	ListPage(w http.ResponseWriter, r *http.Request)
+	ShowPage(w http.ResponseWriter, r *http.Request)
+	List(w http.ResponseWriter, r *http.Request)
+	Show(w http.ResponseWriter, r *http.Request)
+	Delete(w http.ResponseWriter, r *http.Request)
+}
+
+type HandlerImpl struct {
+	logger *zerolog.Logger
+	tpl    analyser.Template
+	store  Store
+}
+
+// historyPage is the data of the history page
+type historyPage struct {
+	URL     string
+	Records []*Record
+}
+
+// PreviousID returns the id of the record stored before the record at the index, empty if it is the oldest one
+func (p historyPage) PreviousID(i int) string {
+	if i+1 >= len(p.Records) {
+		return ""
+	}
+	return p.Records[i+1].ID
+}
+
+func NewHandler(logger *zerolog.Logger, tpl analyser.Template, store Store) *HandlerImpl {
+	return &HandlerImpl{
+		logger: logger,
+		tpl:    tpl,
+		store:  store,
+	}
+}
+
+// ListPage renders the page listing the stored analyses of the url, newest first.
+func (h *HandlerImpl) ListPage(w http.ResponseWriter, r *http.Request) {
+	url, records, _, customError := h.list(r)
+	if customError != nil {
+		render.Template(w, r, h.logger, h.tpl, "error.gohtml", *customError)
+		return
+	}
+	render.Template(w, r, h.logger, h.tpl, "history.gohtml", historyPage{URL: url, Records: records})
+}
+
+// ShowPage renders the summary page of the stored analysis with the id.
+func (h *HandlerImpl) ShowPage(w http.ResponseWriter, r *http.Request) {
+	record, _, customError := h.get(r)
+	if customError != nil {
+		render.Template(w, r, h.logger, h.tpl, "error.gohtml", *customError)
+		return
+	}
+	render.Template(w, r, h.logger, h.tpl, "summary.gohtml", record.Summary)
+}
+
+// List returns the stored analyses of the url as JSON, newest first and without their summaries.
+func (h *HandlerImpl) List(w http.ResponseWriter, r *http.Request) {
+	_, records, statusCode, customError := h.list(r)
+	if customError != nil {
+		render.JSON(w, r, h.logger, statusCode, analyser.ErrorResponse{Error: *customError})
+		return
+	}
+	render.JSON(w, r, h.logger, http.StatusOK, records)
+}
+
+// Show returns the stored analysis with the id as JSON.
+func (h *HandlerImpl) Show(w http.ResponseWriter, r *http.Request) {
+	record, statusCode, customError := h.get(r)
+	if customError != nil {
+		render.JSON(w, r, h.logger, statusCode, analyser.ErrorResponse{Error: *customError})
+		return
+	}
+	render.JSON(w, r, h.logger, http.StatusOK, record)
+}
+
+// Delete removes the stored analysis with the id.
+func (h *HandlerImpl) Delete(w http.ResponseWriter, r *http.Request) {
+	err := h.store.Delete(chi.URLParam(r, "id"))
+	if err != nil {
+		statusCode, customError := h.storeError(r, err)
+		render.JSON(w, r, h.logger, statusCode, analyser.ErrorResponse{Error: *customError})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// list validates the url and returns its key along with its stored analyses without their summaries. In case of
+// failure, it logs the error and returns the http status code to respond with along with the error to be conveyed
+// to the user
+func (h *HandlerImpl) list(r *http.Request) (string, []*Record, int, *iError.CustomError) {
+	parsedUrl, err := iHttp.ValidateURL(r.FormValue("url"))
+	if err != nil {
+		h.logger.Error().Str(iCtx.KeyRequestID, iCtx.RequestID(r.Context())).Str("url", r.FormValue("url")).
+			Err(err).Msg("invalid URL")
+		return "", nil, http.StatusBadRequest, &iError.CustomError{Message: err.Error()}
+	}
+
+	url := Key(parsedUrl)
+	records, err := h.store.List(url)
+	if err != nil {
+		statusCode, customError := h.storeError(r, err)
+		return "", nil, statusCode, customError
+	}
+
+	list := make([]*Record, 0, len(records))
+	for _, record := range records {
+		list = append(list, record.withoutSummary())
+	}
+	return url, list, http.StatusOK, nil
+}
+
+// get returns the stored analysis with the id of the route. In case of failure, it logs the error and returns the
+// http status code to respond with along with the error to be conveyed to the user
+func (h *HandlerImpl) get(r *http.Request) (*Record, int, *iError.CustomError) {
+	record, err := h.store.Get(chi.URLParam(r, "id"))
+	if err != nil {
+		statusCode, customError := h.storeError(r, err)
+		return nil, statusCode, customError
+	}
+	return record, http.StatusOK, nil
+}
+
+// storeError logs the error of the store and returns the http status code to respond with along with the error to
+// be conveyed to the user
+func (h *HandlerImpl) storeError(r *http.Request, err error) (int, *iError.CustomError) {
+	if errors.Is(err, ErrNotFound) {
+		return http.StatusNotFound, &iError.CustomError{Message: string(iError.RecordNotFoundError)}
+	}
+	h.logger.Error().Str(iCtx.KeyRequestID, iCtx.RequestID(r.Context())).Err(err).Msg("history store error")
+	return http.StatusInternalServerError, &iError.CustomError{Message: string(iError.HistoryUnavailableError)}
+}
@@ -0,0 +1,213 @@
+package history_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+	"web-analyser/api/backend/analyser"
+	"web-analyser/api/backend/history"
+	"web-analyser/config"
+	iError "web-analyser/internal/utils/error"
+	l "web-analyser/internal/utils/logger"
+	"web-analyser/mocks"
+)
+
+const recordID = "9b2c1e9e-0b7a-4d0e-9d43-4a0f4c1b7a01"
+
+// withID returns the request with the id as the route param
+func withID(r *http.Request, id string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
+
+// newStore returns a memory store with a record of https://google.com/
+func newStore() (history.Store, *history.Record) {
+	store := history.NewMemoryStore(&config.HistoryConf{})
+	record := newRecord(recordID, "https://google.com/", time.Hour)
+	_ = store.Save(record)
+	return store, record
+}
+
+func TestHandlerImpl_ListPage(t *testing.T) {
+	tests := []*struct {
+		name              string
+		url               string
+		setupExpectations func(*httptest.ResponseRecorder, *mocks.MockTemplate, *history.Record)
+	}{
+		{
+			"Should render error template for invalid url", "ftp://google.com",
+			func(w *httptest.ResponseRecorder, mockTemplate *mocks.MockTemplate, record *history.Record) {
+				mockTemplate.EXPECT().ExecuteTemplate(w, "error.gohtml", iError.CustomError{
+					Message: string(iError.UnsupportedSchemeError)})
+			},
+		},
+		{
+			"Should render history template with the records of the url without their summaries", "google.com",
+			func(w *httptest.ResponseRecorder, mockTemplate *mocks.MockTemplate, record *history.Record) {
+				mockTemplate.EXPECT().ExecuteTemplate(w, "history.gohtml", gomock.Any()).Do(
+					func(_ any, _ string, data any) {
+						records := reflect.ValueOf(data).FieldByName("Records").Interface().([]*history.Record)
+						if len(records) != 1 || records[0].ID != record.ID || records[0].Summary != nil {
+							t.Fatalf("Expected:%v, Got:%+v", record.ID, records)
+						}
+					})
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockedTemplate := mocks.NewMockTemplate(ctrl)
+			store, record := newStore()
+			handler := history.NewHandler(l.NewLogger(false), mockedTemplate, store)
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/history?url="+tc.url, nil)
+			tc.setupExpectations(w, mockedTemplate, record)
+			handler.ListPage(w, r)
+		})
+	}
+}
+
+func TestHandlerImpl_ShowPage(t *testing.T) {
+	tests := []*struct {
+		name              string
+		id                string
+		setupExpectations func(*httptest.ResponseRecorder, *mocks.MockTemplate, *history.Record)
+	}{
+		{
+			"Should render error template if the record is not found", "unknown",
+			func(w *httptest.ResponseRecorder, mockTemplate *mocks.MockTemplate, record *history.Record) {
+				mockTemplate.EXPECT().ExecuteTemplate(w, "error.gohtml", iError.CustomError{
+					Message: string(iError.RecordNotFoundError)})
+			},
+		},
+		{
+			"Should render summary template with the stored summary", recordID,
+			func(w *httptest.ResponseRecorder, mockTemplate *mocks.MockTemplate, record *history.Record) {
+				mockTemplate.EXPECT().ExecuteTemplate(w, "summary.gohtml", record.Summary)
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockedTemplate := mocks.NewMockTemplate(ctrl)
+			store, record := newStore()
+			handler := history.NewHandler(l.NewLogger(false), mockedTemplate, store)
+			w := httptest.NewRecorder()
+			r := withID(httptest.NewRequest(http.MethodGet, "/history/"+tc.id, nil), tc.id)
+			tc.setupExpectations(w, mockedTemplate, record)
+			handler.ShowPage(w, r)
+		})
+	}
+}
+
+func TestHandlerImpl_API(t *testing.T) {
+	tests := []*struct {
+		name               string
+		request            *http.Request
+		handle             func(h history.Handler) http.HandlerFunc
+		expectedStatusCode int
+		expectedError      iError.Msg
+		expectedRecords    int
+	}{
+		{
+			name:               "Should return the records of the url",
+			request:            httptest.NewRequest(http.MethodGet, "/api/v1/history?url=https://google.com", nil),
+			handle:             func(h history.Handler) http.HandlerFunc { return h.List },
+			expectedStatusCode: http.StatusOK,
+			expectedRecords:    1,
+		},
+		{
+			name:               "Should return no records of another url",
+			request:            httptest.NewRequest(http.MethodGet, "/api/v1/history?url=https://facebook.com", nil),
+			handle:             func(h history.Handler) http.HandlerFunc { return h.List },
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Should return bad request for an invalid url",
+			request:            httptest.NewRequest(http.MethodGet, "/api/v1/history?url=", nil),
+			handle:             func(h history.Handler) http.HandlerFunc { return h.List },
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      iError.EmptyURLError,
+		},
+		{
+			name:               "Should return the record",
+			request:            withID(httptest.NewRequest(http.MethodGet, "/api/v1/history/"+recordID, nil), recordID),
+			handle:             func(h history.Handler) http.HandlerFunc { return h.Show },
+			expectedStatusCode: http.StatusOK,
+			expectedRecords:    1,
+		},
+		{
+			name:               "Should return not found for an unknown record",
+			request:            withID(httptest.NewRequest(http.MethodGet, "/api/v1/history/unknown", nil), "unknown"),
+			handle:             func(h history.Handler) http.HandlerFunc { return h.Show },
+			expectedStatusCode: http.StatusNotFound,
+			expectedError:      iError.RecordNotFoundError,
+		},
+		{
+			name: "Should delete the record",
+			request: withID(httptest.NewRequest(http.MethodDelete, "/api/v1/history/"+recordID, nil),
+				recordID),
+			handle:             func(h history.Handler) http.HandlerFunc { return h.Delete },
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			name:               "Should return not found when deleting an unknown record",
+			request:            withID(httptest.NewRequest(http.MethodDelete, "/api/v1/history/unknown", nil), "unknown"),
+			handle:             func(h history.Handler) http.HandlerFunc { return h.Delete },
+			expectedStatusCode: http.StatusNotFound,
+			expectedError:      iError.RecordNotFoundError,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store, record := newStore()
+			handler := history.NewHandler(l.NewLogger(false), mocks.NewMockTemplate(ctrl), store)
+			w := httptest.NewRecorder()
+			tc.handle(handler)(w, tc.request)
+
+			if w.Code != tc.expectedStatusCode {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedStatusCode, w.Code)
+			}
+			switch {
+			case tc.expectedError != "":
+				var response analyser.ErrorResponse
+				_ = json.NewDecoder(w.Body).Decode(&response)
+				if response.Error.Message != string(tc.expectedError) {
+					t.Fatalf("Expected:%v, Got:%v", tc.expectedError, response.Error.Message)
+				}
+			case tc.request.Method == http.MethodDelete:
+				if _, err := store.Get(record.ID); err == nil {
+					t.Fatalf("Expected:%v, Got:%v", history.ErrNotFound, err)
+				}
+			case tc.request.URL.Path == "/api/v1/history":
+				var records []*history.Record
+				_ = json.NewDecoder(w.Body).Decode(&records)
+				if len(records) != tc.expectedRecords {
+					t.Fatalf("Expected:%v, Got:%v", tc.expectedRecords, len(records))
+				}
+			default:
+				var response history.Record
+				_ = json.NewDecoder(w.Body).Decode(&response)
+				if !reflect.DeepEqual(record, &response) {
+					t.Fatalf("Expected:%+v, Got:%+v", record, &response)
+				}
+			}
+		})
+	}
+}
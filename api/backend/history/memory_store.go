@@ -0,0 +1,78 @@
+package history
+
+import (
+	"sync"
+	"time"
+	"web-analyser/config"
+)
+
+// MemoryStore stores the records in memory, the records are lost on restart
+type MemoryStore struct {
+	mu      sync.RWMutex
+	records map[string]*Record
+	conf    *config.HistoryConf
+	now     func() time.Time
+}
+
+func NewMemoryStore(conf *config.HistoryConf) *MemoryStore {
+	return &MemoryStore{
+		records: make(map[string]*Record),
+		conf:    conf,
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Save(record *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// a copy is stored, so that the callers can't change the stored record without saving it
+	c := *record
+	s.records[record.ID] = &c
+
+	records := make([]*Record, 0, len(s.records))
+	for _, r := range s.records {
+		records = append(records, r)
+	}
+	for _, r := range expiredRecords(records, s.now(), s.conf) {
+		delete(s.records, r.ID)
+	}
+	return nil
+}
+
+func (s *MemoryStore) List(url string) ([]*Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	records := []*Record{}
+	for _, r := range s.records {
+		if r.URL == url {
+			records = append(records, r.withoutSummary())
+		}
+	}
+	sortNewestFirst(records)
+	return records, nil
+}
+
+func (s *MemoryStore) Get(id string) (*Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	record, ok := s.records[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	c := *record
+	return &c, nil
+}
+
+func (s *MemoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.records[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.records, id)
+	return nil
+}
@@ -0,0 +1,33 @@
+package history
+
+import (
+	"net/url"
+	"time"
+	"web-analyser/api/backend/analyser"
+	iHttp "web-analyser/internal/utils/http"
+)
+
+// Record represents a stored analysis of a URL
+type Record struct {
+	ID         string            `json:"id"`                // ID represents the unique id of the record
+	URL        string            `json:"url"`               // URL represents the normalised URL which was analysed
+	CreatedAt  time.Time         `json:"createdAt"`         // CreatedAt represents when the URL was analysed
+	RequestID  string            `json:"requestId"`         // RequestID represents the id of the request analysing the URL
+	StatusCode int               `json:"statusCode"`        // StatusCode represents the http status code of the page
+	FinalURL   string            `json:"finalUrl"`          // FinalURL represents the URL the page was loaded from
+	Redirects  int               `json:"redirects"`         // Redirects represents the number of redirects followed
+	Duration   time.Duration     `json:"duration"`          // Duration represents the time taken to analyse the URL
+	Summary    *analyser.Summary `json:"summary,omitempty"` // Summary represents the summary of the analysis
+}
+
+// withoutSummary returns a copy of the record without the summary, used to list the records
+func (r *Record) withoutSummary() *Record {
+	c := *r
+	c.Summary = nil
+	return &c
+}
+
+// Key returns the URL the records of the url are stored with, the URLs pointing to the same page share the records
+func Key(url *url.URL) string {
+	return iHttp.NormaliseURL(url, false).String()
+}
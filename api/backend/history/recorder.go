@@ -0,0 +1,36 @@
+package history
+
+import (
+	"github.com/google/uuid"
+	"time"
+	"web-analyser/api/backend/analyser"
+)
+
+// RecorderImpl records the analyses in the store
+type RecorderImpl struct {
+	store Store
+	now   func() time.Time
+}
+
+func NewRecorder(store Store) *RecorderImpl {
+	return &RecorderImpl{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+// Record saves the summary in the store as a new record of the normalised URL of the summary
+func (r *RecorderImpl) Record(requestID string, summary *analyser.Summary, statusCode int,
+	duration time.Duration) error {
+	return r.store.Save(&Record{
+		ID:         uuid.New().String(),
+		URL:        Key(summary.URL),
+		CreatedAt:  r.now().UTC(),
+		RequestID:  requestID,
+		StatusCode: statusCode,
+		FinalURL:   summary.FinalURL.String(),
+		Redirects:  len(summary.RedirectChain),
+		Duration:   duration,
+		Summary:    summary,
+	})
+}
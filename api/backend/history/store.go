@@ -0,0 +1,66 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog"
+	"sort"
+	"time"
+	"web-analyser/config"
+)
+
+const (
+	BackendMemory = "memory"
+	BackendFile   = "file"
+)
+
+// ErrNotFound is returned when there is no record with the id
+var ErrNotFound = errors.New("record not found")
+
+// Store stores the records of the analyses
+type Store interface {
+	// Save stores the record and removes the records over the retention limits
+	Save(record *Record) error
+	// List returns the records of the URL without their summaries, newest first, see Get for the summary
+	List(url string) ([]*Record, error)
+	// Get returns the record with the id, ErrNotFound if there is none
+	Get(id string) (*Record, error)
+	// Delete removes the record with the id, ErrNotFound if there is none
+	Delete(id string) error
+}
+
+// NewStore returns the store of the backend set in the config
+func NewStore(conf *config.HistoryConf, logger *zerolog.Logger) (Store, error) {
+	switch conf.Backend {
+	case BackendMemory:
+		return NewMemoryStore(conf), nil
+	case BackendFile:
+		return NewFileStore(conf, logger)
+	default:
+		return nil, errors.New(fmt.Sprintf("unknown history backend: %v", conf.Backend))
+	}
+}
+
+// expiredRecords returns the records over the retention limits, i.e. the records older than the max age and the
+// oldest records of a URL over the max records per URL. A limit is disabled if it is not positive.
+func expiredRecords(records []*Record, now time.Time, conf *config.HistoryConf) []*Record {
+	sortNewestFirst(records)
+
+	var expired []*Record
+	countPerURL := make(map[string]int)
+	for _, record := range records {
+		countPerURL[record.URL]++
+		if (conf.MaxAge > 0 && now.Sub(record.CreatedAt) > conf.MaxAge) ||
+			(conf.MaxPerURL > 0 && countPerURL[record.URL] > conf.MaxPerURL) {
+			expired = append(expired, record)
+		}
+	}
+	return expired
+}
+
+// sortNewestFirst sorts the records by their creation time, newest first
+func sortNewestFirst(records []*Record) {
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].CreatedAt.After(records[j].CreatedAt)
+	})
+}
@@ -0,0 +1,208 @@
+package history_test
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+	"web-analyser/api/backend/analyser"
+	"web-analyser/api/backend/history"
+	"web-analyser/config"
+	l "web-analyser/internal/utils/logger"
+)
+
+// newRecord returns a record of the url created the given time ago
+func newRecord(id string, page string, ago time.Duration) *history.Record {
+	u, _ := url.Parse(page)
+	summary := analyser.NewSummary(u)
+	summary.SetTitle("Title")
+	summary.AddInternalLink(page + "/a")
+	return &history.Record{
+		ID:         id,
+		URL:        page,
+		CreatedAt:  time.Now().Add(-ago).UTC().Truncate(time.Second),
+		RequestID:  "request-" + id,
+		StatusCode: 200,
+		FinalURL:   page,
+		Summary:    summary,
+	}
+}
+
+// ids returns the ids of the records in order
+func ids(records []*history.Record) []string {
+	ids := []string{}
+	for _, record := range records {
+		ids = append(ids, record.ID)
+	}
+	return ids
+}
+
+func TestStore(t *testing.T) {
+	stores := []*struct {
+		name     string
+		newStore func(conf *config.HistoryConf) history.Store
+	}{
+		{
+			name: "memory",
+			newStore: func(conf *config.HistoryConf) history.Store {
+				return history.NewMemoryStore(conf)
+			},
+		},
+		{
+			name: "file",
+			newStore: func(conf *config.HistoryConf) history.Store {
+				conf.Dir = t.TempDir()
+				store, err := history.NewFileStore(conf, l.NewLogger(false))
+				if err != nil {
+					t.Fatalf("Expected:%v, Got:%v", nil, err)
+				}
+				return store
+			},
+		},
+	}
+	for _, s := range stores {
+		t.Run(s.name, func(t *testing.T) {
+			t.Run("Should save, list, get and delete the records", func(t *testing.T) {
+				store := s.newStore(&config.HistoryConf{})
+				first := newRecord("9b2c1e9e-0b7a-4d0e-9d43-4a0f4c1b7a01", "https://google.com/", 2*time.Hour)
+				second := newRecord("9b2c1e9e-0b7a-4d0e-9d43-4a0f4c1b7a02", "https://google.com/", time.Hour)
+				other := newRecord("9b2c1e9e-0b7a-4d0e-9d43-4a0f4c1b7a03", "https://facebook.com/", time.Hour)
+				for _, record := range []*history.Record{first, second, other} {
+					if err := store.Save(record); err != nil {
+						t.Fatalf("Expected:%v, Got:%v", nil, err)
+					}
+				}
+
+				records, err := store.List("https://google.com/")
+				expectedIDs := []string{second.ID, first.ID}
+				if err != nil || !reflect.DeepEqual(expectedIDs, ids(records)) {
+					t.Fatalf("Expected:%v, Got:%v %v", expectedIDs, ids(records), err)
+				}
+				// the records are listed without their summaries
+				if records[0].Summary != nil || records[0].RequestID != second.RequestID {
+					t.Fatalf("Expected:%v, Got:%+v", "the record without its summary", records[0])
+				}
+
+				record, err := store.Get(first.ID)
+				if err != nil || !reflect.DeepEqual(first, record) {
+					t.Fatalf("Expected:%+v, Got:%+v %v", first, record, err)
+				}
+
+				if err := store.Delete(first.ID); err != nil {
+					t.Fatalf("Expected:%v, Got:%v", nil, err)
+				}
+				if _, err := store.Get(first.ID); !errors.Is(err, history.ErrNotFound) {
+					t.Fatalf("Expected:%v, Got:%v", history.ErrNotFound, err)
+				}
+				if err := store.Delete(first.ID); !errors.Is(err, history.ErrNotFound) {
+					t.Fatalf("Expected:%v, Got:%v", history.ErrNotFound, err)
+				}
+			})
+
+			t.Run("Should remove the records over the retention limits", func(t *testing.T) {
+				store := s.newStore(&config.HistoryConf{MaxAge: 24 * time.Hour, MaxPerURL: 2})
+				expired := newRecord("9b2c1e9e-0b7a-4d0e-9d43-4a0f4c1b7a01", "https://google.com/", 48*time.Hour)
+				oldest := newRecord("9b2c1e9e-0b7a-4d0e-9d43-4a0f4c1b7a02", "https://google.com/", 3*time.Hour)
+				older := newRecord("9b2c1e9e-0b7a-4d0e-9d43-4a0f4c1b7a03", "https://google.com/", 2*time.Hour)
+				newest := newRecord("9b2c1e9e-0b7a-4d0e-9d43-4a0f4c1b7a04", "https://google.com/", time.Hour)
+				other := newRecord("9b2c1e9e-0b7a-4d0e-9d43-4a0f4c1b7a05", "https://facebook.com/", 3*time.Hour)
+				for _, record := range []*history.Record{expired, other, oldest, older, newest} {
+					if err := store.Save(record); err != nil {
+						t.Fatalf("Expected:%v, Got:%v", nil, err)
+					}
+				}
+
+				records, _ := store.List("https://google.com/")
+				expectedIDs := []string{newest.ID, older.ID}
+				if !reflect.DeepEqual(expectedIDs, ids(records)) {
+					t.Fatalf("Expected:%v, Got:%v", expectedIDs, ids(records))
+				}
+				records, _ = store.List("https://facebook.com/")
+				if !reflect.DeepEqual([]string{other.ID}, ids(records)) {
+					t.Fatalf("Expected:%v, Got:%v", []string{other.ID}, ids(records))
+				}
+			})
+		})
+	}
+}
+
+func TestFileStore_Index(t *testing.T) {
+	conf := &config.HistoryConf{Dir: t.TempDir()}
+	store, _ := history.NewFileStore(conf, l.NewLogger(false))
+	record := newRecord("9b2c1e9e-0b7a-4d0e-9d43-4a0f4c1b7a01", "https://google.com/", time.Hour)
+	if err := store.Save(record); err != nil {
+		t.Fatalf("Expected:%v, Got:%v", nil, err)
+	}
+
+	// the records already stored in the directory are indexed when the store is created
+	reopened, err := history.NewFileStore(conf, l.NewLogger(false))
+	if err != nil {
+		t.Fatalf("Expected:%v, Got:%v", nil, err)
+	}
+	records, err := reopened.List("https://google.com/")
+	if err != nil || !reflect.DeepEqual([]*history.Record{{ID: record.ID, URL: record.URL, CreatedAt: record.CreatedAt,
+		RequestID: record.RequestID, StatusCode: record.StatusCode, FinalURL: record.FinalURL}}, records) {
+		t.Fatalf("Expected:%v, Got:%+v %v", []string{record.ID}, records, err)
+	}
+	got, err := reopened.Get(record.ID)
+	if err != nil || !reflect.DeepEqual(record, got) {
+		t.Fatalf("Expected:%+v, Got:%+v %v", record, got, err)
+	}
+}
+
+func TestFileStore_Index_InvalidFiles(t *testing.T) {
+	conf := &config.HistoryConf{Dir: t.TempDir()}
+	store, _ := history.NewFileStore(conf, l.NewLogger(false))
+	record := newRecord("9b2c1e9e-0b7a-4d0e-9d43-4a0f4c1b7a01", "https://google.com/", time.Hour)
+	if err := store.Save(record); err != nil {
+		t.Fatalf("Expected:%v, Got:%v", nil, err)
+	}
+
+	// a record truncated by a crash and a record whose id was edited are stored next to the valid record
+	data, _ := os.ReadFile(filepath.Join(conf.Dir, record.ID+".json"))
+	truncated := filepath.Join(conf.Dir, "4f0d7a2e-8c1b-4e5a-9f3d-2b6c8e1a7d02.json")
+	if err := os.WriteFile(truncated, data[:len(data)/2], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	edited := filepath.Join(conf.Dir, "c3e1b7d9-5a2f-4b8e-8d6c-1f9a3e7b5c03.json")
+	if err := os.WriteFile(edited, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// the invalid files are skipped rather than preventing the store from being created
+	reopened, err := history.NewFileStore(conf, l.NewLogger(false))
+	if err != nil {
+		t.Fatalf("Expected:%v, Got:%v", nil, err)
+	}
+	records, _ := reopened.List("https://google.com/")
+	if !reflect.DeepEqual([]string{record.ID}, ids(records)) {
+		t.Fatalf("Expected:%v, Got:%v", []string{record.ID}, ids(records))
+	}
+}
+
+func TestMemoryStore_Copy(t *testing.T) {
+	store := history.NewMemoryStore(&config.HistoryConf{})
+	record := newRecord("9b2c1e9e-0b7a-4d0e-9d43-4a0f4c1b7a01", "https://google.com/", time.Hour)
+	if err := store.Save(record); err != nil {
+		t.Fatalf("Expected:%v, Got:%v", nil, err)
+	}
+
+	// changing the saved or the returned record doesn't change the stored record
+	record.StatusCode = 500
+	got, _ := store.Get(record.ID)
+	got.URL = "https://facebook.com/"
+	if got, _ := store.Get(record.ID); got.StatusCode == 500 || got.URL != "https://google.com/" {
+		t.Fatalf("Expected:%v, Got:%+v", "the stored record", got)
+	}
+}
+
+func TestFileStore_Get(t *testing.T) {
+	store, _ := history.NewFileStore(&config.HistoryConf{Dir: t.TempDir()}, l.NewLogger(false))
+	// the ids which are not uuids are never read, so that the files outside the directory can't be read
+	if _, err := store.Get("../../etc/passwd"); !errors.Is(err, history.ErrNotFound) {
+		t.Fatalf("Expected:%v, Got:%v", history.ErrNotFound, err)
+	}
+}
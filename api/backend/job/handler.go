@@ -0,0 +1,267 @@
+package job
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+	"mime"
+	"net/http"
+	"time"
+	"web-analyser/api/backend/analyser"
+	iCtx "web-analyser/internal/utils/ctx"
+	iError "web-analyser/internal/utils/error"
+	iHttp "web-analyser/internal/utils/http"
+	"web-analyser/internal/utils/render"
+)
+
+// ContentTypeEventStream is the media type of the Server-Sent Events
+const ContentTypeEventStream = "text/event-stream"
+
+type Handler interface {
+	Submit(w http.ResponseWriter, r *http.Request)
+	Create(w http.ResponseWriter, r *http.Request)
+	SubmitCrawl(w http.ResponseWriter, r *http.Request)
+	CreateCrawl(w http.ResponseWriter, r *http.Request)
+	Page(w http.ResponseWriter, r *http.Request)
+	Show(w http.ResponseWriter, r *http.Request)
+	Events(w http.ResponseWriter, r *http.Request)
+}
+
+type HandlerImpl struct {
+	logger *zerolog.Logger
+	tpl    analyser.Template
+	queue  Queue
+}
+
+// jobRequest is the JSON body accepted by the jobs api, the request options are set along with the url
+type jobRequest struct {
+	URL string `json:"url"`
+	iHttp.RequestOptions
+}
+
+// crawlRequest is the JSON body accepted by the crawls api
+type crawlRequest struct {
+	URL string `json:"url"`
+}
+
+func NewHandler(logger *zerolog.Logger, tpl analyser.Template, queue Queue) *HandlerImpl {
+	return &HandlerImpl{
+		logger: logger,
+		tpl:    tpl,
+		queue:  queue,
+	}
+}
+
+// Submit queues the analysis of the url and redirects to the progress page of the job, it responds with the job as
+// JSON instead if the client accepts JSON.
+func (h *HandlerImpl) Submit(w http.ResponseWriter, r *http.Request) {
+	job, statusCode, customError := h.submit(r, KindAnalysis, r.FormValue("url"), iHttp.FormRequestOptions(r))
+	h.redirectToJob(w, r, job, statusCode, customError)
+}
+
+// Create queues the analysis of the url and responds with the job as JSON, the url and the request options are read
+// from the JSON body in case of a JSON request, otherwise from the query or form values.
+func (h *HandlerImpl) Create(w http.ResponseWriter, r *http.Request) {
+	url, options := r.FormValue("url"), iHttp.FormRequestOptions(r)
+
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == iHttp.ContentTypeJSON {
+		var body jobRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			h.logger.Error().Str(iCtx.KeyRequestID, iCtx.RequestID(r.Context())).Err(err).Msg("invalid request body")
+			render.JSON(w, r, h.logger, http.StatusBadRequest,
+				analyser.ErrorResponse{Error: iError.CustomError{Message: string(iError.InvalidRequestError)}})
+			return
+		}
+		url, options = body.URL, &body.RequestOptions
+	}
+
+	job, statusCode, customError := h.submit(r, KindAnalysis, url, options)
+	h.renderCreatedJSON(w, r, job, statusCode, customError)
+}
+
+// SubmitCrawl queues the crawl of the website of the url and redirects to the progress page of the job, it responds
+// with the job as JSON instead if the client accepts JSON.
+func (h *HandlerImpl) SubmitCrawl(w http.ResponseWriter, r *http.Request) {
+	job, statusCode, customError := h.submit(r, KindCrawl, r.FormValue("url"), nil)
+	h.redirectToJob(w, r, job, statusCode, customError)
+}
+
+// CreateCrawl queues the crawl of the website of the url and responds with the job as JSON, the url is read from the
+// JSON body in case of a JSON request, otherwise from the query or form values.
+func (h *HandlerImpl) CreateCrawl(w http.ResponseWriter, r *http.Request) {
+	url := r.FormValue("url")
+
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == iHttp.ContentTypeJSON {
+		var body crawlRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			h.logger.Error().Str(iCtx.KeyRequestID, iCtx.RequestID(r.Context())).Err(err).Msg("invalid request body")
+			render.JSON(w, r, h.logger, http.StatusBadRequest,
+				analyser.ErrorResponse{Error: iError.CustomError{Message: string(iError.InvalidRequestError)}})
+			return
+		}
+		url = body.URL
+	}
+
+	job, statusCode, customError := h.submit(r, KindCrawl, url, nil)
+	h.renderCreatedJSON(w, r, job, statusCode, customError)
+}
+
+// Page renders the progress page of the job until it is finished, then the summary page, the crawl page or the error
+// page of the job. It responds with the job as JSON instead if the client accepts JSON, so that the clients can poll it.
+func (h *HandlerImpl) Page(w http.ResponseWriter, r *http.Request) {
+	job, statusCode, customError := h.get(r)
+
+	if iHttp.AcceptsJSON(r) {
+		h.renderJobJSON(w, r, job, statusCode, customError)
+		return
+	}
+
+	switch {
+	case customError != nil:
+		render.Template(w, r, h.logger, h.tpl, "error.gohtml", *customError)
+	case job.Status == StatusDone && job.Kind == KindCrawl:
+		render.Template(w, r, h.logger, h.tpl, "crawl.gohtml", job.Site)
+	case job.Status == StatusDone:
+		render.Template(w, r, h.logger, h.tpl, "summary.gohtml", job.Summary)
+	case job.Status == StatusFailed:
+		render.Template(w, r, h.logger, h.tpl, "error.gohtml", *job.Error)
+	default:
+		render.Template(w, r, h.logger, h.tpl, "job.gohtml", job)
+	}
+}
+
+// Show returns the job as JSON, along with the summary or the site summary once it is done.
+func (h *HandlerImpl) Show(w http.ResponseWriter, r *http.Request) {
+	job, statusCode, customError := h.get(r)
+	h.renderJobJSON(w, r, job, statusCode, customError)
+}
+
+// Events streams the job as Server-Sent Events every time it changes, a progress event without the summary until the
+// job is finished and then a done event with the summary or the site summary, which ends the stream.
+func (h *HandlerImpl) Events(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	updates, unsubscribe, err := h.queue.Subscribe(id)
+	if err != nil {
+		h.renderJobJSON(w, r, nil, http.StatusNotFound, &iError.CustomError{Message: string(iError.JobNotFoundError)})
+		return
+	}
+	defer unsubscribe()
+
+	// the stream lasts as long as the analysis, so it is not bound by the write timeout of the server
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", ContentTypeEventStream)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-updates:
+		}
+
+		job, err := h.queue.Get(id)
+		if err != nil {
+			return
+		}
+
+		event, data := "progress", job.withoutSummary()
+		if job.IsFinished() {
+			event, data = "done", job
+		}
+		body, err := json.Marshal(data)
+		if err != nil {
+			h.logger.Error().Str(iCtx.KeyRequestID, iCtx.RequestID(r.Context())).Err(err).Msg("json error")
+			return
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, body); err != nil {
+			return
+		}
+		_ = rc.Flush()
+
+		if job.IsFinished() {
+			return
+		}
+	}
+}
+
+// submit validates the url and the request options and queues the job of the kind for the url, the crawl jobs have
+// no request options. In case of failure, it logs the error and returns the http status code to respond with along
+// with the error to be conveyed to the user
+func (h *HandlerImpl) submit(r *http.Request, kind Kind, url string, options *iHttp.RequestOptions) (*Job, int,
+	*iError.CustomError) {
+	reqID := iCtx.RequestID(r.Context())
+
+	parsedUrl, err := iHttp.ValidateURL(url)
+	if err != nil {
+		h.logger.Error().Str(iCtx.KeyRequestID, reqID).Str("url", url).Err(err).Msg("invalid URL")
+		return nil, http.StatusBadRequest, &iError.CustomError{Message: err.Error()}
+	}
+	// the options are not logged since they may hold credentials
+	if options != nil {
+		if err := options.Validate(); err != nil {
+			h.logger.Error().Str(iCtx.KeyRequestID, reqID).Err(err).Msg("invalid request options")
+			return nil, http.StatusBadRequest, &iError.CustomError{Message: err.Error()}
+		}
+	}
+
+	var job *Job
+	if kind == KindCrawl {
+		job, err = h.queue.SubmitCrawl(parsedUrl, reqID)
+	} else {
+		job, err = h.queue.Submit(parsedUrl, reqID, options)
+	}
+	if err != nil {
+		h.logger.Error().Str(iCtx.KeyRequestID, reqID).Err(err).Msg(string(iError.QueueFullError))
+		return nil, http.StatusServiceUnavailable, &iError.CustomError{Message: string(iError.QueueFullError)}
+	}
+	return job, http.StatusAccepted, nil
+}
+
+// get returns the job with the id of the route. In case there is no job with the id, it returns the http status code
+// to respond with along with the error to be conveyed to the user
+func (h *HandlerImpl) get(r *http.Request) (*Job, int, *iError.CustomError) {
+	job, err := h.queue.Get(chi.URLParam(r, "id"))
+	if err != nil {
+		return nil, http.StatusNotFound, &iError.CustomError{Message: string(iError.JobNotFoundError)}
+	}
+	return job, http.StatusOK, nil
+}
+
+// redirectToJob redirects to the progress page of the submitted job, or renders the error page. It responds with the
+// job or the error as JSON instead if the client accepts JSON.
+func (h *HandlerImpl) redirectToJob(w http.ResponseWriter, r *http.Request, job *Job, statusCode int,
+	customError *iError.CustomError) {
+	if iHttp.AcceptsJSON(r) {
+		h.renderJobJSON(w, r, job, statusCode, customError)
+		return
+	}
+
+	if customError != nil {
+		render.Template(w, r, h.logger, h.tpl, "error.gohtml", *customError)
+		return
+	}
+	http.Redirect(w, r, "/jobs/"+job.ID, http.StatusSeeOther)
+}
+
+// renderCreatedJSON writes either the created job, its url in the Location header, or the error response as JSON
+func (h *HandlerImpl) renderCreatedJSON(w http.ResponseWriter, r *http.Request, job *Job, statusCode int,
+	customError *iError.CustomError) {
+	if customError == nil {
+		w.Header().Set("Location", "/api/v1/jobs/"+job.ID)
+	}
+	h.renderJobJSON(w, r, job, statusCode, customError)
+}
+
+// renderJobJSON writes either the job or the error response as JSON
+func (h *HandlerImpl) renderJobJSON(w http.ResponseWriter, r *http.Request, job *Job, statusCode int,
+	customError *iError.CustomError) {
+	if customError != nil {
+		render.JSON(w, r, h.logger, statusCode, analyser.ErrorResponse{Error: *customError})
+		return
+	}
+	render.JSON(w, r, h.logger, statusCode, job)
+}
@@ -0,0 +1,489 @@
+package job_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	netUrl "net/url"
+	"strings"
+	"testing"
+	"web-analyser/api/backend/analyser"
+	"web-analyser/api/backend/crawler"
+	"web-analyser/api/backend/job"
+	iError "web-analyser/internal/utils/error"
+	iHttp "web-analyser/internal/utils/http"
+	l "web-analyser/internal/utils/logger"
+	"web-analyser/mocks"
+)
+
+const jobID = "5f1d8a52-8a0e-4a8e-9d0f-3c6b2f1e7a10"
+
+// withID returns the request with the id as the route param
+func withID(r *http.Request, id string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
+
+// formRequest returns a POST request of the form
+func formRequest(target string, form string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(form))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+// jsonRequest returns a POST request of the JSON body
+func jsonRequest(target string, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	r.Header.Set("Content-Type", iHttp.ContentTypeJSON)
+	return r
+}
+
+// newJob returns a job of https://google.com/ with the status
+func newJob(status job.Status) *job.Job {
+	j := &job.Job{ID: jobID, URL: "https://google.com/", Status: status}
+	switch status {
+	case job.StatusDone:
+		j.Summary = newSummary("Google")
+	case job.StatusFailed:
+		j.Error = &iError.CustomError{Message: string(iError.UnreachableURLError)}
+	}
+	return j
+}
+
+func TestHandlerImpl_Submit(t *testing.T) {
+	tests := []*struct {
+		name               string
+		request            *http.Request
+		setupExpectations  func(*httptest.ResponseRecorder, *mocks.MockTemplate, *mocks.MockQueue)
+		expectedStatusCode int
+		expectedLocation   string
+	}{
+		{
+			name:    "Should queue the analysis and redirect to the job page",
+			request: httptest.NewRequest(http.MethodPost, "/jobs?url=google.com", nil),
+			setupExpectations: func(w *httptest.ResponseRecorder, mockTemplate *mocks.MockTemplate,
+				mockQueue *mocks.MockQueue) {
+				mockQueue.EXPECT().Submit(gomock.Any(), gomock.Any(), gomock.Any()).Return(newJob(job.StatusQueued), nil)
+			},
+			expectedStatusCode: http.StatusSeeOther,
+			expectedLocation:   "/jobs/" + jobID,
+		},
+		{
+			name: "Should queue the analysis with the request options of the form",
+			request: formRequest("/jobs", "url=google.com&userAgent=Mozilla%2F5.0&headers=X-Env%3A+staging"+
+				"&username=user&password=pass"),
+			setupExpectations: func(w *httptest.ResponseRecorder, mockTemplate *mocks.MockTemplate,
+				mockQueue *mocks.MockQueue) {
+				mockQueue.EXPECT().Submit(gomock.Any(), gomock.Any(), &iHttp.RequestOptions{UserAgent: "Mozilla/5.0",
+					Headers: map[string]string{"X-Env": "staging"}, Username: "user", Password: "pass"}).
+					Return(newJob(job.StatusQueued), nil)
+			},
+			expectedStatusCode: http.StatusSeeOther,
+			expectedLocation:   "/jobs/" + jobID,
+		},
+		{
+			name:    "Should render error template for invalid request options",
+			request: formRequest("/jobs", "url=google.com&headers=X-Env"),
+			setupExpectations: func(w *httptest.ResponseRecorder, mockTemplate *mocks.MockTemplate,
+				mockQueue *mocks.MockQueue) {
+				mockTemplate.EXPECT().ExecuteTemplate(w, "error.gohtml", iError.CustomError{
+					Message: string(iError.InvalidHeaderError)})
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:    "Should render error template for invalid url",
+			request: httptest.NewRequest(http.MethodPost, "/jobs?url=ftp://google.com", nil),
+			setupExpectations: func(w *httptest.ResponseRecorder, mockTemplate *mocks.MockTemplate,
+				mockQueue *mocks.MockQueue) {
+				mockTemplate.EXPECT().ExecuteTemplate(w, "error.gohtml", iError.CustomError{
+					Message: string(iError.UnsupportedSchemeError)})
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:    "Should render error template when the queue is full",
+			request: httptest.NewRequest(http.MethodPost, "/jobs?url=google.com", nil),
+			setupExpectations: func(w *httptest.ResponseRecorder, mockTemplate *mocks.MockTemplate,
+				mockQueue *mocks.MockQueue) {
+				mockQueue.EXPECT().Submit(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, job.ErrQueueFull)
+				mockTemplate.EXPECT().ExecuteTemplate(w, "error.gohtml", iError.CustomError{
+					Message: string(iError.QueueFullError)})
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockTemplate := mocks.NewMockTemplate(ctrl)
+			mockQueue := mocks.NewMockQueue(ctrl)
+			w := httptest.NewRecorder()
+			tc.setupExpectations(w, mockTemplate, mockQueue)
+
+			handler := job.NewHandler(l.NewLogger(false), mockTemplate, mockQueue)
+			handler.Submit(w, tc.request)
+
+			if w.Code != tc.expectedStatusCode {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedStatusCode, w.Code)
+			}
+			if location := w.Header().Get("Location"); location != tc.expectedLocation {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedLocation, location)
+			}
+		})
+	}
+}
+
+func TestHandlerImpl_Create(t *testing.T) {
+	tests := []*struct {
+		name               string
+		body               string
+		setupExpectations  func(*mocks.MockQueue)
+		expectedStatusCode int
+		expectedLocation   string
+		expectedError      iError.Msg
+	}{
+		{
+			name: "Should queue the analysis of the url of the JSON body",
+			body: `{"url": "google.com"}`,
+			setupExpectations: func(mockQueue *mocks.MockQueue) {
+				mockQueue.EXPECT().Submit(gomock.Any(), gomock.Any(), gomock.Any()).Return(newJob(job.StatusQueued), nil)
+			},
+			expectedStatusCode: http.StatusAccepted,
+			expectedLocation:   "/api/v1/jobs/" + jobID,
+		},
+		{
+			name:               "Should return bad request for an invalid JSON body",
+			body:               `{`,
+			setupExpectations:  func(mockQueue *mocks.MockQueue) {},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      iError.InvalidRequestError,
+		},
+		{
+			name: "Should return service unavailable when the queue is full",
+			body: `{"url": "google.com"}`,
+			setupExpectations: func(mockQueue *mocks.MockQueue) {
+				mockQueue.EXPECT().Submit(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, job.ErrQueueFull)
+			},
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedError:      iError.QueueFullError,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueue := mocks.NewMockQueue(ctrl)
+			tc.setupExpectations(mockQueue)
+			handler := job.NewHandler(l.NewLogger(false), mocks.NewMockTemplate(ctrl), mockQueue)
+
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader(tc.body))
+			r.Header.Set("Content-Type", iHttp.ContentTypeJSON)
+			w := httptest.NewRecorder()
+			handler.Create(w, r)
+
+			if w.Code != tc.expectedStatusCode {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedStatusCode, w.Code)
+			}
+			if location := w.Header().Get("Location"); location != tc.expectedLocation {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedLocation, location)
+			}
+			if tc.expectedError != "" {
+				var response analyser.ErrorResponse
+				_ = json.NewDecoder(w.Body).Decode(&response)
+				if response.Error.Message != string(tc.expectedError) {
+					t.Fatalf("Expected:%v, Got:%v", tc.expectedError, response.Error.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestHandlerImpl_SubmitCrawl(t *testing.T) {
+	tests := []*struct {
+		name               string
+		url                string
+		setupExpectations  func(*httptest.ResponseRecorder, *mocks.MockTemplate, *mocks.MockQueue)
+		expectedStatusCode int
+		expectedLocation   string
+	}{
+		{
+			name: "Should queue the crawl and redirect to the job page",
+			url:  "https://google.com",
+			setupExpectations: func(w *httptest.ResponseRecorder, mockTemplate *mocks.MockTemplate,
+				mockQueue *mocks.MockQueue) {
+				u, _ := netUrl.Parse("https://google.com")
+				mockQueue.EXPECT().SubmitCrawl(u, gomock.Any()).Return(newJob(job.StatusQueued), nil)
+			},
+			expectedStatusCode: http.StatusSeeOther,
+			expectedLocation:   "/jobs/" + jobID,
+		},
+		{
+			name: "Should render error template for invalid url",
+			url:  "invalid url",
+			setupExpectations: func(w *httptest.ResponseRecorder, mockTemplate *mocks.MockTemplate,
+				mockQueue *mocks.MockQueue) {
+				mockTemplate.EXPECT().ExecuteTemplate(w, "error.gohtml", iError.CustomError{
+					Message: string(iError.InvalidURLError)})
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockTemplate := mocks.NewMockTemplate(ctrl)
+			mockQueue := mocks.NewMockQueue(ctrl)
+			w := httptest.NewRecorder()
+			tc.setupExpectations(w, mockTemplate, mockQueue)
+
+			handler := job.NewHandler(l.NewLogger(false), mockTemplate, mockQueue)
+			handler.SubmitCrawl(w, formRequest("/crawl", "url="+tc.url))
+
+			if w.Code != tc.expectedStatusCode {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedStatusCode, w.Code)
+			}
+			if location := w.Header().Get("Location"); location != tc.expectedLocation {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedLocation, location)
+			}
+		})
+	}
+}
+
+func TestHandlerImpl_CreateCrawl(t *testing.T) {
+	tests := []*struct {
+		name               string
+		request            *http.Request
+		setupExpectations  func(*mocks.MockQueue)
+		expectedStatusCode int
+		expectedLocation   string
+		expectedError      iError.Msg
+	}{
+		{
+			name:    "Should queue the crawl of the url of the JSON body",
+			request: jsonRequest("/api/v1/crawls", `{"url": "https://google.com"}`),
+			setupExpectations: func(mockQueue *mocks.MockQueue) {
+				u, _ := netUrl.Parse("https://google.com")
+				mockQueue.EXPECT().SubmitCrawl(u, gomock.Any()).Return(newJob(job.StatusQueued), nil)
+			},
+			expectedStatusCode: http.StatusAccepted,
+			expectedLocation:   "/api/v1/jobs/" + jobID,
+		},
+		{
+			name:    "Should queue the crawl of the url of the query",
+			request: httptest.NewRequest(http.MethodGet, "/api/v1/crawls?url=https://google.com", nil),
+			setupExpectations: func(mockQueue *mocks.MockQueue) {
+				mockQueue.EXPECT().SubmitCrawl(gomock.Any(), gomock.Any()).Return(newJob(job.StatusQueued), nil)
+			},
+			expectedStatusCode: http.StatusAccepted,
+			expectedLocation:   "/api/v1/jobs/" + jobID,
+		},
+		{
+			name:               "Should return bad request for an invalid url",
+			request:            jsonRequest("/api/v1/crawls", `{"url": "invalid url"}`),
+			setupExpectations:  func(mockQueue *mocks.MockQueue) {},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      iError.InvalidURLError,
+		},
+		{
+			name:    "Should return service unavailable when the queue is full",
+			request: jsonRequest("/api/v1/crawls", `{"url": "https://google.com"}`),
+			setupExpectations: func(mockQueue *mocks.MockQueue) {
+				mockQueue.EXPECT().SubmitCrawl(gomock.Any(), gomock.Any()).Return(nil, job.ErrQueueFull)
+			},
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedError:      iError.QueueFullError,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueue := mocks.NewMockQueue(ctrl)
+			tc.setupExpectations(mockQueue)
+			handler := job.NewHandler(l.NewLogger(false), mocks.NewMockTemplate(ctrl), mockQueue)
+
+			w := httptest.NewRecorder()
+			handler.CreateCrawl(w, tc.request)
+
+			if w.Code != tc.expectedStatusCode {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedStatusCode, w.Code)
+			}
+			if location := w.Header().Get("Location"); location != tc.expectedLocation {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedLocation, location)
+			}
+			if tc.expectedError != "" {
+				var response analyser.ErrorResponse
+				_ = json.NewDecoder(w.Body).Decode(&response)
+				if response.Error.Message != string(tc.expectedError) {
+					t.Fatalf("Expected:%v, Got:%v", tc.expectedError, response.Error.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestHandlerImpl_Page(t *testing.T) {
+	tests := []*struct {
+		name              string
+		setupExpectations func(*httptest.ResponseRecorder, *mocks.MockTemplate, *mocks.MockQueue)
+	}{
+		{
+			name: "Should render job template while the job is running",
+			setupExpectations: func(w *httptest.ResponseRecorder, mockTemplate *mocks.MockTemplate,
+				mockQueue *mocks.MockQueue) {
+				j := newJob(job.StatusRunning)
+				mockQueue.EXPECT().Get(jobID).Return(j, nil)
+				mockTemplate.EXPECT().ExecuteTemplate(w, "job.gohtml", j)
+			},
+		},
+		{
+			name: "Should render summary template once the job is done",
+			setupExpectations: func(w *httptest.ResponseRecorder, mockTemplate *mocks.MockTemplate,
+				mockQueue *mocks.MockQueue) {
+				j := newJob(job.StatusDone)
+				mockQueue.EXPECT().Get(jobID).Return(j, nil)
+				mockTemplate.EXPECT().ExecuteTemplate(w, "summary.gohtml", j.Summary)
+			},
+		},
+		{
+			name: "Should render crawl template once the crawl job is done",
+			setupExpectations: func(w *httptest.ResponseRecorder, mockTemplate *mocks.MockTemplate,
+				mockQueue *mocks.MockQueue) {
+				u, _ := netUrl.Parse("https://google.com")
+				j := &job.Job{ID: jobID, Kind: job.KindCrawl, URL: "https://google.com", Status: job.StatusDone,
+					Site: crawler.NewSiteSummary(u)}
+				mockQueue.EXPECT().Get(jobID).Return(j, nil)
+				mockTemplate.EXPECT().ExecuteTemplate(w, "crawl.gohtml", j.Site)
+			},
+		},
+		{
+			name: "Should render error template once the job failed",
+			setupExpectations: func(w *httptest.ResponseRecorder, mockTemplate *mocks.MockTemplate,
+				mockQueue *mocks.MockQueue) {
+				mockQueue.EXPECT().Get(jobID).Return(newJob(job.StatusFailed), nil)
+				mockTemplate.EXPECT().ExecuteTemplate(w, "error.gohtml", iError.CustomError{
+					Message: string(iError.UnreachableURLError)})
+			},
+		},
+		{
+			name: "Should render error template for an unknown job",
+			setupExpectations: func(w *httptest.ResponseRecorder, mockTemplate *mocks.MockTemplate,
+				mockQueue *mocks.MockQueue) {
+				mockQueue.EXPECT().Get(jobID).Return(nil, job.ErrNotFound)
+				mockTemplate.EXPECT().ExecuteTemplate(w, "error.gohtml", iError.CustomError{
+					Message: string(iError.JobNotFoundError)})
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockTemplate := mocks.NewMockTemplate(ctrl)
+			mockQueue := mocks.NewMockQueue(ctrl)
+			w := httptest.NewRecorder()
+			tc.setupExpectations(w, mockTemplate, mockQueue)
+
+			handler := job.NewHandler(l.NewLogger(false), mockTemplate, mockQueue)
+			handler.Page(w, withID(httptest.NewRequest(http.MethodGet, "/jobs/"+jobID, nil), jobID))
+		})
+	}
+}
+
+func TestHandlerImpl_Show(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockQueue := mocks.NewMockQueue(ctrl)
+	mockQueue.EXPECT().Get(jobID).Return(newJob(job.StatusDone), nil)
+	handler := job.NewHandler(l.NewLogger(false), mocks.NewMockTemplate(ctrl), mockQueue)
+
+	w := httptest.NewRecorder()
+	handler.Show(w, withID(httptest.NewRequest(http.MethodGet, "/api/v1/jobs/"+jobID, nil), jobID))
+
+	var response job.Job
+	_ = json.NewDecoder(w.Body).Decode(&response)
+	if w.Code != http.StatusOK || response.Status != job.StatusDone || response.Summary == nil {
+		t.Fatalf("Expected:%v, Got:%v %+v", job.StatusDone, w.Code, response)
+	}
+}
+
+func TestHandlerImpl_Events(t *testing.T) {
+	tests := []*struct {
+		name               string
+		setupExpectations  func(*mocks.MockQueue)
+		expectedStatusCode int
+		expectedEvents     []string
+	}{
+		{
+			name: "Should stream the progress of the job until it is done",
+			setupExpectations: func(mockQueue *mocks.MockQueue) {
+				updates := make(chan struct{}, 2)
+				updates <- struct{}{}
+				updates <- struct{}{}
+				mockQueue.EXPECT().Subscribe(jobID).Return(updates, func() {}, nil)
+				gomock.InOrder(
+					mockQueue.EXPECT().Get(jobID).Return(newJob(job.StatusRunning), nil),
+					mockQueue.EXPECT().Get(jobID).Return(newJob(job.StatusDone), nil),
+				)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedEvents:     []string{"progress", "done"},
+		},
+		{
+			name: "Should return not found for an unknown job",
+			setupExpectations: func(mockQueue *mocks.MockQueue) {
+				mockQueue.EXPECT().Subscribe(jobID).Return(nil, nil, job.ErrNotFound)
+			},
+			expectedStatusCode: http.StatusNotFound,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueue := mocks.NewMockQueue(ctrl)
+			tc.setupExpectations(mockQueue)
+			handler := job.NewHandler(l.NewLogger(false), mocks.NewMockTemplate(ctrl), mockQueue)
+
+			w := httptest.NewRecorder()
+			handler.Events(w, withID(httptest.NewRequest(http.MethodGet, "/jobs/"+jobID+"/events", nil), jobID))
+
+			if w.Code != tc.expectedStatusCode {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedStatusCode, w.Code)
+			}
+			if tc.expectedEvents == nil {
+				return
+			}
+			if contentType := w.Header().Get("Content-Type"); contentType != job.ContentTypeEventStream {
+				t.Fatalf("Expected:%v, Got:%v", job.ContentTypeEventStream, contentType)
+			}
+
+			var events []string
+			for _, line := range strings.Split(w.Body.String(), "\n") {
+				if event, ok := strings.CutPrefix(line, "event: "); ok {
+					events = append(events, event)
+				}
+			}
+			if strings.Join(events, ",") != strings.Join(tc.expectedEvents, ",") {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedEvents, events)
+			}
+			if !strings.Contains(w.Body.String(), `"summary"`) {
+				t.Fatalf("Expected:%v, Got:%v", "the summary in the done event", w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package job
+
+import (
+	"net/url"
+	"time"
+	"web-analyser/api/backend/analyser"
+	"web-analyser/api/backend/crawler"
+	iError "web-analyser/internal/utils/error"
+	iHttp "web-analyser/internal/utils/http"
+)
+
+// Status represents the state of a job
+type Status string
+
+const (
+	StatusQueued  Status = "queued"  // the job is waiting for a worker
+	StatusRunning Status = "running" // the URL is being analysed
+	StatusDone    Status = "done"    // the URL was analysed, the summary is set
+	StatusFailed  Status = "failed"  // the analysis failed, the error is set
+)
+
+// Kind represents what a job runs
+type Kind string
+
+const (
+	KindAnalysis Kind = "analysis" // the job analyses the page of the URL
+	KindCrawl    Kind = "crawl"    // the job crawls the website of the URL
+)
+
+// Job represents the analysis of a URL, or the crawl of its website, run in the background
+type Job struct {
+	ID        string `json:"id"`        // ID represents the unique id of the job
+	Kind      Kind   `json:"kind"`      // Kind represents what the job runs
+	URL       string `json:"url"`       // URL represents the URL to analyse or to crawl from
+	RequestID string `json:"requestId"` // RequestID represents the id of the request submitting the job
+	Status    Status `json:"status"`    // Status represents the state of the job
+	// Progress represents how far the analysis has progressed, nil until the page is fetched
+	Progress   *analyser.Progress `json:"progress,omitempty"`
+	CreatedAt  time.Time          `json:"createdAt"`  // CreatedAt represents when the job was submitted
+	StartedAt  time.Time          `json:"startedAt"`  // StartedAt represents when a worker started the job
+	FinishedAt time.Time          `json:"finishedAt"` // FinishedAt represents when the job was done or failed
+	// Summary represents the summary of the analysis once an analysis job is done
+	Summary *analyser.Summary `json:"summary,omitempty"`
+	// Site represents the site summary of the crawl once a crawl job is done
+	Site *crawler.SiteSummary `json:"site,omitempty"`
+	// Error represents why the analysis failed once the job failed
+	Error *iError.CustomError `json:"error,omitempty"`
+
+	url     *url.URL
+	options *iHttp.RequestOptions
+}
+
+// IsFinished returns whether the job is either done or failed
+func (j *Job) IsFinished() bool {
+	return j.Status == StatusDone || j.Status == StatusFailed
+}
+
+// withoutSummary returns a copy of the job without the summary nor the site summary, used to stream the progress
+func (j *Job) withoutSummary() *Job {
+	c := *j
+	c.Summary = nil
+	c.Site = nil
+	return &c
+}
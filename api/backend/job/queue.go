@@ -0,0 +1,255 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"net/url"
+	"sync"
+	"time"
+	"web-analyser/api/backend/analyser"
+	"web-analyser/api/backend/crawler"
+	"web-analyser/config"
+	iCtx "web-analyser/internal/utils/ctx"
+	iError "web-analyser/internal/utils/error"
+	iHttp "web-analyser/internal/utils/http"
+)
+
+var (
+	// ErrNotFound is returned when there is no job with the id
+	ErrNotFound = errors.New("job not found")
+	// ErrQueueFull is returned when there are already as many jobs waiting as the queue size
+	ErrQueueFull = errors.New("job queue is full")
+)
+
+// Queue runs the analyses in the background
+type Queue interface {
+	// Submit queues the analysis of the url with the request options and returns the queued job
+	Submit(url *url.URL, requestID string, options *iHttp.RequestOptions) (*Job, error)
+	// SubmitCrawl queues the crawl of the website of the url and returns the queued job
+	SubmitCrawl(url *url.URL, requestID string) (*Job, error)
+	// Get returns a snapshot of the job with the id, ErrNotFound if there is none
+	Get(id string) (*Job, error)
+	// Subscribe returns a channel receiving a value every time the job with the id changes, along with the func to
+	// unsubscribe. The channel receives a value right away, and only the latest change is kept if the subscriber
+	// falls behind, so the subscriber gets the state of the job with Get
+	Subscribe(id string) (<-chan struct{}, func(), error)
+}
+
+// QueueImpl keeps the jobs in memory and runs them with a bounded pool of workers
+type QueueImpl struct {
+	analyser analyser.Analyser
+	crawler  crawler.Crawler
+	recorder analyser.Recorder
+	conf     *config.JobConf
+	logger   *zerolog.Logger
+	now      func() time.Time
+	queue    chan string
+
+	mu          sync.RWMutex
+	jobs        map[string]*Job
+	subscribers map[string]map[chan struct{}]struct{}
+}
+
+func NewQueue(analyser analyser.Analyser, crawler crawler.Crawler, recorder analyser.Recorder, conf *config.JobConf,
+	logger *zerolog.Logger) *QueueImpl {
+	return &QueueImpl{
+		analyser:    analyser,
+		crawler:     crawler,
+		recorder:    recorder,
+		conf:        conf,
+		logger:      logger,
+		now:         time.Now,
+		queue:       make(chan string, conf.QueueSize),
+		jobs:        make(map[string]*Job),
+		subscribers: make(map[string]map[chan struct{}]struct{}),
+	}
+}
+
+// Start starts the workers, the analyses are bound to the context and the workers stop taking jobs once it is done
+func (q *QueueImpl) Start(ctx context.Context) {
+	for i := 0; i < max(q.conf.Workers, 1); i++ {
+		go q.work(ctx)
+	}
+}
+
+func (q *QueueImpl) Submit(url *url.URL, requestID string, options *iHttp.RequestOptions) (*Job, error) {
+	return q.submit(&Job{Kind: KindAnalysis, URL: url.String(), RequestID: requestID, url: url, options: options})
+}
+
+func (q *QueueImpl) SubmitCrawl(url *url.URL, requestID string) (*Job, error) {
+	return q.submit(&Job{Kind: KindCrawl, URL: url.String(), RequestID: requestID, url: url})
+}
+
+// submit queues the job and returns a copy of it, ErrQueueFull if there are already as many jobs waiting as the
+// queue size
+func (q *QueueImpl) submit(job *Job) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.removeExpired()
+	job.ID = uuid.New().String()
+	job.Status = StatusQueued
+	job.CreatedAt = q.now().UTC()
+
+	select {
+	case q.queue <- job.ID:
+		q.jobs[job.ID] = job
+	default:
+		return nil, ErrQueueFull
+	}
+
+	c := *job
+	return &c, nil
+}
+
+func (q *QueueImpl) Get(id string) (*Job, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	job, ok := q.jobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	c := *job
+	return &c, nil
+}
+
+func (q *QueueImpl) Subscribe(id string) (<-chan struct{}, func(), error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.jobs[id]; !ok {
+		return nil, nil, ErrNotFound
+	}
+
+	updates := make(chan struct{}, 1)
+	updates <- struct{}{}
+	if q.subscribers[id] == nil {
+		q.subscribers[id] = make(map[chan struct{}]struct{})
+	}
+	q.subscribers[id][updates] = struct{}{}
+
+	unsubscribe := func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		delete(q.subscribers[id], updates)
+		if len(q.subscribers[id]) == 0 {
+			delete(q.subscribers, id)
+		}
+	}
+	return updates, unsubscribe, nil
+}
+
+// work runs the queued jobs one at a time until the context is done
+func (q *QueueImpl) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-q.queue:
+			q.run(ctx, id)
+		}
+	}
+}
+
+// run analyses the URL of the job, updating the job as the analysis progresses, and records the summary in the
+// history once the job is done. The request id and the request options of the job are forwarded to the requests of
+// the analysis, the options are dropped from the job so that its credentials are not kept any longer than needed.
+// The crawl jobs crawl the website of the URL instead, see crawl.
+func (q *QueueImpl) run(ctx context.Context, id string) {
+	var u *url.URL
+	var kind Kind
+	var requestID string
+	var options *iHttp.RequestOptions
+	q.update(id, func(job *Job) {
+		job.Status = StatusRunning
+		job.StartedAt = q.now().UTC()
+		u, kind, requestID, options = job.url, job.Kind, job.RequestID, job.options
+		job.options = nil
+	})
+	if u == nil {
+		// the job no longer exists
+		return
+	}
+	if kind == KindCrawl {
+		q.crawl(iCtx.SetRequestID(ctx, requestID), id, u)
+		return
+	}
+
+	start := q.now()
+	ctx = iHttp.WithRequestOptions(iCtx.SetRequestID(ctx, requestID), u, options)
+	summary, err, statusCode := q.analyser.AnalyseWithProgress(ctx, u, func(progress *analyser.Progress) {
+		q.update(id, func(job *Job) { job.Progress = progress })
+	})
+	if err != nil {
+		_, customError := analyser.AnalysisError(err, statusCode)
+		q.logger.Error().Str(iCtx.KeyRequestID, requestID).Str("job", id).Err(err).Msg(customError.Message)
+		q.update(id, func(job *Job) {
+			job.Status = StatusFailed
+			job.Error = customError
+			job.FinishedAt = q.now().UTC()
+		})
+		return
+	}
+
+	// the analysis is served even if it can't be recorded
+	if err := q.recorder.Record(requestID, summary, statusCode, q.now().Sub(start)); err != nil {
+		q.logger.Error().Str(iCtx.KeyRequestID, requestID).Err(err).Msg("unable to record the analysis")
+	}
+	q.update(id, func(job *Job) {
+		job.Status = StatusDone
+		job.Summary = summary
+		job.FinishedAt = q.now().UTC()
+	})
+}
+
+// crawl crawls the website of the URL of the job and sets the site summary once the job is done. The job fails if the
+// context is done before the crawl is finished, since the site summary misses the pages which were not crawled.
+func (q *QueueImpl) crawl(ctx context.Context, id string, u *url.URL) {
+	site := q.crawler.Crawl(ctx, u)
+	if err := ctx.Err(); err != nil {
+		q.logger.Error().Str(iCtx.KeyRequestID, iCtx.RequestID(ctx)).Str("job", id).Err(err).Msg("crawl cancelled")
+		q.update(id, func(job *Job) {
+			job.Status = StatusFailed
+			job.Error = &iError.CustomError{Message: string(iError.AnalysisCancelledError)}
+			job.FinishedAt = q.now().UTC()
+		})
+		return
+	}
+	q.update(id, func(job *Job) {
+		job.Status = StatusDone
+		job.Site = site
+		job.FinishedAt = q.now().UTC()
+	})
+}
+
+// update applies the change to the job with the id, if it still exists, and signals its subscribers
+func (q *QueueImpl) update(id string, change func(job *Job)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[id]
+	if !ok {
+		return
+	}
+	change(job)
+
+	for updates := range q.subscribers[id] {
+		// the subscriber already has a change to read, which is enough since it reads the latest state of the job
+		select {
+		case updates <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// removeExpired removes the jobs which finished more than the TTL ago, the caller must hold the lock
+func (q *QueueImpl) removeExpired() {
+	for id, job := range q.jobs {
+		if job.IsFinished() && q.now().Sub(job.FinishedAt) > q.conf.TTL {
+			delete(q.jobs, id)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package job_test
+
+import (
+	"context"
+	"errors"
+	"go.uber.org/mock/gomock"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+	"web-analyser/api/backend/analyser"
+	"web-analyser/api/backend/crawler"
+	"web-analyser/api/backend/history"
+	"web-analyser/api/backend/job"
+	"web-analyser/config"
+	iError "web-analyser/internal/utils/error"
+	l "web-analyser/internal/utils/logger"
+	"web-analyser/mocks"
+)
+
+// newSummary returns the summary of https://google.com with the title
+func newSummary(title string) *analyser.Summary {
+	u, _ := url.Parse("https://google.com")
+	summary := analyser.NewSummary(u)
+	summary.SetTitle(title)
+	return summary
+}
+
+// wait returns the job with the id once it is finished
+func wait(t *testing.T, q job.Queue, id string) *job.Job {
+	updates, unsubscribe, err := q.Subscribe(id)
+	if err != nil {
+		t.Fatalf("Expected:%v, Got:%v", nil, err)
+	}
+	defer unsubscribe()
+
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case <-updates:
+		case <-timeout:
+			t.Fatalf("Expected:%v, Got:%v", "the job to finish", "timeout")
+		}
+		j, _ := q.Get(id)
+		if j.IsFinished() {
+			return j
+		}
+	}
+}
+
+func TestQueueImpl_Run(t *testing.T) {
+	tests := []*struct {
+		name              string
+		setupExpectations func(*mocks.MockAnalyser)
+		expectedStatus    job.Status
+		expectedProgress  *analyser.Progress
+		expectedError     *iError.CustomError
+		expectedRecords   int
+	}{
+		{
+			name: "Should run the analysis, report its progress and record the summary",
+			setupExpectations: func(mockAnalyser *mocks.MockAnalyser) {
+				mockAnalyser.EXPECT().AnalyseWithProgress(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ *url.URL, progress analyser.ProgressFunc) (*analyser.Summary, error, int) {
+						progress(&analyser.Progress{Stage: analyser.StageFetched})
+						progress(&analyser.Progress{Stage: analyser.StageCheckingLinks, LinksChecked: 2, LinksTotal: 2})
+						return newSummary("Google"), nil, 200
+					})
+			},
+			expectedStatus:   job.StatusDone,
+			expectedProgress: &analyser.Progress{Stage: analyser.StageCheckingLinks, LinksChecked: 2, LinksTotal: 2},
+			expectedRecords:  1,
+		},
+		{
+			name: "Should fail the job when the url can't be analysed",
+			setupExpectations: func(mockAnalyser *mocks.MockAnalyser) {
+				mockAnalyser.EXPECT().AnalyseWithProgress(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil,
+					errors.New("error"), 404)
+			},
+			expectedStatus: job.StatusFailed,
+			expectedError:  &iError.CustomError{Message: string(iError.UnreachableURLError), HttpStatusCode: 404},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockAnalyser := mocks.NewMockAnalyser(ctrl)
+			tc.setupExpectations(mockAnalyser)
+			store := history.NewMemoryStore(&config.HistoryConf{})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			q := job.NewQueue(mockAnalyser, nil, history.NewRecorder(store),
+				&config.JobConf{Workers: 1, QueueSize: 1, TTL: time.Hour}, l.NewLogger(false))
+			q.Start(ctx)
+
+			u, _ := url.Parse("https://google.com")
+			submitted, err := q.Submit(u, "request", nil)
+			if err != nil || submitted.Status != job.StatusQueued {
+				t.Fatalf("Expected:%v, Got:%+v, %v", job.StatusQueued, submitted, err)
+			}
+
+			finished := wait(t, q, submitted.ID)
+			if finished.Status != tc.expectedStatus {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedStatus, finished.Status)
+			}
+			if !reflect.DeepEqual(tc.expectedProgress, finished.Progress) {
+				t.Fatalf("Expected:%+v, Got:%+v", tc.expectedProgress, finished.Progress)
+			}
+			if !reflect.DeepEqual(tc.expectedError, finished.Error) {
+				t.Fatalf("Expected:%+v, Got:%+v", tc.expectedError, finished.Error)
+			}
+			if (finished.Summary != nil) != (tc.expectedStatus == job.StatusDone) {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedStatus == job.StatusDone, finished.Summary != nil)
+			}
+
+			records, _ := store.List("https://google.com/")
+			if len(records) != tc.expectedRecords {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedRecords, len(records))
+			}
+		})
+	}
+}
+
+func TestQueueImpl_Run_Crawl(t *testing.T) {
+	tests := []*struct {
+		name           string
+		cancel         bool
+		expectedStatus job.Status
+		expectedError  *iError.CustomError
+	}{
+		{
+			name:           "Should crawl the website and set the site summary",
+			expectedStatus: job.StatusDone,
+		},
+		{
+			name:           "Should fail the job when the crawl is cancelled",
+			cancel:         true,
+			expectedStatus: job.StatusFailed,
+			expectedError:  &iError.CustomError{Message: string(iError.AnalysisCancelledError)},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			u, _ := url.Parse("https://google.com")
+			mockCrawler := mocks.NewMockCrawler(ctrl)
+			mockCrawler.EXPECT().Crawl(gomock.Any(), u).DoAndReturn(
+				func(context.Context, *url.URL) *crawler.SiteSummary {
+					if tc.cancel {
+						// the server shuts down while the website is crawled
+						cancel()
+					}
+					return crawler.NewSiteSummary(u)
+				})
+
+			q := job.NewQueue(nil, mockCrawler, nil, &config.JobConf{Workers: 1, QueueSize: 1, TTL: time.Hour},
+				l.NewLogger(false))
+			q.Start(ctx)
+
+			submitted, err := q.SubmitCrawl(u, "request")
+			if err != nil || submitted.Kind != job.KindCrawl {
+				t.Fatalf("Expected:%v, Got:%+v, %v", job.KindCrawl, submitted, err)
+			}
+
+			finished := wait(t, q, submitted.ID)
+			if finished.Status != tc.expectedStatus {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedStatus, finished.Status)
+			}
+			if !reflect.DeepEqual(tc.expectedError, finished.Error) {
+				t.Fatalf("Expected:%+v, Got:%+v", tc.expectedError, finished.Error)
+			}
+			if (finished.Site != nil) != (tc.expectedStatus == job.StatusDone) || finished.Summary != nil {
+				t.Fatalf("Expected:%v, Got:%+v", tc.expectedStatus == job.StatusDone, finished)
+			}
+		})
+	}
+}
+
+func TestQueueImpl_Submit(t *testing.T) {
+	// the workers are not started, so the jobs stay in the queue
+	q := job.NewQueue(nil, nil, nil, &config.JobConf{Workers: 1, QueueSize: 1, TTL: time.Hour}, l.NewLogger(false))
+	u, _ := url.Parse("https://google.com")
+
+	if _, err := q.Submit(u, "request", nil); err != nil {
+		t.Fatalf("Expected:%v, Got:%v", nil, err)
+	}
+	if _, err := q.Submit(u, "request", nil); !errors.Is(err, job.ErrQueueFull) {
+		t.Fatalf("Expected:%v, Got:%v", job.ErrQueueFull, err)
+	}
+}
+
+func TestQueueImpl_Get(t *testing.T) {
+	q := job.NewQueue(nil, nil, nil, &config.JobConf{Workers: 1, QueueSize: 1, TTL: time.Hour}, l.NewLogger(false))
+
+	if _, err := q.Get("unknown"); !errors.Is(err, job.ErrNotFound) {
+		t.Fatalf("Expected:%v, Got:%v", job.ErrNotFound, err)
+	}
+	if _, _, err := q.Subscribe("unknown"); !errors.Is(err, job.ErrNotFound) {
+		t.Fatalf("Expected:%v, Got:%v", job.ErrNotFound, err)
+	}
+}
@@ -0,0 +1,216 @@
+package monitor
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+	"web-analyser/api/backend/analyser"
+	"web-analyser/config"
+	iCtx "web-analyser/internal/utils/ctx"
+	iError "web-analyser/internal/utils/error"
+	iHttp "web-analyser/internal/utils/http"
+	"web-analyser/internal/utils/render"
+)
+
+// scheduleSamples is the number of the next checks of a schedule which must be at least the min interval apart, a
+// day of checks every 15 minutes
+const scheduleSamples = 96
+
+type Handler interface {
+	List(w http.ResponseWriter, r *http.Request)
+	Register(w http.ResponseWriter, r *http.Request)
+	Show(w http.ResponseWriter, r *http.Request)
+	Delete(w http.ResponseWriter, r *http.Request)
+}
+
+type HandlerImpl struct {
+	logger    *zerolog.Logger
+	store     Store
+	scheduler Scheduler
+	conf      *config.MonitorConf
+	now       func() time.Time
+}
+
+// registerRequest is the JSON body accepted by the register api
+type registerRequest struct {
+	URL        string   `json:"url"`
+	Interval   string   `json:"interval"`
+	Schedule   string   `json:"schedule"`
+	Conditions []string `json:"conditions"`
+}
+
+func NewHandler(logger *zerolog.Logger, store Store, scheduler Scheduler, conf *config.MonitorConf) *HandlerImpl {
+	return &HandlerImpl{
+		logger:    logger,
+		store:     store,
+		scheduler: scheduler,
+		conf:      conf,
+		now:       time.Now,
+	}
+}
+
+// List returns all the registered monitors as JSON, oldest first.
+func (h *HandlerImpl) List(w http.ResponseWriter, r *http.Request) {
+	monitors, err := h.store.List()
+	if err != nil {
+		h.renderStoreError(w, r, err)
+		return
+	}
+	render.JSON(w, r, h.logger, http.StatusOK, monitors)
+}
+
+// Register registers and schedules a monitor of the url, checked every interval or at the cron schedule, and returns
+// it as JSON. The url, the interval or the schedule and the conditions are read from the JSON body in case of a JSON
+// request, otherwise from the query or form values.
+func (h *HandlerImpl) Register(w http.ResponseWriter, r *http.Request) {
+	request := registerRequest{URL: r.FormValue("url"), Interval: r.FormValue("interval"),
+		Schedule: r.FormValue("schedule")}
+	// the form is parsed by FormValue, the conditions are the values of the repeated conditions field
+	request.Conditions = r.Form["conditions"]
+
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == iHttp.ContentTypeJSON {
+		request = registerRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			h.logger.Error().Str(iCtx.KeyRequestID, iCtx.RequestID(r.Context())).Err(err).Msg("invalid request body")
+			h.renderError(w, r, http.StatusBadRequest, iError.InvalidMonitorRequestError)
+			return
+		}
+	}
+
+	monitor, customError := h.newMonitor(r, &request)
+	if customError != nil {
+		render.JSON(w, r, h.logger, http.StatusBadRequest, analyser.ErrorResponse{Error: *customError})
+		return
+	}
+	if err := h.store.Save(monitor); err != nil {
+		h.renderStoreError(w, r, err)
+		return
+	}
+	h.scheduler.Schedule(monitor)
+	render.JSON(w, r, h.logger, http.StatusCreated, monitor)
+}
+
+// Show returns the monitor with the id as JSON.
+func (h *HandlerImpl) Show(w http.ResponseWriter, r *http.Request) {
+	monitor, err := h.store.Get(chi.URLParam(r, "id"))
+	if err != nil {
+		h.renderStoreError(w, r, err)
+		return
+	}
+	render.JSON(w, r, h.logger, http.StatusOK, monitor)
+}
+
+// Delete stops and removes the monitor with the id. The monitor is unscheduled first, waiting for its running check,
+// so that the check can't store the monitor again once it is deleted.
+func (h *HandlerImpl) Delete(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.scheduler.Unschedule(id)
+	if err := h.store.Delete(id); err != nil {
+		// the monitor which couldn't be deleted is still stored, so it is scheduled again
+		if monitor, err := h.store.Get(id); err == nil {
+			h.scheduler.Schedule(monitor)
+		}
+		h.renderStoreError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// newMonitor validates the request and returns the monitor to register. In case of an invalid request, it logs the
+// error and returns the error to be conveyed to the user
+func (h *HandlerImpl) newMonitor(r *http.Request, request *registerRequest) (*Monitor, *iError.CustomError) {
+	reqID := iCtx.RequestID(r.Context())
+
+	parsedUrl, err := iHttp.ValidateURL(request.URL)
+	if err != nil {
+		h.logger.Error().Str(iCtx.KeyRequestID, reqID).Str("url", request.URL).Err(err).Msg("invalid URL")
+		return nil, &iError.CustomError{Message: err.Error()}
+	}
+
+	var interval time.Duration
+	switch {
+	case request.Interval != "" && request.Schedule != "":
+		h.logger.Error().Str(iCtx.KeyRequestID, reqID).Msg("both an interval and a schedule")
+		return nil, &iError.CustomError{Message: string(iError.IntervalAndScheduleError)}
+	case request.Schedule != "":
+		if !h.isScheduleAllowed(request.Schedule) {
+			h.logger.Error().Str(iCtx.KeyRequestID, reqID).Str("schedule", request.Schedule).Msg("invalid schedule")
+			return nil, &iError.CustomError{Message: string(iError.InvalidScheduleError) + h.conf.MinInterval.String()}
+		}
+	default:
+		interval, err = time.ParseDuration(request.Interval)
+		if err != nil || interval < h.conf.MinInterval {
+			h.logger.Error().Str(iCtx.KeyRequestID, reqID).Str("interval", request.Interval).Msg("invalid interval")
+			return nil, &iError.CustomError{Message: string(iError.InvalidIntervalError) + h.conf.MinInterval.String()}
+		}
+	}
+
+	for _, condition := range request.Conditions {
+		if !isCondition(condition) {
+			h.logger.Error().Str(iCtx.KeyRequestID, reqID).Str("condition", condition).Msg("unknown condition")
+			return nil, &iError.CustomError{
+				Message: string(iError.UnknownConditionError) + strings.Join(Conditions, ", ")}
+		}
+	}
+
+	// the conditions are encoded as an empty list rather than null when all of them are enabled
+	conditions := []string{}
+	conditions = append(conditions, request.Conditions...)
+
+	return &Monitor{
+		ID:         uuid.New().String(),
+		URL:        parsedUrl.String(),
+		Interval:   interval,
+		Schedule:   request.Schedule,
+		Conditions: conditions,
+		CreatedAt:  h.now().UTC(),
+	}, nil
+}
+
+// isScheduleAllowed returns whether the schedule is a valid cron expression whose next checks are at least the min
+// interval apart
+func (h *HandlerImpl) isScheduleAllowed(schedule string) bool {
+	monitor := &Monitor{Schedule: schedule}
+	previous, err := monitor.NextCheck(h.now())
+	if err != nil {
+		return false
+	}
+	for i := 0; i < scheduleSamples; i++ {
+		next, err := monitor.NextCheck(previous)
+		if err != nil || next.Sub(previous) < h.conf.MinInterval {
+			return false
+		}
+		previous = next
+	}
+	return true
+}
+
+// isCondition returns whether the name is the name of an alert condition
+func isCondition(name string) bool {
+	for _, condition := range Conditions {
+		if condition == name {
+			return true
+		}
+	}
+	return false
+}
+
+// renderStoreError writes the error of the store as JSON, logging it unless the monitor is not found
+func (h *HandlerImpl) renderStoreError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, ErrNotFound) {
+		h.renderError(w, r, http.StatusNotFound, iError.MonitorNotFoundError)
+		return
+	}
+	h.logger.Error().Str(iCtx.KeyRequestID, iCtx.RequestID(r.Context())).Err(err).Msg("monitor store error")
+	h.renderError(w, r, http.StatusInternalServerError, iError.MonitorUnavailableError)
+}
+
+func (h *HandlerImpl) renderError(w http.ResponseWriter, r *http.Request, statusCode int, msg iError.Msg) {
+	render.JSON(w, r, h.logger, statusCode, analyser.ErrorResponse{Error: iError.CustomError{Message: string(msg)}})
+}
@@ -0,0 +1,295 @@
+package monitor_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"web-analyser/api/backend/analyser"
+	"web-analyser/api/backend/history"
+	"web-analyser/api/backend/monitor"
+	"web-analyser/config"
+	iError "web-analyser/internal/utils/error"
+	iHttp "web-analyser/internal/utils/http"
+	l "web-analyser/internal/utils/logger"
+	"web-analyser/mocks"
+)
+
+// withID returns the request with the id as the route param
+func withID(r *http.Request, id string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
+
+// jsonRequest returns a JSON request with the body
+func jsonRequest(method string, target string, body string) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Header.Set("Content-Type", iHttp.ContentTypeJSON)
+	return r
+}
+
+func TestHandlerImpl_Register(t *testing.T) {
+	tests := []*struct {
+		name               string
+		request            *http.Request
+		expectedStatusCode int
+		expectedError      string
+		expectedMonitor    *monitor.Monitor
+	}{
+		{
+			name: "Should register and schedule the monitor of the JSON body",
+			request: jsonRequest(http.MethodPost, "/api/v1/monitors",
+				`{"url": "google.com", "interval": "1h", "conditions": ["title", "status"]}`),
+			expectedStatusCode: http.StatusCreated,
+			expectedMonitor: &monitor.Monitor{URL: "https://google.com", Interval: time.Hour,
+				Conditions: []string{"title", "status"}},
+		},
+		{
+			name: "Should register and schedule the monitor of the form values",
+			request: httptest.NewRequest(http.MethodPost,
+				"/api/v1/monitors?url=https://google.com&interval=15m&conditions=login_form", nil),
+			expectedStatusCode: http.StatusCreated,
+			expectedMonitor: &monitor.Monitor{URL: "https://google.com", Interval: 15 * time.Minute,
+				Conditions: []string{"login_form"}},
+		},
+		{
+			name: "Should register and schedule the monitor of the cron schedule",
+			request: jsonRequest(http.MethodPost, "/api/v1/monitors",
+				`{"url": "https://google.com", "schedule": "*/15 9-17 * * 1-5"}`),
+			expectedStatusCode: http.StatusCreated,
+			expectedMonitor:    &monitor.Monitor{URL: "https://google.com", Schedule: "*/15 9-17 * * 1-5"},
+		},
+		{
+			name:               "Should return bad request for an invalid JSON body",
+			request:            jsonRequest(http.MethodPost, "/api/v1/monitors", `{`),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      string(iError.InvalidMonitorRequestError),
+		},
+		{
+			name:               "Should return bad request for an invalid url",
+			request:            jsonRequest(http.MethodPost, "/api/v1/monitors", `{"url": "ftp://google.com"}`),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      string(iError.UnsupportedSchemeError),
+		},
+		{
+			name: "Should return bad request for an interval shorter than the min interval",
+			request: jsonRequest(http.MethodPost, "/api/v1/monitors",
+				`{"url": "https://google.com", "interval": "10s"}`),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      string(iError.InvalidIntervalError) + "1m0s",
+		},
+		{
+			name: "Should return bad request for an invalid schedule",
+			request: jsonRequest(http.MethodPost, "/api/v1/monitors",
+				`{"url": "https://google.com", "schedule": "* * *"}`),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      string(iError.InvalidScheduleError) + "1m0s",
+		},
+		{
+			name: "Should return bad request for a schedule which never matches",
+			request: jsonRequest(http.MethodPost, "/api/v1/monitors",
+				`{"url": "https://google.com", "schedule": "0 0 30 2 *"}`),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      string(iError.InvalidScheduleError) + "1m0s",
+		},
+		{
+			name: "Should return bad request for a schedule checking more often than the min interval",
+			request: jsonRequest(http.MethodPost, "/api/v1/monitors",
+				`{"url": "https://google.com", "schedule": "@every 10s"}`),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      string(iError.InvalidScheduleError) + "1m0s",
+		},
+		{
+			name: "Should return bad request for both an interval and a schedule",
+			request: jsonRequest(http.MethodPost, "/api/v1/monitors",
+				`{"url": "https://google.com", "interval": "1h", "schedule": "@hourly"}`),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      string(iError.IntervalAndScheduleError),
+		},
+		{
+			name: "Should return bad request for an unknown condition",
+			request: jsonRequest(http.MethodPost, "/api/v1/monitors",
+				`{"url": "https://google.com", "interval": "1h", "conditions": ["images"]}`),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      string(iError.UnknownConditionError) + "status, broken_links, login_form, title",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockScheduler := mocks.NewMockScheduler(ctrl)
+			store, _ := monitor.NewFileStore(filepath.Join(t.TempDir(), "monitors.json"))
+			handler := monitor.NewHandler(l.NewLogger(false), store, mockScheduler,
+				&config.MonitorConf{MinInterval: time.Minute})
+			if tc.expectedMonitor != nil {
+				mockScheduler.EXPECT().Schedule(gomock.Any())
+			}
+
+			w := httptest.NewRecorder()
+			handler.Register(w, tc.request)
+
+			if w.Code != tc.expectedStatusCode {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedStatusCode, w.Code)
+			}
+			if tc.expectedError != "" {
+				var response analyser.ErrorResponse
+				_ = json.NewDecoder(w.Body).Decode(&response)
+				if response.Error.Message != tc.expectedError {
+					t.Fatalf("Expected:%v, Got:%v", tc.expectedError, response.Error.Message)
+				}
+				return
+			}
+
+			var response monitor.Monitor
+			_ = json.NewDecoder(w.Body).Decode(&response)
+			if response.URL != tc.expectedMonitor.URL || response.Interval != tc.expectedMonitor.Interval ||
+				response.Schedule != tc.expectedMonitor.Schedule ||
+				strings.Join(response.Conditions, ";") != strings.Join(tc.expectedMonitor.Conditions, ";") {
+				t.Fatalf("Expected:%+v, Got:%+v", tc.expectedMonitor, response)
+			}
+			if _, err := store.Get(response.ID); err != nil {
+				t.Fatalf("Expected:%v, Got:%v", nil, err)
+			}
+		})
+	}
+}
+
+func TestHandlerImpl_API(t *testing.T) {
+	tests := []*struct {
+		name               string
+		request            *http.Request
+		handle             func(h monitor.Handler) http.HandlerFunc
+		setupExpectations  func(*mocks.MockScheduler)
+		expectedStatusCode int
+		expectedError      iError.Msg
+	}{
+		{
+			name:               "Should list the monitors",
+			request:            httptest.NewRequest(http.MethodGet, "/api/v1/monitors", nil),
+			handle:             func(h monitor.Handler) http.HandlerFunc { return h.List },
+			setupExpectations:  func(mockScheduler *mocks.MockScheduler) {},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Should return the monitor",
+			request:            withID(httptest.NewRequest(http.MethodGet, "/api/v1/monitors/1", nil), "1"),
+			handle:             func(h monitor.Handler) http.HandlerFunc { return h.Show },
+			setupExpectations:  func(mockScheduler *mocks.MockScheduler) {},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Should return not found for an unknown monitor",
+			request:            withID(httptest.NewRequest(http.MethodGet, "/api/v1/monitors/2", nil), "2"),
+			handle:             func(h monitor.Handler) http.HandlerFunc { return h.Show },
+			setupExpectations:  func(mockScheduler *mocks.MockScheduler) {},
+			expectedStatusCode: http.StatusNotFound,
+			expectedError:      iError.MonitorNotFoundError,
+		},
+		{
+			name:    "Should unschedule and delete the monitor",
+			request: withID(httptest.NewRequest(http.MethodDelete, "/api/v1/monitors/1", nil), "1"),
+			handle:  func(h monitor.Handler) http.HandlerFunc { return h.Delete },
+			setupExpectations: func(mockScheduler *mocks.MockScheduler) {
+				mockScheduler.EXPECT().Unschedule("1")
+			},
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			name:    "Should return not found when deleting an unknown monitor",
+			request: withID(httptest.NewRequest(http.MethodDelete, "/api/v1/monitors/2", nil), "2"),
+			handle:  func(h monitor.Handler) http.HandlerFunc { return h.Delete },
+			setupExpectations: func(mockScheduler *mocks.MockScheduler) {
+				mockScheduler.EXPECT().Unschedule("2")
+			},
+			expectedStatusCode: http.StatusNotFound,
+			expectedError:      iError.MonitorNotFoundError,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockScheduler := mocks.NewMockScheduler(ctrl)
+			tc.setupExpectations(mockScheduler)
+			store, _ := monitor.NewFileStore(filepath.Join(t.TempDir(), "monitors.json"))
+			_ = store.Save(&monitor.Monitor{ID: "1", URL: "https://google.com", Interval: time.Hour})
+			handler := monitor.NewHandler(l.NewLogger(false), store, mockScheduler, &config.MonitorConf{})
+
+			w := httptest.NewRecorder()
+			tc.handle(handler)(w, tc.request)
+
+			if w.Code != tc.expectedStatusCode {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedStatusCode, w.Code)
+			}
+			if tc.expectedError != "" {
+				var response analyser.ErrorResponse
+				_ = json.NewDecoder(w.Body).Decode(&response)
+				if response.Error.Message != string(tc.expectedError) {
+					t.Fatalf("Expected:%v, Got:%v", tc.expectedError, response.Error.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestHandlerImpl_Delete_DuringCheck(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// the analysis keeps running until it is released, even once the check is cancelled
+	started, release := make(chan struct{}), make(chan struct{})
+	mockAnalyser := mocks.NewMockAnalyser(ctrl)
+	mockAnalyser.EXPECT().Analyse(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(context.Context, *url.URL) (*analyser.Summary, error, int) {
+			close(started)
+			<-release
+			return nil, errors.New("error"), 500
+		})
+
+	path := filepath.Join(t.TempDir(), "monitors.json")
+	store, _ := monitor.NewFileStore(path)
+	_ = store.Save(&monitor.Monitor{ID: "1", URL: "https://google.com", Interval: time.Millisecond})
+	historyStore := history.NewMemoryStore(&config.HistoryConf{})
+	scheduler := monitor.NewScheduler(mockAnalyser, history.NewRecorder(historyStore), historyStore, store,
+		[]monitor.Notifier{}, &config.MonitorConf{}, l.NewLogger(false))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_ = scheduler.Start(ctx)
+	handler := monitor.NewHandler(l.NewLogger(false), store, scheduler, &config.MonitorConf{})
+
+	<-started
+	deleted := make(chan *httptest.ResponseRecorder)
+	go func() {
+		w := httptest.NewRecorder()
+		handler.Delete(w, withID(httptest.NewRequest(http.MethodDelete, "/api/v1/monitors/1", nil), "1"))
+		deleted <- w
+	}()
+
+	// the delete waits for the running check
+	select {
+	case <-deleted:
+		t.Fatalf("Expected:%v, Got:%v", "the delete to wait for the check", "deleted")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	if w := <-deleted; w.Code != http.StatusNoContent {
+		t.Fatalf("Expected:%v, Got:%v", http.StatusNoContent, w.Code)
+	}
+
+	reloaded, _ := monitor.NewFileStore(path)
+	if _, err := reloaded.Get("1"); !errors.Is(err, monitor.ErrNotFound) {
+		t.Fatalf("Expected:%v, Got:%v", monitor.ErrNotFound, err)
+	}
+}
@@ -0,0 +1,101 @@
+package monitor
+
+import (
+	"fmt"
+	"github.com/robfig/cron/v3"
+	"strings"
+	"time"
+)
+
+// names of the alert conditions
+const (
+	ConditionStatus      = "status"       // the page no longer responds with 200
+	ConditionBrokenLinks = "broken_links" // links of the page became inaccessible
+	ConditionLoginForm   = "login_form"   // the login form was removed from the page
+	ConditionTitle       = "title"        // the title of the page changed
+)
+
+// Conditions are the names of all the alert conditions
+var Conditions = []string{ConditionStatus, ConditionBrokenLinks, ConditionLoginForm, ConditionTitle}
+
+// Monitor represents a URL checked periodically for regressions
+type Monitor struct {
+	ID  string `json:"id"`  // ID represents the unique id of the monitor
+	URL string `json:"url"` // URL represents the URL which is checked
+	// Interval represents the time between two checks, 0 if the monitor checks its URL at its schedule
+	Interval time.Duration `json:"interval"`
+	// Schedule represents the cron expression the URL is checked at, for example: 0 9 * * 1-5, empty if the monitor
+	// checks its URL every interval
+	Schedule string `json:"schedule,omitempty"`
+	// Conditions represents the names of the conditions raising an alert, all the conditions if empty
+	Conditions []string  `json:"conditions"`
+	CreatedAt  time.Time `json:"createdAt"` // CreatedAt represents when the monitor was registered
+	// LastCheckedAt represents when the URL was last checked, zero if it was never checked
+	LastCheckedAt time.Time `json:"lastCheckedAt"`
+	// LastStatusCode represents the http status code of the last check, 0 if the URL couldn't be loaded
+	LastStatusCode int `json:"lastStatusCode"`
+	// LastError represents why the last check failed, empty if it succeeded
+	LastError string `json:"lastError,omitempty"`
+}
+
+// HasCondition returns whether the condition raises an alert
+func (m *Monitor) HasCondition(condition string) bool {
+	if len(m.Conditions) == 0 {
+		return true
+	}
+	for _, c := range m.Conditions {
+		if c == condition {
+			return true
+		}
+	}
+	return false
+}
+
+// NextCheck returns when the URL is checked next after the time, at the next time of the schedule if the monitor has
+// one, otherwise an interval after the time. It returns an error if the schedule is not a valid cron expression.
+func (m *Monitor) NextCheck(after time.Time) (time.Time, error) {
+	if m.Schedule == "" {
+		return after.Add(m.Interval), nil
+	}
+	// the standard parser accepts the 5 fields expressions and the descriptors, for example: @hourly or @every 15m
+	schedule, err := cron.ParseStandard(m.Schedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+	// the schedule is evaluated in the time zone of the server, the checks being saved in UTC, it returns the zero time
+	// if it never matches, for example: 0 0 30 2 *
+	next := schedule.Next(after.Local())
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("the schedule %q never matches", m.Schedule)
+	}
+	return next, nil
+}
+
+// Reason represents a condition which raised an alert
+type Reason struct {
+	Condition string `json:"condition"` // Condition represents the name of the condition
+	Message   string `json:"message"`   // Message represents what regressed
+}
+
+// Alert represents the regressions found by a check of a monitor
+type Alert struct {
+	MonitorID string    `json:"monitorId"` // MonitorID represents the id of the monitor
+	URL       string    `json:"url"`       // URL represents the URL which was checked
+	CheckedAt time.Time `json:"checkedAt"` // CheckedAt represents when the URL was checked
+	Reasons   []*Reason `json:"reasons"`   // Reasons represents the conditions which raised the alert
+}
+
+// Subject returns a one line description of the alert
+func (a *Alert) Subject() string {
+	return fmt.Sprintf("%v regressed", a.URL)
+}
+
+// String returns the description of the alert with a line per reason
+func (a *Alert) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%v regressed at %v:\n", a.URL, a.CheckedAt.Format(time.RFC3339)))
+	for _, reason := range a.Reasons {
+		sb.WriteString(fmt.Sprintf("- %v: %v\n", reason.Condition, reason.Message))
+	}
+	return sb.String()
+}
@@ -0,0 +1,131 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"web-analyser/config"
+	iHttp "web-analyser/internal/utils/http"
+)
+
+// names of the built-in notifiers
+const (
+	NotifierLog     = "log"
+	NotifierWebhook = "webhook"
+	NotifierSMTP    = "smtp"
+)
+
+// Notifier delivers the alerts raised by the monitors
+type Notifier interface {
+	// Name returns the unique name of the notifier, used to enable it via the config
+	Name() string
+	// Notify delivers the alert
+	Notify(alert *Alert) error
+}
+
+// NewNotifiers returns the notifiers enabled in the config
+func NewNotifiers(conf *config.MonitorConf, logger *zerolog.Logger) ([]Notifier, error) {
+	var notifiers []Notifier
+	for _, name := range conf.Notifiers {
+		switch name {
+		case NotifierLog:
+			notifiers = append(notifiers, NewLogNotifier(logger))
+		case NotifierWebhook:
+			if conf.WebhookURL == "" {
+				return nil, errors.New("the webhook notifier needs a webhook url")
+			}
+			notifiers = append(notifiers, NewWebhookNotifier(&http.Client{Timeout: conf.WebhookTimeout},
+				conf.WebhookURL))
+		case NotifierSMTP:
+			if conf.SMTPFrom == "" || len(conf.SMTPTo) == 0 {
+				return nil, errors.New("the smtp notifier needs a sender and at least a recipient")
+			}
+			notifiers = append(notifiers, NewSMTPNotifier(conf.SMTPAddr, conf.SMTPFrom, conf.SMTPTo))
+		default:
+			return nil, errors.New(fmt.Sprintf("unknown notifier: %v", name))
+		}
+	}
+	return notifiers, nil
+}
+
+// LogNotifier logs the alerts
+type LogNotifier struct {
+	logger *zerolog.Logger
+}
+
+func NewLogNotifier(logger *zerolog.Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+func (n *LogNotifier) Name() string { return NotifierLog }
+
+func (n *LogNotifier) Notify(alert *Alert) error {
+	event := n.logger.Warn().Str("monitor", alert.MonitorID).Str("url", alert.URL)
+	for _, reason := range alert.Reasons {
+		event = event.Str(reason.Condition, reason.Message)
+	}
+	event.Msg("monitor alert")
+	return nil
+}
+
+// WebhookNotifier posts the alerts as JSON to the webhook URL
+type WebhookNotifier struct {
+	client *http.Client
+	url    string
+}
+
+// NewWebhookNotifier creates a new instance of WebhookNotifier, the client is not the client of the analyser since
+// the webhook URL is set by the operator and is usually an internal address
+func NewWebhookNotifier(client *http.Client, url string) *WebhookNotifier {
+	return &WebhookNotifier{client: client, url: url}
+}
+
+func (n *WebhookNotifier) Name() string { return NotifierWebhook }
+
+func (n *WebhookNotifier) Notify(alert *Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	resp, err := n.client.Post(n.url, iHttp.ContentTypeJSON, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.New(fmt.Sprintf("webhook responded with http status code %v", resp.StatusCode))
+	}
+	return nil
+}
+
+// SMTPNotifier emails the alerts through an SMTP relay which doesn't need auth, usually a local relay
+type SMTPNotifier struct {
+	addr string
+	from string
+	to   []string
+}
+
+func NewSMTPNotifier(addr string, from string, to []string) *SMTPNotifier {
+	return &SMTPNotifier{addr: addr, from: from, to: to}
+}
+
+func (n *SMTPNotifier) Name() string { return NotifierSMTP }
+
+func (n *SMTPNotifier) Notify(alert *Alert) error {
+	var msg strings.Builder
+	msg.WriteString("From: " + n.from + "\r\n")
+	msg.WriteString("To: " + strings.Join(n.to, ", ") + "\r\n")
+	msg.WriteString("Subject: " + alert.Subject() + "\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(alert.String(), "\n", "\r\n"))
+
+	return smtp.SendMail(n.addr, nil, n.from, n.to, []byte(msg.String()))
+}
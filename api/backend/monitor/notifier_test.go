@@ -0,0 +1,162 @@
+package monitor_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"web-analyser/api/backend/monitor"
+	"web-analyser/config"
+	l "web-analyser/internal/utils/logger"
+)
+
+var alert = &monitor.Alert{
+	MonitorID: "1",
+	URL:       "https://google.com",
+	CheckedAt: time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC),
+	Reasons:   []*monitor.Reason{{Condition: monitor.ConditionTitle, Message: "the title changed"}},
+}
+
+func TestNewNotifiers(t *testing.T) {
+	tests := []*struct {
+		name          string
+		conf          *config.MonitorConf
+		expectedNames []string
+		expectedError bool
+	}{
+		{
+			name: "Should return the enabled notifiers",
+			conf: &config.MonitorConf{Notifiers: []string{"log", "webhook", "smtp"}, WebhookURL: "http://localhost",
+				SMTPFrom: "monitor@localhost", SMTPTo: []string{"team@localhost"}},
+			expectedNames: []string{"log", "webhook", "smtp"},
+		},
+		{
+			name:          "Should return error for a webhook notifier without url",
+			conf:          &config.MonitorConf{Notifiers: []string{"webhook"}},
+			expectedError: true,
+		},
+		{
+			name:          "Should return error for a smtp notifier without recipients",
+			conf:          &config.MonitorConf{Notifiers: []string{"smtp"}, SMTPFrom: "monitor@localhost"},
+			expectedError: true,
+		},
+		{
+			name:          "Should return error for an unknown notifier",
+			conf:          &config.MonitorConf{Notifiers: []string{"sms"}},
+			expectedError: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			notifiers, err := monitor.NewNotifiers(tc.conf, l.NewLogger(false))
+			if (err != nil) != tc.expectedError {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedError, err)
+			}
+			var names []string
+			for _, notifier := range notifiers {
+				names = append(names, notifier.Name())
+			}
+			if !reflect.DeepEqual(tc.expectedNames, names) {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedNames, names)
+			}
+		})
+	}
+}
+
+func TestWebhookNotifier_Notify(t *testing.T) {
+	tests := []*struct {
+		name          string
+		statusCode    int
+		expectedError bool
+	}{
+		{name: "Should post the alert as JSON", statusCode: http.StatusNoContent},
+		{name: "Should return error if the webhook fails", statusCode: http.StatusInternalServerError,
+			expectedError: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var received monitor.Alert
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewDecoder(r.Body).Decode(&received)
+				w.WriteHeader(tc.statusCode)
+			}))
+			defer server.Close()
+
+			err := monitor.NewWebhookNotifier(server.Client(), server.URL).Notify(alert)
+			if (err != nil) != tc.expectedError {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedError, err)
+			}
+			if !reflect.DeepEqual(alert, &received) {
+				t.Fatalf("Expected:%+v, Got:%+v", alert, &received)
+			}
+		})
+	}
+}
+
+// serveSMTP accepts a single SMTP session on the listener and sends the received message to the channel
+func serveSMTP(listener net.Listener, messages chan<- string) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+	reply("220 localhost")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		switch command := strings.ToUpper(strings.TrimSpace(line)); {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case command == "DATA":
+			reply("354 end with .")
+			var message strings.Builder
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil || line == ".\r\n" {
+					break
+				}
+				message.WriteString(line)
+			}
+			messages <- message.String()
+			reply("250 ok")
+		case command == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func TestSMTPNotifier_Notify(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected:%v, Got:%v", nil, err)
+	}
+	defer listener.Close()
+	messages := make(chan string, 1)
+	go serveSMTP(listener, messages)
+
+	notifier := monitor.NewSMTPNotifier(listener.Addr().String(), "monitor@localhost", []string{"team@localhost"})
+	if err := notifier.Notify(alert); err != nil {
+		t.Fatalf("Expected:%v, Got:%v", nil, err)
+	}
+
+	message := <-messages
+	for _, expected := range []string{"To: team@localhost", "Subject: https://google.com regressed",
+		"- title: the title changed"} {
+		if !strings.Contains(message, expected) {
+			t.Fatalf("Expected:%v, Got:%v", expected, message)
+		}
+	}
+}
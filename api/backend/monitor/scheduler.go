@@ -0,0 +1,268 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog"
+	"math/rand"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"web-analyser/api/backend/analyser"
+	"web-analyser/api/backend/compare"
+	"web-analyser/api/backend/history"
+	"web-analyser/config"
+	iCtx "web-analyser/internal/utils/ctx"
+)
+
+// Scheduler checks the URLs of the monitors at their intervals or schedules
+type Scheduler interface {
+	// Schedule starts checking the URL of the monitor, replacing the schedule of the monitor with the same id
+	Schedule(monitor *Monitor)
+	// Unschedule stops checking the URL of the monitor with the id, it returns once the running check, if any, is done
+	Unschedule(id string)
+}
+
+type SchedulerImpl struct {
+	analyser  analyser.Analyser
+	recorder  analyser.Recorder
+	history   history.Store
+	store     Store
+	notifiers []Notifier
+	conf      *config.MonitorConf
+	logger    *zerolog.Logger
+	now       func() time.Time
+
+	mu   sync.Mutex
+	ctx  context.Context
+	runs map[string]*scheduled
+}
+
+// scheduled represents the checks of a scheduled monitor, done is closed once the checks are stopped
+type scheduled struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewScheduler(analyser analyser.Analyser, recorder analyser.Recorder, history history.Store, store Store,
+	notifiers []Notifier, conf *config.MonitorConf, logger *zerolog.Logger) *SchedulerImpl {
+	return &SchedulerImpl{
+		analyser:  analyser,
+		recorder:  recorder,
+		history:   history,
+		store:     store,
+		notifiers: notifiers,
+		conf:      conf,
+		logger:    logger,
+		now:       time.Now,
+		ctx:       context.Background(),
+		runs:      make(map[string]*scheduled),
+	}
+}
+
+// Start schedules all the stored monitors, the monitors are checked until the context is done
+func (s *SchedulerImpl) Start(ctx context.Context) error {
+	monitors, err := s.store.List()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.ctx = ctx
+	s.mu.Unlock()
+
+	for _, monitor := range monitors {
+		s.Schedule(monitor)
+	}
+	return nil
+}
+
+func (s *SchedulerImpl) Schedule(monitor *Monitor) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r, ok := s.runs[monitor.ID]; ok {
+		r.cancel()
+	}
+	ctx, cancel := context.WithCancel(s.ctx)
+	r := &scheduled{cancel: cancel, done: make(chan struct{})}
+	s.runs[monitor.ID] = r
+	go func() {
+		defer close(r.done)
+		s.run(ctx, monitor.ID, s.delay(monitor))
+	}()
+}
+
+func (s *SchedulerImpl) Unschedule(id string) {
+	s.mu.Lock()
+	r, ok := s.runs[id]
+	delete(s.runs, id)
+	s.mu.Unlock()
+
+	if ok {
+		// the running check is cancelled, waiting for it so that it doesn't save the monitor once it is deleted
+		r.cancel()
+		<-r.done
+	}
+}
+
+// run checks the URL of the monitor after the delay and then at its interval or schedule until the context is done
+func (s *SchedulerImpl) run(ctx context.Context, id string, delay time.Duration) {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		// the monitor is loaded on each check, so that the check starts from the state saved by the previous one
+		monitor, err := s.store.Get(id)
+		if errors.Is(err, ErrNotFound) {
+			return
+		}
+		if err != nil {
+			s.logger.Error().Str("monitor", id).Err(err).Msg("unable to load the monitor")
+			timer.Reset(s.conf.MinInterval)
+			continue
+		}
+
+		s.Check(ctx, monitor)
+		timer.Reset(s.delay(monitor))
+	}
+}
+
+// delay returns the time until the next check of the monitor, the next check after the last one, or after now if the
+// monitor was never checked, along with a random jitter so that the monitors registered together don't check their
+// URLs at the same time. The jitter is a fraction of the time between the next check and the following one, since the
+// checks of a schedule aren't evenly spaced out.
+func (s *SchedulerImpl) delay(monitor *Monitor) time.Duration {
+	now := s.now()
+	last := monitor.LastCheckedAt
+	if last.IsZero() {
+		last = now
+	}
+	next, err := monitor.NextCheck(last)
+	if err != nil {
+		s.logger.Error().Str("monitor", monitor.ID).Err(err).Msg("invalid monitor schedule")
+		return s.conf.MinInterval
+	}
+	following, _ := monitor.NextCheck(next)
+
+	delay := next.Sub(now)
+	if delay < 0 {
+		delay = 0
+	}
+	if maxJitter := int64(float64(following.Sub(next)) * s.conf.Jitter); maxJitter > 0 {
+		delay += time.Duration(rand.Int63n(maxJitter))
+	}
+	return delay
+}
+
+// Check analyses the URL of the monitor, records the analysis in the history and notifies the alert if the analysis
+// regressed compared to the previous analysis of the URL. It returns the alert, nil if nothing regressed. The check
+// is dropped if the context is done before the analysis is finished, since the monitor was unscheduled or the server
+// is shutting down.
+func (s *SchedulerImpl) Check(ctx context.Context, monitor *Monitor) *Alert {
+	u, err := url.Parse(monitor.URL)
+	if err != nil {
+		s.logger.Error().Str("monitor", monitor.ID).Err(err).Msg("invalid monitor URL")
+		return nil
+	}
+
+	// the previous analysis is the latest stored analysis of the URL, analysed either by the monitor or by a user, the
+	// records are listed without their summaries so the summary of the latest one is loaded on its own
+	var previous *analyser.Summary
+	records, err := s.history.List(history.Key(u))
+	if err == nil && len(records) > 0 {
+		var record *history.Record
+		if record, err = s.history.Get(records[0].ID); err == nil {
+			previous = record.Summary
+		}
+	}
+	if err != nil {
+		s.logger.Error().Str("monitor", monitor.ID).Err(err).Msg("unable to load the previous analysis")
+	}
+
+	start := s.now()
+	summary, err, statusCode := s.analyser.Analyse(iCtx.SetRequestID(ctx, "monitor-"+monitor.ID), u)
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err == nil {
+		if err := s.recorder.Record("monitor-"+monitor.ID, summary, statusCode, s.now().Sub(start)); err != nil {
+			s.logger.Error().Str("monitor", monitor.ID).Err(err).Msg("unable to record the analysis")
+		}
+	}
+
+	alert := &Alert{
+		MonitorID: monitor.ID,
+		URL:       monitor.URL,
+		CheckedAt: start.UTC(),
+		Reasons:   evaluate(monitor, previous, summary, err, statusCode),
+	}
+
+	monitor.LastCheckedAt = alert.CheckedAt
+	monitor.LastStatusCode = statusCode
+	monitor.LastError = ""
+	if err != nil {
+		monitor.LastError = err.Error()
+	}
+	// the monitor is only updated, so that a monitor deleted during the check isn't stored again nor alerted
+	if err := s.store.Update(monitor); errors.Is(err, ErrNotFound) {
+		return nil
+	} else if err != nil {
+		s.logger.Error().Str("monitor", monitor.ID).Err(err).Msg("unable to save the monitor")
+	}
+
+	if len(alert.Reasons) == 0 {
+		return nil
+	}
+	for _, notifier := range s.notifiers {
+		if err := notifier.Notify(alert); err != nil {
+			s.logger.Error().Str("monitor", monitor.ID).Str("notifier", notifier.Name()).Err(err).
+				Msg("unable to notify the alert")
+		}
+	}
+	return alert
+}
+
+// evaluate returns the reasons of the enabled conditions which regressed from the previous analysis to the current
+// one, the status regresses only when the previous check of the monitor succeeded so that a failing URL isn't
+// alerted on every check, the other conditions need both analyses
+func evaluate(monitor *Monitor, previous *analyser.Summary, current *analyser.Summary, err error,
+	statusCode int) []*Reason {
+	reasons := []*Reason{}
+	if err != nil {
+		if monitor.HasCondition(ConditionStatus) && monitor.LastError == "" {
+			message := fmt.Sprintf("the page is unreachable: %v", err)
+			if statusCode != 0 {
+				message = fmt.Sprintf("the page responds with http status code %v", statusCode)
+			}
+			reasons = append(reasons, &Reason{Condition: ConditionStatus, Message: message})
+		}
+		return reasons
+	}
+	if previous == nil {
+		return reasons
+	}
+
+	diff := compare.Summaries(previous, current)
+	if monitor.HasCondition(ConditionBrokenLinks) && len(diff.InaccessibleLinks.Added) > 0 {
+		reasons = append(reasons, &Reason{Condition: ConditionBrokenLinks, Message: fmt.Sprintf(
+			"%v new broken links: %v", len(diff.InaccessibleLinks.Added),
+			strings.Join(diff.InaccessibleLinks.Added, ", "))})
+	}
+	if monitor.HasCondition(ConditionLoginForm) && diff.HasLoginForm != nil && diff.HasLoginForm.From {
+		reasons = append(reasons, &Reason{Condition: ConditionLoginForm, Message: "the login form was removed"})
+	}
+	if monitor.HasCondition(ConditionTitle) && diff.Title != nil {
+		reasons = append(reasons, &Reason{Condition: ConditionTitle, Message: fmt.Sprintf(
+			"the title changed from %q to %q", diff.Title.From, diff.Title.To)})
+	}
+	return reasons
+}
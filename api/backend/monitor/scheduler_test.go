@@ -0,0 +1,291 @@
+package monitor_test
+
+import (
+	"context"
+	"errors"
+	"go.uber.org/mock/gomock"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+	"web-analyser/api/backend/analyser"
+	"web-analyser/api/backend/history"
+	"web-analyser/api/backend/monitor"
+	"web-analyser/config"
+	l "web-analyser/internal/utils/logger"
+	"web-analyser/mocks"
+)
+
+// newSummary returns the summary of https://google.com with the title, login form and inaccessible links
+func newSummary(title string, hasLoginForm bool, inaccessibleLinks ...string) *analyser.Summary {
+	u, _ := url.Parse("https://google.com")
+	summary := analyser.NewSummary(u)
+	summary.SetTitle(title)
+	summary.SetHasLoginForm(hasLoginForm)
+	for _, link := range inaccessibleLinks {
+		summary.AddInaccessibleLink(link)
+	}
+	return summary
+}
+
+func TestSchedulerImpl_Check(t *testing.T) {
+	tests := []*struct {
+		name              string
+		monitor           *monitor.Monitor
+		previous          *analyser.Summary
+		setupExpectations func(*mocks.MockAnalyser)
+		expectedReasons   []*monitor.Reason
+		expectedRecords   int
+	}{
+		{
+			name:     "Should not alert on the first analysis of the url",
+			monitor:  &monitor.Monitor{ID: "1", URL: "https://google.com"},
+			previous: nil,
+			setupExpectations: func(mockAnalyser *mocks.MockAnalyser) {
+				mockAnalyser.EXPECT().Analyse(gomock.Any(), gomock.Any()).Return(newSummary("New", false), nil, 200)
+			},
+			expectedRecords: 1,
+		},
+		{
+			name:     "Should alert the conditions which regressed from the previous analysis",
+			monitor:  &monitor.Monitor{ID: "1", URL: "https://google.com"},
+			previous: newSummary("Old", true),
+			setupExpectations: func(mockAnalyser *mocks.MockAnalyser) {
+				mockAnalyser.EXPECT().Analyse(gomock.Any(), gomock.Any()).Return(newSummary("New", false,
+					"https://google.com/a"), nil, 200)
+			},
+			expectedReasons: []*monitor.Reason{
+				{Condition: monitor.ConditionBrokenLinks, Message: "1 new broken links: https://google.com/a"},
+				{Condition: monitor.ConditionLoginForm, Message: "the login form was removed"},
+				{Condition: monitor.ConditionTitle, Message: `the title changed from "Old" to "New"`},
+			},
+			expectedRecords: 2,
+		},
+		{
+			name:     "Should alert only the enabled conditions",
+			monitor:  &monitor.Monitor{ID: "1", URL: "https://google.com", Conditions: []string{"title"}},
+			previous: newSummary("Old", true),
+			setupExpectations: func(mockAnalyser *mocks.MockAnalyser) {
+				mockAnalyser.EXPECT().Analyse(gomock.Any(), gomock.Any()).Return(newSummary("New", false), nil, 200)
+			},
+			expectedReasons: []*monitor.Reason{
+				{Condition: monitor.ConditionTitle, Message: `the title changed from "Old" to "New"`},
+			},
+			expectedRecords: 2,
+		},
+		{
+			name:     "Should alert when the page no longer responds with 200",
+			monitor:  &monitor.Monitor{ID: "1", URL: "https://google.com", LastStatusCode: 200},
+			previous: newSummary("Old", true),
+			setupExpectations: func(mockAnalyser *mocks.MockAnalyser) {
+				mockAnalyser.EXPECT().Analyse(gomock.Any(), gomock.Any()).Return(nil, errors.New("error"), 404)
+			},
+			expectedReasons: []*monitor.Reason{
+				{Condition: monitor.ConditionStatus, Message: "the page responds with http status code 404"},
+			},
+			expectedRecords: 1,
+		},
+		{
+			name:     "Should not alert again while the page keeps failing",
+			monitor:  &monitor.Monitor{ID: "1", URL: "https://google.com", LastError: "error"},
+			previous: newSummary("Old", true),
+			setupExpectations: func(mockAnalyser *mocks.MockAnalyser) {
+				mockAnalyser.EXPECT().Analyse(gomock.Any(), gomock.Any()).Return(nil, errors.New("error"), 0)
+			},
+			expectedRecords: 1,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockAnalyser := mocks.NewMockAnalyser(ctrl)
+			mockNotifier := mocks.NewMockNotifier(ctrl)
+			tc.setupExpectations(mockAnalyser)
+			if tc.expectedReasons != nil {
+				mockNotifier.EXPECT().Notify(gomock.Any()).Return(nil)
+			}
+
+			historyStore := history.NewMemoryStore(&config.HistoryConf{})
+			recorder := history.NewRecorder(historyStore)
+			if tc.previous != nil {
+				_ = recorder.Record("", tc.previous, 200, time.Second)
+			}
+			store, _ := monitor.NewFileStore(filepath.Join(t.TempDir(), "monitors.json"))
+			_ = store.Save(tc.monitor)
+
+			s := monitor.NewScheduler(mockAnalyser, recorder, historyStore, store,
+				[]monitor.Notifier{mockNotifier}, &config.MonitorConf{}, l.NewLogger(false))
+			alert := s.Check(context.Background(), tc.monitor)
+
+			if tc.expectedReasons == nil && alert != nil {
+				t.Fatalf("Expected:%v, Got:%+v", nil, alert)
+			}
+			if tc.expectedReasons != nil && (alert == nil || !reflect.DeepEqual(tc.expectedReasons, alert.Reasons)) {
+				t.Fatalf("Expected:%+v, Got:%+v", tc.expectedReasons, alert)
+			}
+
+			records, _ := historyStore.List("https://google.com/")
+			if len(records) != tc.expectedRecords {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedRecords, len(records))
+			}
+			saved, _ := store.Get(tc.monitor.ID)
+			if saved.LastCheckedAt.IsZero() {
+				t.Fatalf("Expected:%v, Got:%v", "the last check time", saved.LastCheckedAt)
+			}
+		})
+	}
+}
+
+func TestSchedulerImpl_Check_Cancelled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// the analysis is cancelled by the unschedule or the shutdown, it is not a regression of the url
+	ctx, cancel := context.WithCancel(context.Background())
+	mockAnalyser := mocks.NewMockAnalyser(ctrl)
+	mockAnalyser.EXPECT().Analyse(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(context.Context, *url.URL) (*analyser.Summary, error, int) {
+			cancel()
+			return nil, context.Canceled, 0
+		})
+
+	historyStore := history.NewMemoryStore(&config.HistoryConf{})
+	store, _ := monitor.NewFileStore(filepath.Join(t.TempDir(), "monitors.json"))
+	m := &monitor.Monitor{ID: "1", URL: "https://google.com", LastStatusCode: 200}
+	_ = store.Save(m)
+
+	s := monitor.NewScheduler(mockAnalyser, history.NewRecorder(historyStore), historyStore, store,
+		[]monitor.Notifier{mocks.NewMockNotifier(ctrl)}, &config.MonitorConf{}, l.NewLogger(false))
+	if alert := s.Check(ctx, m); alert != nil {
+		t.Fatalf("Expected:%v, Got:%+v", nil, alert)
+	}
+	if saved, _ := store.Get(m.ID); !saved.LastCheckedAt.IsZero() {
+		t.Fatalf("Expected:%v, Got:%v", "no check saved", saved.LastCheckedAt)
+	}
+}
+
+func TestSchedulerImpl_Check_Deleted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	path := filepath.Join(t.TempDir(), "monitors.json")
+	store, _ := monitor.NewFileStore(path)
+	m := &monitor.Monitor{ID: "1", URL: "https://google.com", LastStatusCode: 200}
+	_ = store.Save(m)
+
+	// the monitor is deleted while its URL is analysed
+	mockAnalyser := mocks.NewMockAnalyser(ctrl)
+	mockAnalyser.EXPECT().Analyse(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(context.Context, *url.URL) (*analyser.Summary, error, int) {
+			_ = store.Delete(m.ID)
+			return nil, errors.New("error"), 500
+		})
+
+	historyStore := history.NewMemoryStore(&config.HistoryConf{})
+	s := monitor.NewScheduler(mockAnalyser, history.NewRecorder(historyStore), historyStore, store,
+		[]monitor.Notifier{mocks.NewMockNotifier(ctrl)}, &config.MonitorConf{}, l.NewLogger(false))
+	if alert := s.Check(context.Background(), m); alert != nil {
+		t.Fatalf("Expected:%v, Got:%+v", nil, alert)
+	}
+	reloaded, _ := monitor.NewFileStore(path)
+	if _, err := reloaded.Get(m.ID); !errors.Is(err, monitor.ErrNotFound) {
+		t.Fatalf("Expected:%v, Got:%v", monitor.ErrNotFound, err)
+	}
+}
+
+func TestSchedulerImpl_Schedule(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAnalyser := mocks.NewMockAnalyser(ctrl)
+	mockNotifier := mocks.NewMockNotifier(ctrl)
+	checked := make(chan struct{}, 10)
+	mockAnalyser.EXPECT().Analyse(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, u *url.URL) (*analyser.Summary, error, int) {
+			checked <- struct{}{}
+			return nil, errors.New("error"), 500
+		}).MinTimes(2)
+	mockNotifier.EXPECT().Notify(gomock.Any()).Return(nil).AnyTimes()
+
+	historyStore := history.NewMemoryStore(&config.HistoryConf{})
+	store, _ := monitor.NewFileStore(filepath.Join(t.TempDir(), "monitors.json"))
+	m := &monitor.Monitor{ID: "1", URL: "https://google.com", Interval: 10 * time.Millisecond}
+	_ = store.Save(m)
+
+	s := monitor.NewScheduler(mockAnalyser, history.NewRecorder(historyStore), historyStore, store,
+		[]monitor.Notifier{mockNotifier}, &config.MonitorConf{Jitter: 0.5}, l.NewLogger(false))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Expected:%v, Got:%v", nil, err)
+	}
+
+	// the stored monitor is checked at its interval until it is unscheduled
+	for i := 0; i < 2; i++ {
+		select {
+		case <-checked:
+		case <-time.After(time.Second):
+			t.Fatalf("Expected:%v, Got:%v", "the url to be checked", "no check")
+		}
+	}
+	s.Unschedule(m.ID)
+	time.Sleep(50 * time.Millisecond)
+	for len(checked) > 0 {
+		<-checked
+	}
+	time.Sleep(50 * time.Millisecond)
+	if len(checked) != 0 {
+		t.Fatalf("Expected:%v, Got:%v", 0, len(checked))
+	}
+}
+
+func TestMonitor_NextCheck(t *testing.T) {
+	// the schedules are evaluated in the time zone of the server
+	after := time.Date(2024, 6, 14, 17, 20, 0, 0, time.Local)
+	tests := []*struct {
+		name          string
+		monitor       *monitor.Monitor
+		expectedNext  time.Time
+		expectedError bool
+	}{
+		{
+			name:         "Should check an interval later",
+			monitor:      &monitor.Monitor{Interval: time.Hour},
+			expectedNext: after.Add(time.Hour),
+		},
+		{
+			name:         "Should check at the next time of the cron schedule",
+			monitor:      &monitor.Monitor{Schedule: "0 9 * * 1-5"},
+			expectedNext: time.Date(2024, 6, 17, 9, 0, 0, 0, time.Local),
+		},
+		{
+			name:         "Should check at the next time of the descriptor",
+			monitor:      &monitor.Monitor{Schedule: "@hourly"},
+			expectedNext: time.Date(2024, 6, 14, 18, 0, 0, 0, time.Local),
+		},
+		{
+			name:          "Should return an error for an invalid cron expression",
+			monitor:       &monitor.Monitor{Schedule: "0 9 * *"},
+			expectedError: true,
+		},
+		{
+			name:          "Should return an error for a schedule which never matches",
+			monitor:       &monitor.Monitor{Schedule: "0 0 30 2 *"},
+			expectedError: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			next, err := tc.monitor.NextCheck(after)
+			if (err != nil) != tc.expectedError {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedError, err)
+			}
+			if !next.Equal(tc.expectedNext) {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedNext, next)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package monitor
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// ErrNotFound is returned when there is no monitor with the id
+var ErrNotFound = errors.New("monitor not found")
+
+// Store stores the registered monitors
+type Store interface {
+	// Save stores the monitor, replacing the monitor with the same id
+	Save(monitor *Monitor) error
+	// Update replaces the stored monitor with the same id, ErrNotFound if there is none, so that a monitor deleted
+	// meanwhile isn't stored again
+	Update(monitor *Monitor) error
+	// List returns all the monitors, oldest first
+	List() ([]*Monitor, error)
+	// Get returns the monitor with the id, ErrNotFound if there is none
+	Get(id string) (*Monitor, error)
+	// Delete removes the monitor with the id, ErrNotFound if there is none
+	Delete(id string) error
+}
+
+// FileStore keeps the monitors in memory and writes all of them to a single JSON file on each change, so that they
+// survive restarts
+type FileStore struct {
+	mu       sync.RWMutex
+	path     string
+	monitors map[string]*Monitor
+}
+
+// NewFileStore creates a new instance of FileStore loading the monitors of the file, the file and its directory are
+// created on the first change if they don't exist
+func NewFileStore(path string) (*FileStore, error) {
+	s := &FileStore{
+		path:     path,
+		monitors: make(map[string]*Monitor),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var monitors []*Monitor
+	if err := json.Unmarshal(data, &monitors); err != nil {
+		return nil, err
+	}
+	for _, monitor := range monitors {
+		s.monitors[monitor.ID] = monitor
+	}
+	return s, nil
+}
+
+func (s *FileStore) Save(monitor *Monitor) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// a copy is stored, so that the callers can't change the stored monitor without saving it
+	c := *monitor
+	previous, ok := s.monitors[monitor.ID]
+	s.monitors[monitor.ID] = &c
+	if err := s.write(); err != nil {
+		// the file is left unchanged, so is the stored monitor
+		if ok {
+			s.monitors[monitor.ID] = previous
+		} else {
+			delete(s.monitors, monitor.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *FileStore) Update(monitor *Monitor) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, ok := s.monitors[monitor.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	c := *monitor
+	s.monitors[monitor.ID] = &c
+	if err := s.write(); err != nil {
+		s.monitors[monitor.ID] = previous
+		return err
+	}
+	return nil
+}
+
+func (s *FileStore) List() ([]*Monitor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.sorted(), nil
+}
+
+func (s *FileStore) Get(id string) (*Monitor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	monitor, ok := s.monitors[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	c := *monitor
+	return &c, nil
+}
+
+func (s *FileStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	monitor, ok := s.monitors[id]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(s.monitors, id)
+	if err := s.write(); err != nil {
+		s.monitors[id] = monitor
+		return err
+	}
+	return nil
+}
+
+// sorted returns copies of the monitors, oldest first
+func (s *FileStore) sorted() []*Monitor {
+	monitors := make([]*Monitor, 0, len(s.monitors))
+	for _, monitor := range s.monitors {
+		c := *monitor
+		monitors = append(monitors, &c)
+	}
+	sort.SliceStable(monitors, func(i, j int) bool {
+		if monitors[i].CreatedAt.Equal(monitors[j].CreatedAt) {
+			return monitors[i].ID < monitors[j].ID
+		}
+		return monitors[i].CreatedAt.Before(monitors[j].CreatedAt)
+	})
+	return monitors
+}
+
+// write writes all the monitors to the file
+func (s *FileStore) write() error {
+	data, err := json.MarshalIndent(s.sorted(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	// write to a temporary file first, so that a partially written file is never read
+	tmp, err := os.CreateTemp(dir, filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
@@ -0,0 +1,73 @@
+package monitor_test
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+	"web-analyser/api/backend/monitor"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "monitors", "monitors.json")
+	store, err := monitor.NewFileStore(path)
+	if err != nil {
+		t.Fatalf("Expected:%v, Got:%v", nil, err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	first := &monitor.Monitor{ID: "1", URL: "https://google.com", Interval: time.Hour, CreatedAt: now.Add(-time.Hour)}
+	second := &monitor.Monitor{ID: "2", URL: "https://facebook.com", Interval: time.Minute, CreatedAt: now,
+		Conditions: []string{monitor.ConditionTitle}}
+	for _, m := range []*monitor.Monitor{second, first} {
+		if err := store.Save(m); err != nil {
+			t.Fatalf("Expected:%v, Got:%v", nil, err)
+		}
+	}
+
+	// changing the monitor doesn't change the stored monitor until it is saved
+	first.LastStatusCode = 200
+	if m, _ := store.Get(first.ID); m.LastStatusCode != 0 {
+		t.Fatalf("Expected:%v, Got:%v", 0, m.LastStatusCode)
+	}
+	if err := store.Save(first); err != nil {
+		t.Fatalf("Expected:%v, Got:%v", nil, err)
+	}
+
+	// the monitors survive a restart
+	store, err = monitor.NewFileStore(path)
+	if err != nil {
+		t.Fatalf("Expected:%v, Got:%v", nil, err)
+	}
+	monitors, err := store.List()
+	expected := []*monitor.Monitor{first, second}
+	if err != nil || !reflect.DeepEqual(expected, monitors) {
+		t.Fatalf("Expected:%+v, Got:%+v %v", expected, monitors, err)
+	}
+
+	if err := store.Delete(first.ID); err != nil {
+		t.Fatalf("Expected:%v, Got:%v", nil, err)
+	}
+	if _, err := store.Get(first.ID); !errors.Is(err, monitor.ErrNotFound) {
+		t.Fatalf("Expected:%v, Got:%v", monitor.ErrNotFound, err)
+	}
+	if err := store.Delete(first.ID); !errors.Is(err, monitor.ErrNotFound) {
+		t.Fatalf("Expected:%v, Got:%v", monitor.ErrNotFound, err)
+	}
+
+	// only the stored monitors are updated, a deleted monitor isn't stored again
+	second.LastStatusCode = 404
+	if err := store.Update(second); err != nil {
+		t.Fatalf("Expected:%v, Got:%v", nil, err)
+	}
+	if m, _ := store.Get(second.ID); m.LastStatusCode != 404 {
+		t.Fatalf("Expected:%v, Got:%v", 404, m.LastStatusCode)
+	}
+	if err := store.Update(first); !errors.Is(err, monitor.ErrNotFound) {
+		t.Fatalf("Expected:%v, Got:%v", monitor.ErrNotFound, err)
+	}
+	if _, err := store.Get(first.ID); !errors.Is(err, monitor.ErrNotFound) {
+		t.Fatalf("Expected:%v, Got:%v", monitor.ErrNotFound, err)
+	}
+}
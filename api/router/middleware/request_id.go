@@ -4,9 +4,10 @@ import (
 	"github.com/google/uuid"
 	"net/http"
 	iCtx "web-analyser/internal/utils/ctx"
+	iHttp "web-analyser/internal/utils/http"
 )
 
-const RequestIDHeaderKey = "X-Request-ID"
+const RequestIDHeaderKey = iHttp.HeaderRequestID
 
 // RequestID gets the request id passed in request header, generates a new one if not present
 // and sets it in the ctx to be used by other libraries and services
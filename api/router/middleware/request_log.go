@@ -3,9 +3,13 @@ package middleware
 import (
 	"github.com/rs/zerolog"
 	"net/http"
+	"net/url"
 	iCtx "web-analyser/internal/utils/ctx"
 )
 
+// secretParams are the query params holding credentials, their values are redacted from the request log
+var secretParams = []string{"password", "token"}
+
 type RequestLog struct {
 	handler http.Handler
 	logger  *zerolog.Logger
@@ -24,7 +28,25 @@ func (h *RequestLog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.logger.Debug().
 		Str("request id", iCtx.RequestID(r.Context())).
 		Str("method", r.Method).
-		Str("request url", r.URL.String()).
+		Str("request url", redactURL(r.URL)).
 		Msg("request log")
 	h.handler.ServeHTTP(w, r)
 }
+
+// redactURL returns the url with the values of the secret query params redacted
+func redactURL(u *url.URL) string {
+	query := u.Query()
+	redacted := false
+	for _, param := range secretParams {
+		if query.Has(param) {
+			query.Set(param, "[redacted]")
+			redacted = true
+		}
+	}
+	if !redacted {
+		return u.String()
+	}
+	c := *u
+	c.RawQuery = query.Encode()
+	return c.String()
+}
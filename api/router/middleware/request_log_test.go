@@ -0,0 +1,43 @@
+package middleware_test
+
+import (
+	"bytes"
+	"github.com/rs/zerolog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"web-analyser/api/router/middleware"
+)
+
+func TestRequestLog(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		expectedURL string
+	}{
+		{
+			name:        "Should log the url of the request",
+			target:      "/summary?url=google.com",
+			expectedURL: "/summary?url=google.com",
+		},
+		{
+			name:        "Should redact the credentials of the url",
+			target:      "/summary?password=pass&token=token&url=google.com",
+			expectedURL: "/summary?password=%5Bredacted%5D&token=%5Bredacted%5D&url=google.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := zerolog.New(&buf)
+			r := httptest.NewRequest(http.MethodPost, tt.target, nil)
+			middleware.NewRequestLog(func(http.ResponseWriter, *http.Request) {}, &logger).
+				ServeHTTP(httptest.NewRecorder(), r)
+
+			if !strings.Contains(buf.String(), `"request url":"`+tt.expectedURL+`"`) {
+				t.Fatalf("Expected:%v, Got:%v", tt.expectedURL, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+	iHttp "web-analyser/internal/utils/http"
+)
+
+// RetryDeadline returns a middleware setting the retry deadline of each request to the budget after it starts, so
+// that the pages analysed while the user waits are retried only while the response can still be written, see
+// iHttp.WithRetryDeadline. The jobs, batches and monitors don't run within the requests, so they aren't limited by it.
+func RetryDeadline(budget time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := iHttp.WithRetryDeadline(r.Context(), time.Now().Add(budget))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
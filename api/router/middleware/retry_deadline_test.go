@@ -0,0 +1,62 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+	"web-analyser/api/router/middleware"
+	"web-analyser/config"
+	iHttp "web-analyser/internal/utils/http"
+)
+
+func TestRetryDeadline(t *testing.T) {
+	tests := []struct {
+		name             string
+		budget           time.Duration
+		expectedAttempts int32
+	}{
+		{
+			name:             "Should retry the page while the retry can end within the budget of the request",
+			budget:           time.Minute,
+			expectedAttempts: 2,
+		},
+		{
+			name:             "Should not retry the page once the budget of the request is spent",
+			budget:           time.Nanosecond,
+			expectedAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the page fails once with a transient error
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if attempts.Add(1) == 1 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			}))
+			defer server.Close()
+
+			conf := &config.ClientConf{AllowedAddresses: []string{"127.0.0.1"}, Timeout: time.Second,
+				RetryMaxAttempts: 3, RetryBaseDelay: time.Millisecond, RetryMaxDelay: time.Millisecond}
+			impl, _ := iHttp.NewHttpClient(conf)
+			client := iHttp.NewRetryClient(impl, conf)
+
+			r, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, "/", nil)
+			w := httptest.NewRecorder()
+			middleware.RetryDeadline(tt.budget)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if resp, err := client.Get(r.Context(), server.URL); err == nil {
+					resp.Body.Close()
+				}
+			})).ServeHTTP(w, r)
+
+			if attempts.Load() != tt.expectedAttempts {
+				t.Fatalf("Expected:%v, Got:%v", tt.expectedAttempts, attempts.Load())
+			}
+		})
+	}
+}
@@ -5,12 +5,18 @@ import (
 	"github.com/rs/zerolog"
 	"net/http"
 	"web-analyser/api/backend/analyser"
+	"web-analyser/api/backend/batch"
+	"web-analyser/api/backend/compare"
 	"web-analyser/api/backend/health"
+	"web-analyser/api/backend/history"
+	"web-analyser/api/backend/job"
+	"web-analyser/api/backend/monitor"
 	middleware "web-analyser/api/router/middleware"
 )
 
 // New sets the routes using chi.Mux pkg
-func New(l *zerolog.Logger, h analyser.Handler) *chi.Mux {
+func New(l *zerolog.Logger, h analyser.Handler, hh history.Handler, cmp compare.Handler, mh monitor.Handler,
+	jh job.Handler, bh batch.Handler) *chi.Mux {
 	r := chi.NewRouter()
 	// using RequestID middleware to set request id in ctx
 	r.Use(middleware.RequestID)
@@ -21,6 +27,37 @@ func New(l *zerolog.Logger, h analyser.Handler) *chi.Mux {
 	// using NewRequestLog middleware to log important fields
 	r.Method(http.MethodGet, "/", middleware.NewRequestLog(h.Index, l))
 	r.Method(http.MethodPost, "/summary", middleware.NewRequestLog(h.Summary, l))
+	r.Method(http.MethodPost, "/crawl", middleware.NewRequestLog(jh.SubmitCrawl, l))
+	r.Method(http.MethodGet, "/history", middleware.NewRequestLog(hh.ListPage, l))
+	r.Method(http.MethodGet, "/history/{id}", middleware.NewRequestLog(hh.ShowPage, l))
+	r.Method(http.MethodGet, "/compare", middleware.NewRequestLog(cmp.Page, l))
+	r.Method(http.MethodPost, "/jobs", middleware.NewRequestLog(jh.Submit, l))
+	r.Method(http.MethodGet, "/jobs/{id}", middleware.NewRequestLog(jh.Page, l))
+	r.Method(http.MethodGet, "/jobs/{id}/events", middleware.NewRequestLog(jh.Events, l))
+	r.Method(http.MethodPost, "/batch", middleware.NewRequestLog(bh.Submit, l))
+	r.Method(http.MethodGet, "/batch/{id}", middleware.NewRequestLog(bh.Page, l))
+
+	// setting routes for the versioned JSON api
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Method(http.MethodGet, "/analyses", middleware.NewRequestLog(h.Analysis, l))
+		r.Method(http.MethodPost, "/analyses", middleware.NewRequestLog(h.Analysis, l))
+		r.Method(http.MethodGet, "/crawls", middleware.NewRequestLog(jh.CreateCrawl, l))
+		r.Method(http.MethodPost, "/crawls", middleware.NewRequestLog(jh.CreateCrawl, l))
+		r.Method(http.MethodGet, "/history", middleware.NewRequestLog(hh.List, l))
+		r.Method(http.MethodGet, "/history/{id}", middleware.NewRequestLog(hh.Show, l))
+		r.Method(http.MethodDelete, "/history/{id}", middleware.NewRequestLog(hh.Delete, l))
+		r.Method(http.MethodGet, "/comparisons", middleware.NewRequestLog(cmp.Compare, l))
+		r.Method(http.MethodPost, "/comparisons", middleware.NewRequestLog(cmp.Compare, l))
+		r.Method(http.MethodGet, "/monitors", middleware.NewRequestLog(mh.List, l))
+		r.Method(http.MethodPost, "/monitors", middleware.NewRequestLog(mh.Register, l))
+		r.Method(http.MethodGet, "/monitors/{id}", middleware.NewRequestLog(mh.Show, l))
+		r.Method(http.MethodDelete, "/monitors/{id}", middleware.NewRequestLog(mh.Delete, l))
+		r.Method(http.MethodPost, "/jobs", middleware.NewRequestLog(jh.Create, l))
+		r.Method(http.MethodGet, "/jobs/{id}", middleware.NewRequestLog(jh.Show, l))
+		r.Method(http.MethodGet, "/jobs/{id}/events", middleware.NewRequestLog(jh.Events, l))
+		r.Method(http.MethodPost, "/batches", middleware.NewRequestLog(bh.Create, l))
+		r.Method(http.MethodGet, "/batches/{id}", middleware.NewRequestLog(bh.Show, l))
+	})
 
 	// redirecting 404, 405 http response to index page for smooth UX,
 	// not recommended for production environment
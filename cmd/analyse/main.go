@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"github.com/joho/godotenv"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"web-analyser/api/backend/analyser"
+	"web-analyser/config"
+	iError "web-analyser/internal/utils/error"
+	iHttp "web-analyser/internal/utils/http"
+)
+
+// exit codes of the command, a failure to analyse a url takes precedence over too many inaccessible links
+const (
+	exitOK                 = 0
+	exitUsage              = 1
+	exitAnalysisFailed     = 2
+	exitTooManyBrokenLinks = 3
+)
+
+const (
+	disabledInaccessibleLimit = -1
+	defaultEnvFile            = ".env"
+	usage                     = "Usage: analyse [flags] [url ...]\n\nAnalyses the given urls, the urls are read " +
+		"from stdin, one per line, when no url is given.\n\nFlags:\n"
+)
+
+func main() {
+	// an interrupt cancels the analysis in progress and skips the remaining urls
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	exitCode := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(exitCode)
+}
+
+// run parses the flags, analyses the urls and writes the results, it returns the exit code of the command. The urls
+// are no longer analysed once the context is done.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("analyse", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		flags.PrintDefaults()
+	}
+
+	// the env file is optional, so the flag is looked up before parsing the rest of the flags since
+	// the values in the env file are the defaults of the other flags
+	envFile := defaultEnvFile
+	for i, arg := range args {
+		if value, ok := strings.CutPrefix(arg, "-env="); ok {
+			envFile = value
+		} else if (arg == "-env" || arg == "--env") && i+1 < len(args) {
+			envFile = args[i+1]
+		}
+	}
+	if err := godotenv.Load(envFile); err != nil && envFile != defaultEnvFile {
+		fmt.Fprintf(stderr, "unable to load env file %v: %v\n", envFile, err)
+		return exitUsage
+	}
+
+	// initialising the config, the flags override the values of the environment variables
+	conf := config.New()
+	flags.String("env", defaultEnvFile, "env file to load the configuration from, it is optional")
+	format := flags.String("format", formatTable, "output format: table, json or ndjson")
+	maxInaccessibleLinks := flags.Int("max-inaccessible-links", disabledInaccessibleLimit,
+		"fail if a page has more inaccessible links than this, -1 disables the check")
+	flags.DurationVar(&conf.Client.Timeout, "timeout", conf.Client.Timeout, "timeout to fetch the page")
+	flags.DurationVar(&conf.Client.LinkCheckTimeout, "link-check-timeout", conf.Client.LinkCheckTimeout,
+		"timeout to load each link")
+	flags.DurationVar(&conf.Client.LinkCheckDeadline, "link-check-deadline", conf.Client.LinkCheckDeadline,
+		"deadline to load all the links of a page")
+	flags.IntVar(&conf.Client.LinkCheckMaxLinks, "link-check-max-links", conf.Client.LinkCheckMaxLinks,
+		"max number of links to load per page")
+	flags.IntVar(&conf.Client.LinkCheckConcurrency, "link-check-concurrency", conf.Client.LinkCheckConcurrency,
+		"max number of links to load concurrently")
+	flags.IntVar(&conf.Client.LinkCheckPerHost, "link-check-per-host", conf.Client.LinkCheckPerHost,
+		"max number of links to load concurrently from the same host")
+	flags.StringVar(&conf.Client.UserAgent, "user-agent", conf.Client.UserAgent,
+		"User-Agent header of the requests")
+	flags.StringVar(&conf.Client.ProxyURL, "proxy", conf.Client.ProxyURL,
+		"http, https or socks5 proxy to send the requests through")
+	flags.Var(&addressesFlag{addresses: &conf.Client.AllowedAddresses}, "allow",
+		"hosts, IPs and CIDRs, separated by , or ;, which can be loaded even if they are internal, e.g. 127.0.0.1")
+	flags.Var(&addressesFlag{addresses: &conf.Client.DeniedAddresses}, "deny",
+		"hosts, IPs and CIDRs, separated by , or ;, which are never loaded")
+	flags.StringVar(&conf.Client.CAFile, "ca-file", conf.Client.CAFile,
+		"PEM file of the CA certificates to trust on top of the system ones")
+	flags.BoolVar(&conf.Client.InsecureSkipVerify, "insecure-skip-verify", conf.Client.InsecureSkipVerify,
+		"skip the verification of the certificates")
+	flags.IntVar(&conf.Client.RetryMaxAttempts, "retry-max-attempts", conf.Client.RetryMaxAttempts,
+		"max number of attempts to fetch the page on the transient errors, 1 disables the retries")
+	flags.DurationVar(&conf.Client.RetryBudget, "retry-budget", conf.Client.RetryBudget,
+		"max total time of the attempts to fetch the page, 0 disables the budget")
+	flags.IntVar(&conf.Analyser.MaxRedirects, "max-redirects", conf.Analyser.MaxRedirects,
+		"max number of redirects to follow while loading the page")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	output, err := newOutput(*format, stdout)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	urls := flags.Args()
+	if len(urls) == 0 {
+		if urls, err = readURLs(stdin); err != nil {
+			fmt.Fprintf(stderr, "unable to read urls from stdin: %v\n", err)
+			return exitUsage
+		}
+	}
+	if len(urls) == 0 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	if _, err := iHttp.ParseSameSitePolicy(conf.Analyser.SameSitePolicy); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	// setup required objects
+	httpClient, err := iHttp.NewHttpClient(&conf.Client)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	noRedirectHttpClient, err := iHttp.NewNoRedirectHttpClient(&conf.Client)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	linkChecker := analyser.NewLinkChecker(httpClient, &conf.Client)
+	// the page is retried on the transient errors, while the links are checked once within the link check deadline
+	a := analyser.NewAnalyser(iHttp.NewRetryClient(noRedirectHttpClient, &conf.Client), linkChecker, &conf.Analyser)
+
+	exitCode := exitOK
+	for _, url := range urls {
+		if ctx.Err() != nil {
+			break
+		}
+		res := analyse(ctx, a, url)
+		if err := output.write(res); err != nil {
+			fmt.Fprintf(stderr, "unable to write the result: %v\n", err)
+			return exitUsage
+		}
+
+		switch {
+		case res.Error != nil:
+			fmt.Fprintf(stderr, "%v: %v\n", url, res.Error.Message)
+			exitCode = exitAnalysisFailed
+		case *maxInaccessibleLinks != disabledInaccessibleLimit &&
+			len(res.Summary.InaccessibleLinksMap) > *maxInaccessibleLinks && exitCode == exitOK:
+			exitCode = exitTooManyBrokenLinks
+		}
+	}
+
+	if err := output.close(); err != nil {
+		fmt.Fprintf(stderr, "unable to write the result: %v\n", err)
+		return exitUsage
+	}
+	return exitCode
+}
+
+// analyse validates and analyses the url, the same way the summary page does
+func analyse(ctx context.Context, a analyser.Analyser, url string) *result {
+	parsedUrl, err := iHttp.ValidateURL(url)
+	if err != nil {
+		return &result{URL: url, Error: &iError.CustomError{Message: err.Error()}}
+	}
+
+	summary, err, statusCode := a.Analyse(ctx, parsedUrl)
+	if err != nil {
+		_, customError := analyser.AnalysisError(err, statusCode)
+		return &result{URL: url, Error: customError}
+	}
+	return &result{URL: url, Summary: summary}
+}
+
+// addressesFlag is a list of hosts, IPs and CIDRs separated by , or ;. The flag can be repeated, the addresses of
+// the flags replace the ones of the environment variable.
+type addressesFlag struct {
+	addresses *[]string
+	set       bool
+}
+
+func (f *addressesFlag) String() string {
+	if f.addresses == nil {
+		return ""
+	}
+	return strings.Join(*f.addresses, ";")
+}
+
+func (f *addressesFlag) Set(value string) error {
+	if !f.set {
+		*f.addresses = nil
+		f.set = true
+	}
+	for _, address := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' }) {
+		if address = strings.TrimSpace(address); address != "" {
+			*f.addresses = append(*f.addresses, address)
+		}
+	}
+	return nil
+}
+
+// readURLs reads the urls from the reader, one per line, ignoring the empty lines and the lines starting with #
+func readURLs(r io.Reader) ([]string, error) {
+	if f, ok := r.(*os.File); ok {
+		// don't wait for the user to type the urls if stdin is a terminal
+		if info, err := f.Stat(); err != nil || info.Mode()&os.ModeCharDevice != 0 {
+			return nil, err
+		}
+	}
+
+	var urls []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			urls = append(urls, line)
+		}
+	}
+	return urls, scanner.Err()
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	// the test server listens on the loopback address which is blocked by default
+	t.Setenv("CLIENT_ALLOWED_ADDRESSES", "127.0.0.1")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte("<!DOCTYPE HTML><html><title>Title</title><a href='/missing'></a><a href='/'></a></html>"))
+		case "/old":
+			http.Redirect(w, r, "/", http.StatusMovedPermanently)
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []*struct {
+		name             string
+		args             []string
+		stdin            string
+		expectedExitCode int
+		expectedOutput   []string
+	}{
+		{
+			name:             "Should fail for unsupported format",
+			args:             []string{"-format", "xml", server.URL},
+			expectedExitCode: exitUsage,
+		},
+		{
+			name:             "Should fail if no url is given",
+			expectedExitCode: exitUsage,
+		},
+		{
+			name:             "Should print the summary as a table",
+			args:             []string{server.URL},
+			expectedExitCode: exitOK,
+			expectedOutput:   []string{"Title\n", "Inaccessible Links Count  1\n", "/missing (404)"},
+		},
+		{
+			name:             "Should print the redirect chain",
+			args:             []string{server.URL + "/old"},
+			expectedExitCode: exitOK,
+			expectedOutput:   []string{"Final URL", "/old (301) -> /\n", "Title\n"},
+		},
+		{
+			name:             "Should fail on a redirect loop",
+			args:             []string{server.URL + "/loop"},
+			expectedExitCode: exitAnalysisFailed,
+			expectedOutput:   []string{"HTTP Status Code  302\n"},
+		},
+		{
+			name:             "Should fail if the url points to a blocked address",
+			args:             []string{"http://169.254.169.254/latest/meta-data"},
+			expectedExitCode: exitAnalysisFailed,
+			expectedOutput:   []string{"internal or blocked address"},
+		},
+		{
+			name:             "Should read the urls from stdin and fail if a url is not reachable",
+			args:             []string{"-format", "ndjson"},
+			stdin:            server.URL + "\n\n# comment\n" + server.URL + "/missing\n",
+			expectedExitCode: exitAnalysisFailed,
+			expectedOutput:   []string{`"title":"Title"`, `"error":{"message":`, `"httpStatusCode":404`},
+		},
+		{
+			name:             "Should fail if there are more inaccessible links than the max",
+			args:             []string{"-format", "json", "-max-inaccessible-links", "0", server.URL},
+			expectedExitCode: exitTooManyBrokenLinks,
+			expectedOutput:   []string{`"inaccessibleLinks": [`},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			exitCode := run(context.Background(), tc.args, strings.NewReader(tc.stdin), &stdout, &stderr)
+			if exitCode != tc.expectedExitCode {
+				t.Fatalf("Expected:%v, Got:%v %v", tc.expectedExitCode, exitCode, stderr.String())
+			}
+			for _, expected := range tc.expectedOutput {
+				if !strings.Contains(stdout.String(), expected) {
+					t.Fatalf("Expected:%v, Got:%v", expected, stdout.String())
+				}
+			}
+		})
+	}
+}
+
+func TestRun_Addresses(t *testing.T) {
+	// the loopback address of the test server is blocked unless it is allowed by the flags
+	t.Setenv("CLIENT_ALLOWED_ADDRESSES", "")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<!DOCTYPE HTML><html><title>Title</title></html>"))
+	}))
+	defer server.Close()
+
+	tests := []*struct {
+		name             string
+		args             []string
+		expectedExitCode int
+		expectedOutput   string
+	}{
+		{
+			name:             "Should block the internal address by default",
+			args:             []string{server.URL},
+			expectedExitCode: exitAnalysisFailed,
+			expectedOutput:   "internal or blocked address",
+		},
+		{
+			name:             "Should load the internal address allowed by the flag",
+			args:             []string{"-allow", "10.0.0.0/8,127.0.0.0/8", server.URL},
+			expectedExitCode: exitOK,
+			expectedOutput:   "Title\n",
+		},
+		{
+			name:             "Should load the internal address allowed by the repeated flag",
+			args:             []string{"-allow", "10.0.0.0/8", "-allow", "127.0.0.1", server.URL},
+			expectedExitCode: exitOK,
+			expectedOutput:   "Title\n",
+		},
+		{
+			name:             "Should block the address denied by the flag even if it is allowed",
+			args:             []string{"-allow", "127.0.0.1", "-deny", "127.0.0.0/8", server.URL},
+			expectedExitCode: exitAnalysisFailed,
+			expectedOutput:   "internal or blocked address",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			exitCode := run(context.Background(), tc.args, strings.NewReader(""), &stdout, &stderr)
+			if exitCode != tc.expectedExitCode {
+				t.Fatalf("Expected:%v, Got:%v %v", tc.expectedExitCode, exitCode, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tc.expectedOutput) {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedOutput, stdout.String())
+			}
+		})
+	}
+}
+
+func TestRun_NDJSON(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	run(context.Background(), []string{"-format", "ndjson", "invalid", "also invalid"}, nil, &stdout, &stderr)
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected:%v lines, Got:%v", 2, stdout.String())
+	}
+	for _, line := range lines {
+		var res result
+		if err := json.Unmarshal([]byte(line), &res); err != nil || res.Error == nil {
+			t.Fatalf("Expected a result with error, Got:%v", line)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"web-analyser/api/backend/analyser"
+	iError "web-analyser/internal/utils/error"
+)
+
+// output formats supported by the command
+const (
+	formatTable  = "table"
+	formatJSON   = "json"
+	formatNDJSON = "ndjson"
+)
+
+// result is the outcome of analysing a single url, either the summary or the error is set
+type result struct {
+	URL     string              `json:"url"`
+	Summary *analyser.Summary   `json:"summary,omitempty"`
+	Error   *iError.CustomError `json:"error,omitempty"`
+}
+
+// output writes the results in one of the supported formats
+type output interface {
+	write(res *result) error
+	close() error
+}
+
+// newOutput returns the output for the format
+func newOutput(format string, w io.Writer) (output, error) {
+	switch format {
+	case formatTable:
+		return &tableOutput{w: w}, nil
+	case formatJSON:
+		return &jsonOutput{w: w}, nil
+	case formatNDJSON:
+		return &ndjsonOutput{encoder: json.NewEncoder(w)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported format %q, supported formats: %v, %v, %v", format,
+			formatTable, formatJSON, formatNDJSON)
+	}
+}
+
+// tableOutput writes each result as a table of fields and values, the same fields as the summary page
+type tableOutput struct {
+	w       io.Writer
+	written bool
+}
+
+func (o *tableOutput) write(res *result) error {
+	tw := tabwriter.NewWriter(o.w, 0, 0, 2, ' ', 0)
+	if o.written {
+		fmt.Fprintln(tw)
+	}
+	o.written = true
+
+	fmt.Fprintf(tw, "URL\t%v\n", res.URL)
+	if res.Error != nil {
+		fmt.Fprintf(tw, "Error\t%v\n", res.Error.Message)
+		if res.Error.HttpStatusCode != 0 {
+			fmt.Fprintf(tw, "HTTP Status Code\t%v\n", res.Error.HttpStatusCode)
+		}
+		return tw.Flush()
+	}
+
+	s := res.Summary
+	if len(s.RedirectChain) > 0 {
+		fmt.Fprintf(tw, "Final URL\t%v\n", s.FinalURL)
+		for _, hop := range s.RedirectChain {
+			downgrade := ""
+			if hop.Downgrade {
+				downgrade = " https to http downgrade"
+			}
+			fmt.Fprintf(tw, "\t%v (%v) -> %v%v\n", hop.URL, hop.StatusCode, hop.Location, downgrade)
+		}
+	}
+	fmt.Fprintf(tw, "Version\t%v\n", s.Version)
+	fmt.Fprintf(tw, "Title\t%v\n", s.Title)
+	if s.Content != nil {
+		fmt.Fprintf(tw, "Content\t%v, %v bytes (declared: %v, sniffed: %v)\n", s.Content.Type, s.Content.Size,
+			s.Content.DeclaredType, s.Content.SniffedType)
+		if s.Content.Truncated {
+			fmt.Fprintf(tw, "\ttruncated at the max body size or the max compression ratio\n")
+		}
+	}
+	if s.Fetch != nil {
+		fmt.Fprintf(tw, "Fetch Attempts\t%v in %v\n", s.Fetch.Attempts, s.Fetch.Duration)
+	}
+	if s.Encoding != nil {
+		fmt.Fprintf(tw, "Character Encoding\t%v (%v)\n", s.Encoding.Charset, s.Encoding.Source)
+		if s.Encoding.Mismatch {
+			fmt.Fprintf(tw, "\tmismatch: header %v, meta %v\n", s.Encoding.HeaderCharset, s.Encoding.MetaCharset)
+		}
+	}
+	fmt.Fprintf(tw, "Headers Count\t%v\n", headersCount(s.HeadersCount))
+	fmt.Fprintf(tw, "External Links Count\t%v\n", len(s.ExternalLinksMap))
+	fmt.Fprintf(tw, "Internal Links Count\t%v\n", len(s.InternalLinksMap))
+	fmt.Fprintf(tw, "Inaccessible Links Count\t%v\n", len(s.InaccessibleLinksMap))
+	for _, link := range sortedLinks(s.InaccessibleLinksMap) {
+		reason := "failed to parse"
+		if status, ok := s.LinkStatuses[link]; ok {
+			reason = string(status.ErrorClass)
+			if status.StatusCode != 0 {
+				reason = fmt.Sprint(status.StatusCode)
+			}
+		}
+		fmt.Fprintf(tw, "\t%v (%v)\n", link, reason)
+	}
+	fmt.Fprintf(tw, "Has Login Form\t%v\n", s.HasLoginForm)
+	for _, form := range s.DetectedForms {
+		fmt.Fprintf(tw, "\t%v (%v%%) %v: %v\n", form.Kind, form.Confidence, form.Selector,
+			strings.Join(form.Reasons, ", "))
+	}
+	fmt.Fprintf(tw, "Forms Count\t%v\n", len(s.Forms))
+	for _, form := range s.Forms {
+		fmt.Fprintf(tw, "\t%v %v (%v)%v\n", form.Method, form.Action, form.Selector, formFlags(form))
+		for _, field := range form.Fields {
+			fmt.Fprintf(tw, "\t  %v %v\n", field.Type, field.Name)
+		}
+	}
+	for _, report := range s.Reports {
+		fmt.Fprintf(tw, "%v\t\n", report.Name)
+		if report.Score != nil {
+			fmt.Fprintf(tw, "\tscore: %v/100\n", *report.Score)
+		}
+		for _, check := range report.Checks {
+			fmt.Fprintf(tw, "\t%v %v: %v\n", check.Status, check.Name, check.Message)
+		}
+		for _, name := range sortedValueNames(report.Values) {
+			fmt.Fprintf(tw, "\t%v: %v\n", name, report.Values[name])
+		}
+		for _, finding := range report.Findings {
+			if finding.Selector != "" {
+				fmt.Fprintf(tw, "\t%v: %v (%v)\n", finding.Severity, finding.Message, finding.Selector)
+				continue
+			}
+			fmt.Fprintf(tw, "\t%v: %v\n", finding.Severity, finding.Message)
+		}
+	}
+	return tw.Flush()
+}
+
+func (o *tableOutput) close() error {
+	return nil
+}
+
+// jsonOutput collects the results and writes them as a single JSON array
+type jsonOutput struct {
+	w       io.Writer
+	results []*result
+}
+
+func (o *jsonOutput) write(res *result) error {
+	o.results = append(o.results, res)
+	return nil
+}
+
+func (o *jsonOutput) close() error {
+	encoder := json.NewEncoder(o.w)
+	encoder.SetIndent("", "  ")
+	if o.results == nil {
+		o.results = []*result{}
+	}
+	return encoder.Encode(o.results)
+}
+
+// ndjsonOutput writes each result as a JSON object on its own line as soon as it is available
+type ndjsonOutput struct {
+	encoder *json.Encoder
+}
+
+func (o *ndjsonOutput) write(res *result) error {
+	return o.encoder.Encode(res)
+}
+
+func (o *ndjsonOutput) close() error {
+	return nil
+}
+
+// formFlags formats the flags of the form which are set, for example: [cross-origin, over http]
+func formFlags(form *analyser.Form) string {
+	var flags []string
+	for _, flag := range []*struct {
+		set  bool
+		name string
+	}{
+		{form.CrossOrigin, "cross-origin"},
+		{form.Insecure, "over http"},
+		{form.HasCSRFToken, "csrf token"},
+		{form.HasFileUpload, "file upload"},
+	} {
+		if flag.set {
+			flags = append(flags, flag.name)
+		}
+	}
+	if len(flags) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(flags, ", ") + "]"
+}
+
+// headersCount formats the headers count in the order of the header level, for example: h1: 1, h2: 3
+func headersCount(counts map[string]int) string {
+	if len(counts) == 0 {
+		return "0"
+	}
+	headers := make([]string, 0, len(counts))
+	for header := range counts {
+		headers = append(headers, header)
+	}
+	sort.Strings(headers)
+
+	parts := make([]string, 0, len(headers))
+	for _, header := range headers {
+		parts = append(parts, fmt.Sprintf("%v: %v", header, counts[header]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sortedLinks returns the links in sorted order
+func sortedLinks(links map[string]struct{}) []string {
+	sorted := make([]string, 0, len(links))
+	for link := range links {
+		sorted = append(sorted, link)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// sortedValueNames returns the names of the values of a report in sorted order
+func sortedValueNames(values map[string]any) []string {
+	sorted := make([]string, 0, len(values))
+	for name := range values {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
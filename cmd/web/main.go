@@ -6,13 +6,21 @@ import (
 	"fmt"
 	"github.com/joho/godotenv"
 	"html/template"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 	"web-analyser/api/backend/analyser"
+	"web-analyser/api/backend/batch"
+	"web-analyser/api/backend/compare"
+	"web-analyser/api/backend/crawler"
+	"web-analyser/api/backend/history"
+	"web-analyser/api/backend/job"
+	"web-analyser/api/backend/monitor"
 	"web-analyser/api/router"
+	"web-analyser/api/router/middleware"
 	"web-analyser/config"
 	iHttp "web-analyser/internal/utils/http"
 	"web-analyser/internal/utils/logger"
@@ -38,16 +46,78 @@ func main() {
 
 	// setup required objects
 	log := logger.NewLogger(conf.Server.Debug)
-	httpClient := iHttp.NewHttpClient(&conf.Client)
-	a := analyser.NewAnalyser(httpClient)
-	handler := analyser.NewHandler(log, tpl, a)
-	mux := router.New(log, handler)
+	if conf.Client.RetryBudget < 0 {
+		log.Fatal().Dur("budget", conf.Client.RetryBudget).Msg("invalid client retry budget")
+	}
+	// the page analysed within a request is retried only while the response can be written within the write timeout,
+	// once the links are checked
+	requestRetryBudget := conf.Server.TimeoutWrite - conf.Client.LinkCheckDeadline
+	if conf.Server.TimeoutWrite > 0 && requestRetryBudget <= 0 {
+		log.Fatal().Dur("budget", requestRetryBudget).
+			Msg("invalid server config, the write timeout must be longer than the link check deadline")
+	}
+	if _, err := iHttp.ParseSameSitePolicy(conf.Analyser.SameSitePolicy); err != nil {
+		log.Fatal().Err(err).Msg("invalid analyser config")
+	}
+	httpClient, err := iHttp.NewHttpClient(&conf.Client)
+	if err != nil {
+		log.Fatal().Err(err).Msg("unable to create the http client")
+	}
+	// the analyser follows the redirects itself to track the redirect chain
+	noRedirectHttpClient, err := iHttp.NewNoRedirectHttpClient(&conf.Client)
+	if err != nil {
+		log.Fatal().Err(err).Msg("unable to create the http client")
+	}
+	linkChecker := analyser.NewLinkChecker(httpClient, &conf.Client)
+	// the page is retried on the transient errors, while the links are checked once within the link check deadline
+	a := analyser.NewAnalyser(iHttp.NewRetryClient(noRedirectHttpClient, &conf.Client), linkChecker, &conf.Analyser)
+	store, err := history.NewStore(&conf.History, log)
+	if err != nil {
+		log.Fatal().Err(err).Msg("unable to create the history store")
+	}
+	recorder := history.NewRecorder(store)
+	handler := analyser.NewHandler(log, tpl, a, recorder)
+	c := crawler.NewCrawler(a, httpClient, &conf.Crawler, &conf.Analyser)
+	historyHandler := history.NewHandler(log, tpl, store)
+	compareHandler := compare.NewHandler(log, tpl, a, store)
+
+	// the monitors, the jobs, the batches and the requests in progress are all cancelled once the server shuts down
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	monitorStore, err := monitor.NewFileStore(conf.Monitor.File)
+	if err != nil {
+		log.Fatal().Err(err).Msg("unable to load the monitors")
+	}
+	notifiers, err := monitor.NewNotifiers(&conf.Monitor, log)
+	if err != nil {
+		log.Fatal().Err(err).Msg("unable to create the notifiers")
+	}
+	scheduler := monitor.NewScheduler(a, recorder, store, monitorStore, notifiers, &conf.Monitor, log)
+	if err := scheduler.Start(backgroundCtx); err != nil {
+		log.Fatal().Err(err).Msg("unable to start the scheduler")
+	}
+	monitorHandler := monitor.NewHandler(log, monitorStore, scheduler, &conf.Monitor)
+	queue := job.NewQueue(a, c, recorder, &conf.Job, log)
+	queue.Start(backgroundCtx)
+	jobHandler := job.NewHandler(log, tpl, queue)
+	runner := batch.NewRunner(a, recorder, &conf.Batch, log)
+	runner.Start(backgroundCtx)
+	batchHandler := batch.NewHandler(log, tpl, runner, &conf.Batch)
+	var mux http.Handler = router.New(log, handler, historyHandler, compareHandler, monitorHandler, jobHandler,
+		batchHandler)
+	if conf.Server.TimeoutWrite > 0 {
+		mux = middleware.RetryDeadline(requestRetryBudget)(mux)
+	}
 
 	serv := &http.Server{
 		Addr:         fmt.Sprintf(":%d", conf.Server.Port),
 		ReadTimeout:  conf.Server.TimeoutRead,
 		WriteTimeout: conf.Server.TimeoutWrite,
 		Handler:      mux,
+		// the context of every request derives from the background context, so that the analyses in progress are
+		// cancelled on shutdown instead of holding it until they are finished
+		BaseContext: func(net.Listener) context.Context { return backgroundCtx },
 	}
 
 	// Starting the server in another goroutine
@@ -64,6 +134,8 @@ func main() {
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	<-sigChan
 
+	// cancel the analyses in progress so that the requests waiting on them are answered right away
+	stopBackground()
 	shutdownCtx, shutdownRelease := context.WithTimeout(context.Background(), 1*time.Hour)
 	defer shutdownRelease()
 
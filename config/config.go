@@ -7,8 +7,14 @@ import (
 )
 
 type Conf struct {
-	Server ServerConf
-	Client ClientConf
+	Server   ServerConf
+	Client   ClientConf
+	Analyser AnalyserConf
+	Crawler  CrawlerConf
+	History  HistoryConf
+	Monitor  MonitorConf
+	Job      JobConf
+	Batch    BatchConf
 }
 
 // ServerConf is a struct for the server configurations
@@ -21,7 +27,126 @@ type ServerConf struct {
 
 // ClientConf is a struct for the client configurations
 type ClientConf struct {
-	Timeout time.Duration `env:"CLIENT_TIMEOUT"`
+	Timeout              time.Duration `env:"CLIENT_TIMEOUT,default=10s"`
+	LinkCheckTimeout     time.Duration `env:"CLIENT_LINK_CHECK_TIMEOUT,default=2s"`
+	LinkCheckDeadline    time.Duration `env:"CLIENT_LINK_CHECK_DEADLINE,default=10s"`
+	LinkCheckMaxLinks    int           `env:"CLIENT_LINK_CHECK_MAX_LINKS,default=100"`
+	LinkCheckConcurrency int           `env:"CLIENT_LINK_CHECK_CONCURRENCY,default=10"`
+	LinkCheckPerHost     int           `env:"CLIENT_LINK_CHECK_PER_HOST,default=2"`
+	// AllowedAddresses are the hosts, IPs and CIDRs, separated by ;, which can be loaded even if they are internal
+	AllowedAddresses []string `env:"CLIENT_ALLOWED_ADDRESSES"`
+	// DeniedAddresses are the hosts, IPs and CIDRs, separated by ;, which are never loaded
+	DeniedAddresses []string `env:"CLIENT_DENIED_ADDRESSES"`
+	// UserAgent is the User-Agent header of the outbound requests, unless the analysis sets its own
+	UserAgent string `env:"CLIENT_USER_AGENT,default=Mozilla/5.0 (compatible; web-analyser/1.0)"`
+	// Headers are the Name: value pairs, separated by ;, sent along with every outbound request
+	Headers []string `env:"CLIENT_HEADERS"`
+	// ProxyURL is the http, https or socks5 proxy the outbound requests are sent through, none if empty. The proxy is
+	// asked to connect to the checked IP addresses, so that it doesn't resolve the hosts itself.
+	ProxyURL string `env:"CLIENT_PROXY_URL"`
+	// CAFile is the PEM file of the CA certificates trusted on top of the system ones, such as an internal CA
+	CAFile string `env:"CLIENT_CA_FILE"`
+	// InsecureSkipVerify skips the verification of the certificates, only meant for the internal test environments
+	InsecureSkipVerify bool `env:"CLIENT_INSECURE_SKIP_VERIFY,default=false"`
+	// MaxBodySize is the max size in bytes of the decompressed response bodies, the bodies are truncated beyond it, 0
+	// disables the limit
+	MaxBodySize int64 `env:"CLIENT_MAX_BODY_SIZE,default=10485760"`
+	// MaxCompressionRatio is the max ratio of the decompressed to the compressed size of the response bodies, the
+	// bodies are truncated beyond it so that a decompression bomb is stopped early, 0 disables the limit
+	MaxCompressionRatio int64 `env:"CLIENT_MAX_COMPRESSION_RATIO,default=100"`
+	// RetryMaxAttempts is the max number of attempts of a page request failing with a transient error, such as a
+	// timeout or a 503, 1 disables the retries
+	RetryMaxAttempts int `env:"CLIENT_RETRY_MAX_ATTEMPTS,default=3"`
+	// RetryBaseDelay is the delay before the first retry, doubled on every retry up to the RetryMaxDelay
+	RetryBaseDelay time.Duration `env:"CLIENT_RETRY_BASE_DELAY,default=500ms"`
+	// RetryMaxDelay is the max delay between two attempts, a Retry-After asking for longer stops the retries
+	RetryMaxDelay time.Duration `env:"CLIENT_RETRY_MAX_DELAY,default=5s"`
+	// RetryBudget is the max total time of the attempts of a request along with the delays between them, no retry is
+	// made which could end, within the Timeout, after it is spent, 0 disables the budget. The web server also limits
+	// the retries of each request so that the page is fetched, the links checked and the summary written within the
+	// SERVER_TIMEOUT_WRITE, see middleware.RetryDeadline.
+	RetryBudget time.Duration `env:"CLIENT_RETRY_BUDGET,default=30s"`
+}
+
+// AnalyserConf is a struct for the analyser configurations
+type AnalyserConf struct {
+	// SameSitePolicy decides which links are internal, exact: same host, host: same host ignoring the www. prefix,
+	// domain: same registrable domain
+	SameSitePolicy string `env:"ANALYSER_SAME_SITE_POLICY,default=host"`
+	// SiteAliases are the hosts, separated by ;, whose links are always internal
+	SiteAliases []string `env:"ANALYSER_SITE_ALIASES"`
+	// SortQueryParams sorts the query params of the links, so that the links only differing in order are equal
+	SortQueryParams bool `env:"ANALYSER_SORT_QUERY_PARAMS,default=false"`
+	// MaxRedirects is the max number of redirects followed while loading the page
+	MaxRedirects int `env:"ANALYSER_MAX_REDIRECTS,default=10"`
+	// Inspectors are the names, separated by ;, of the inspectors analysing the page, all are enabled if empty
+	Inspectors []string `env:"ANALYSER_INSPECTORS"`
+}
+
+// CrawlerConf is a struct for the crawler configurations
+type CrawlerConf struct {
+	MaxDepth int           `env:"CRAWLER_MAX_DEPTH,default=2"`
+	MaxPages int           `env:"CRAWLER_MAX_PAGES,default=20"`
+	Delay    time.Duration `env:"CRAWLER_DELAY,default=500ms"`
+}
+
+// HistoryConf is a struct for the analysis history configurations
+type HistoryConf struct {
+	// Backend is where the analyses are stored, memory: lost on restart, file: a JSON file per analysis in the Dir
+	Backend string `env:"HISTORY_BACKEND,default=file"`
+	// Dir is the directory of the file backend
+	Dir string `env:"HISTORY_DIR,default=data/history"`
+	// MaxAge is how long the analyses are kept, 0 keeps them forever
+	MaxAge time.Duration `env:"HISTORY_MAX_AGE,default=720h"`
+	// MaxPerURL is the number of analyses kept per URL, the oldest are removed first, 0 keeps all of them
+	MaxPerURL int `env:"HISTORY_MAX_PER_URL,default=20"`
+}
+
+// MonitorConf is a struct for the monitoring configurations
+type MonitorConf struct {
+	// File is the JSON file the monitors are stored in
+	File string `env:"MONITOR_FILE,default=data/monitors.json"`
+	// MinInterval is the shortest interval a monitor can check its URL at
+	MinInterval time.Duration `env:"MONITOR_MIN_INTERVAL,default=1m"`
+	// Jitter is the max random delay added to each check, as a fraction of the time between the check and the next one
+	Jitter float64 `env:"MONITOR_JITTER,default=0.1"`
+	// Notifiers are the names, separated by ;, of the notifiers delivering the alerts: log, webhook and smtp
+	Notifiers []string `env:"MONITOR_NOTIFIERS,default=log"`
+	// WebhookURL is the URL the alerts are posted to as JSON by the webhook notifier
+	WebhookURL string `env:"MONITOR_WEBHOOK_URL"`
+	// WebhookTimeout is the timeout of posting an alert to the WebhookURL
+	WebhookTimeout time.Duration `env:"MONITOR_WEBHOOK_TIMEOUT,default=5s"`
+	// SMTPAddr is the host:port of the SMTP relay the smtp notifier sends the alerts through, without auth
+	SMTPAddr string `env:"MONITOR_SMTP_ADDR,default=localhost:25"`
+	// SMTPFrom is the sender address of the alert emails
+	SMTPFrom string `env:"MONITOR_SMTP_FROM"`
+	// SMTPTo are the recipient addresses, separated by ;, of the alert emails
+	SMTPTo []string `env:"MONITOR_SMTP_TO"`
+}
+
+// JobConf is a struct for the analysis jobs configurations
+type JobConf struct {
+	// Workers is the number of analyses run at the same time
+	Workers int `env:"JOB_WORKERS,default=4"`
+	// QueueSize is the number of jobs waiting for a worker, the jobs submitted beyond it are refused
+	QueueSize int `env:"JOB_QUEUE_SIZE,default=100"`
+	// TTL is how long the finished jobs are kept
+	TTL time.Duration `env:"JOB_TTL,default=1h"`
+}
+
+// BatchConf is a struct for the batch analysis configurations
+type BatchConf struct {
+	// Concurrency is the number of URLs analysed at the same time across all the batches
+	Concurrency int `env:"BATCH_CONCURRENCY,default=4"`
+	// PerHost is the number of URLs of the same host analysed at the same time across all the batches, the hosts take
+	// turns
+	PerHost int `env:"BATCH_PER_HOST,default=1"`
+	// MaxURLs is the max number of URLs of a batch
+	MaxURLs int `env:"BATCH_MAX_URLS,default=100"`
+	// MaxUploadSize is the max size in bytes of the submitted list of URLs
+	MaxUploadSize int64 `env:"BATCH_MAX_UPLOAD_SIZE,default=1048576"`
+	// TTL is how long the reports of the finished batches are kept
+	TTL time.Duration `env:"BATCH_TTL,default=1h"`
 }
 
 // New maps the environment variables to Conf using envdecode pkg
@@ -2,8 +2,8 @@ package error
 
 // CustomError defines a struct for custom error, containing message and http status code
 type CustomError struct {
-	Message        string
-	HttpStatusCode int
+	Message        string `json:"message"`
+	HttpStatusCode int    `json:"httpStatusCode,omitempty"`
 }
 
 type Msg string
@@ -13,4 +13,57 @@ const (
 		"please check your internet connection and ensure that the URL is correct"
 	InvalidURLError Msg = "Invalid URL provided, please ensure the URL format is correct, " +
 		"for example: https://www.google.com"
+	EmptyURLError Msg = "No URL provided, please enter the URL to analyse, " +
+		"for example: https://www.google.com"
+	UnsupportedSchemeError Msg = "Unsupported URL scheme, only http and https URLs can be analysed, " +
+		"for example: https://www.google.com"
+	MissingHostError Msg = "The URL provided has no host, please include the domain name, " +
+		"for example: https://www.google.com"
+	InvalidHostError Msg = "The host of the URL provided is not a valid domain name or IP address, " +
+		"please ensure that the host is correct"
+	InvalidPortError Msg = "The port of the URL provided is invalid, " +
+		"please ensure that the port is a number between 1 and 65535"
+	UserInfoError Msg = "URLs with a username or password are not supported, " +
+		"please remove them from the URL"
+	BlockedURLError Msg = "The URL provided points to an internal or blocked address, " +
+		"please ensure that the URL is publicly reachable"
+	UnsupportedContentTypeError Msg = "The URL provided doesn't point to an HTML page, only HTML and XHTML pages " +
+		"can be analysed, the content type of the page is "
+	InvalidRequestError Msg = "Invalid request body, please send a JSON object with the url field, " +
+		"for example: {\"url\": \"https://www.google.com\"}"
+	InvalidHeaderError Msg = "Invalid header provided, please enter the headers as Name: value pairs, one per line, " +
+		"for example: X-Environment: staging"
+	InvalidCredentialsError Msg = "Invalid credentials provided, please enter either a username and a password or " +
+		"a token, the username can't contain a colon"
+	InvalidCookiesError Msg = "Invalid cookies provided, please enter the cookies as name=value pairs separated by " +
+		"semicolons, for example: session=abc; lang=en"
+	RecordNotFoundError Msg = "No stored analysis found, " +
+		"it may have been deleted or removed by the retention policy"
+	HistoryUnavailableError   Msg = "Unable to load the history of the analyses, please try again later"
+	IncompleteComparisonError Msg = "Two analyses are needed to compare, please provide either a stored analysis " +
+		"or a URL to analyse for both sides of the comparison"
+	InvalidCompareRequestError Msg = "Invalid request body, please send a JSON object with the fromId or fromUrl " +
+		"and the toId or toUrl fields, for example: {\"fromUrl\": \"https://staging.google.com\", " +
+		"\"toUrl\": \"https://www.google.com\"}"
+	InvalidMonitorRequestError Msg = "Invalid request body, please send a JSON object with the url and the interval " +
+		"or schedule fields, for example: {\"url\": \"https://www.google.com\", \"interval\": \"1h\"} or " +
+		"{\"url\": \"https://www.google.com\", \"schedule\": \"0 9 * * 1-5\"}"
+	InvalidIntervalError Msg = "Invalid interval provided, please use a duration such as 15m or 1h, or a cron " +
+		"schedule instead, the shortest interval is "
+	InvalidScheduleError Msg = "Invalid schedule provided, please use a cron expression such as */15 * * * * or " +
+		"@hourly, the shortest interval between two checks is "
+	IntervalAndScheduleError Msg = "Please provide either an interval or a schedule, not both"
+	UnknownConditionError    Msg = "Unknown alert condition provided, the conditions are "
+	MonitorNotFoundError     Msg = "No monitor found, it may have been deleted"
+	MonitorUnavailableError  Msg = "Unable to load the monitors, please try again later"
+	QueueFullError           Msg = "Too many analyses are waiting to run, please try again later"
+	JobNotFoundError         Msg = "No analysis job found, it may have expired"
+	AnalysisCancelledError   Msg = "The analysis was cancelled before it finished, please try again"
+	EmptyBatchError          Msg = "No URLs provided, please enter or upload the URLs to analyse, one per line"
+	TooManyURLsError         Msg = "Too many URLs provided, the max number of URLs of a batch is "
+	URLListTooLargeError     Msg = "The list of URLs is too large, the max size in bytes is "
+	InvalidURLListError      Msg = "Unable to read the list of URLs, please upload a CSV or plain text file"
+	InvalidBatchRequestError Msg = "Invalid request body, please send a JSON object with the urls field, " +
+		"for example: {\"urls\": [\"https://www.google.com\"]}"
+	BatchNotFoundError Msg = "No batch found, it may have expired"
 )
@@ -0,0 +1,460 @@
+package html
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"strings"
+	"unicode"
+)
+
+// FormKind represents the purpose of a form
+type FormKind string
+
+const (
+	FormLogin         FormKind = "login"          // the form signs the user in
+	FormSignup        FormKind = "signup"         // the form creates an account
+	FormPasswordReset FormKind = "password_reset" // the form recovers or changes a password
+	FormSearch        FormKind = "search"         // the form searches the site
+)
+
+// MinFormConfidence is the confidence a form needs to be classified, see FormClass
+const MinFormConfidence = 40
+
+// formKinds are the kinds of form in the order they win a tie
+var formKinds = []FormKind{FormLogin, FormSignup, FormPasswordReset, FormSearch}
+
+// formWords are the words of the buttons and links hinting at each kind of form, in several languages
+var formWords = map[FormKind][]string{
+	FormLogin: {"log in", "login", "log on", "sign in", "signin", "anmelden", "einloggen", "se connecter", "connexion",
+		"iniciar sesión", "iniciar sessão", "acceder", "entrar", "accedi", "inloggen", "logga in", "zaloguj", "войти",
+		"вход", "ログイン", "登录", "登入", "로그인", "giriş yap"},
+	FormSignup: {"sign up", "signup", "register", "create account", "create an account", "join now", "registrieren",
+		"konto erstellen", "s'inscrire", "inscription", "créer un compte", "regístrate", "registrarse", "crear cuenta",
+		"criar conta", "cadastre", "registrati", "registreren", "zarejestruj", "зарегистрироваться", "регистрация",
+		"新規登録", "注册", "註冊", "회원가입"},
+	FormPasswordReset: {"forgot", "reset", "recover", "change password", "passwort vergessen", "zurücksetzen",
+		"mot de passe oublié", "réinitialiser", "olvidaste", "restablecer", "recuperar", "password dimenticata",
+		"wachtwoord vergeten", "nie pamiętasz", "забыли пароль", "восстановить", "パスワードを忘れ", "忘记密码",
+		"重置密码", "비밀번호 찾기"},
+	FormSearch: {"search", "suche", "suchen", "rechercher", "recherche", "buscar", "pesquisar", "cerca", "zoeken", "sök",
+		"szukaj", "поиск", "найти", "検索", "搜索", "검색"},
+}
+
+// formTokens are the tokens of the action, id, class and name attributes of a form hinting at each kind of form, the
+// attributes are compacted first so that log-in, log_in and logIn all match login
+var formTokens = map[FormKind][]string{
+	FormLogin:         {"login", "signin", "logon", "session"},
+	FormSignup:        {"signup", "register", "registration", "createaccount", "join", "enroll"},
+	FormPasswordReset: {"forgot", "reset", "recover", "lostpassword", "changepassword"},
+	FormSearch:        {"search", "suche", "recherche", "buscar"},
+}
+
+// ssoWords are the words of the buttons and links signing in with another provider
+var ssoWords = []string{"sign in with", "log in with", "login with", "sign up with", "continue with", "anmelden mit",
+	"se connecter avec", "continuer avec", "iniciar sesión con", "continuar con", "accedi con", "inloggen met"}
+
+// ssoTokens are the tokens of the links to an OAuth, OpenID or SAML provider
+var ssoTokens = []string{"oauth", "openid", "saml", "/sso", "authorize"}
+
+// identifierWords are the words of the labels and placeholders of a username field
+var identifierWords = []string{"email", "e-mail", "username", "user name", "login", "benutzername", "nom d'utilisateur",
+	"usuario", "correo", "utente", "gebruikersnaam", "логин", "имя пользователя", "ユーザー名", "用户名", "아이디", "이메일"}
+
+// identifierTokens are the tokens of the name and id attributes of a username field
+var identifierTokens = []string{"user", "login", "email", "mail", "account", "identifier"}
+
+// usernameTokens are the tokens of the name and id attributes of a field asking for the username only
+var usernameTokens = []string{"username", "userid", "login", "identifier"}
+
+// searchTokens are the name and id attributes of a search field
+var searchTokens = []string{"q", "query", "s", "search", "keyword", "keywords", "term", "searchterm"}
+
+// personalTokens are the tokens of the name, id and autocomplete attributes of a field asking for personal details
+var personalTokens = []string{"firstname", "lastname", "fullname", "givenname", "familyname", "phone", "tel", "birth",
+	"bday", "address", "zip", "postal"}
+
+// FormClass represents the kind of form an element is classified as, along with the reasons it is based on. The
+// confidence is the total of the points scored by the hints of the kind capped at 100, not a probability, for example
+// a password field alone scores enough to classify a login form.
+type FormClass struct {
+	Kind       FormKind // Kind represents the purpose of the form
+	Confidence int      // Confidence represents the points scored by the form for its kind, up to 100
+	Reasons    []string // Reasons represents the hints the form is of the kind, in the order they were found
+}
+
+// formField represents a field of a form
+type formField struct {
+	inputType    string
+	tokens       string
+	autocomplete string
+	text         string
+}
+
+// formScore represents the points scored by a form for each kind of form along with their reasons
+type formScore struct {
+	points  map[FormKind]int
+	reasons map[FormKind][]string
+}
+
+// add scores the points for the kind of form with the reason
+func (s *formScore) add(kind FormKind, points int, reason string) {
+	s.points[kind] += points
+	s.reasons[kind] = append(s.reasons[kind], reason)
+}
+
+// ClassifyForm classifies the form element, or the element grouping the fields of a form without a form element, as
+// a login, signup, password reset or search form. The fields, labels, buttons and links of the form score points for
+// each kind of form, and the form is of the kind with the most points, returns nil if no kind scores at least
+// MinFormConfidence. The labels are the labels of the document of the form, collected once for all its forms, see
+// CollectLabels.
+func ClassifyForm(n *html.Node, labels map[string]string) *FormClass {
+	score := &formScore{points: map[FormKind]int{}, reasons: map[FormKind][]string{}}
+	scoreAttributes(n, score)
+
+	var fields []*formField
+	var checkboxes []string
+	scoreElements(n, labels, score, &fields, &checkboxes)
+	scoreFields(fields, score)
+
+	for _, checkbox := range checkboxes {
+		if containsAny(checkbox, []string{"remember", "stay", "keep"}) {
+			score.add(FormLogin, 10, "offers to remember the user")
+		}
+		if containsAny(checkbox, []string{"terms", "tos", "agree", "privacy", "consent"}) {
+			score.add(FormSignup, 15, "asks to accept the terms")
+		}
+	}
+
+	best := FormKind("")
+	for _, kind := range formKinds {
+		// the forms asking for a password never search
+		if kind == FormSearch && hasPassword(fields) {
+			continue
+		}
+		if best == "" || score.points[kind] > score.points[best] {
+			best = kind
+		}
+	}
+	if score.points[best] < MinFormConfidence {
+		return nil
+	}
+	return &FormClass{Kind: best, Confidence: min(score.points[best], 100), Reasons: score.reasons[best]}
+}
+
+// HasLoginForm returns whether the given html node is classified as a login form
+func HasLoginForm(n *html.Node) bool {
+	class := ClassifyForm(n, CollectLabels(root(n)))
+	return class != nil && class.Kind == FormLogin
+}
+
+// FormContainer returns the element grouping the field with its submit button when the field isn't in a form
+// element, which is the closest ancestor having a button, or the parent of the field if there is none
+func FormContainer(n *html.Node) *html.Node {
+	for p := n.Parent; p != nil && p.Data != "body" && p.Data != "html"; p = p.Parent {
+		if p.Type == html.ElementNode && hasButton(p) {
+			return p
+		}
+	}
+	return n.Parent
+}
+
+// scoreAttributes scores the tokens of the action, id, class and name attributes of the form, and its search role
+func scoreAttributes(n *html.Node, score *formScore) {
+	var attrs []string
+	for _, key := range []string{"action", "id", "class", "name"} {
+		if value, _ := Attr(n, key); value != "" {
+			attrs = append(attrs, value)
+		}
+	}
+	tokens := compact(strings.Join(attrs, " "))
+	for _, kind := range formKinds {
+		if token := firstContained(tokens, formTokens[kind]); token != "" {
+			score.add(kind, 40, fmt.Sprintf("the form attributes mention %q", token))
+		}
+	}
+
+	for p := n; p != nil; p = p.Parent {
+		if role, _ := Attr(p, "role"); strings.EqualFold(strings.TrimSpace(role), "search") {
+			score.add(FormSearch, 50, "the form has the search role")
+			break
+		}
+	}
+}
+
+// scoreElements scores the buttons and links of the form, and collects its fields and the text of its checkboxes. The
+// nested forms of an element grouping the fields of a form without a form element belong to another form, so they
+// are skipped.
+func scoreElements(n *html.Node, labels map[string]string, score *formScore, fields *[]*formField,
+	checkboxes *[]string) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.Data == "form" {
+			continue
+		}
+		inputType := attrText(c, "type")
+		switch {
+		case c.Data == "input" && (inputType == "submit" || inputType == "image"):
+			scoreSubmit(fieldText(c, labels)+" "+attrText(c, "value", "alt"), score)
+		case c.Data == "button" && (inputType == "" || inputType == "submit"):
+			scoreSubmit(lower(Text(c))+" "+attrText(c, "aria-label", "value"), score)
+		case c.Data == "a" || c.Data == "button" || c.Data == "input" && inputType == "button":
+			href, _ := Attr(c, "href")
+			scoreLink(lower(Text(c))+" "+attrText(c, "aria-label", "value"), lower(href), score)
+		case c.Data == "input" && (inputType == "checkbox" || inputType == "radio"):
+			*checkboxes = append(*checkboxes, compact(attrText(c, "name", "id"))+" "+fieldText(c, labels))
+		case c.Data == "input" && inputType != "hidden" && inputType != "reset" && inputType != "file",
+			c.Data == "select", c.Data == "textarea":
+			*fields = append(*fields, &formField{
+				inputType:    inputType,
+				tokens:       compact(attrText(c, "name", "id")),
+				autocomplete: attrText(c, "autocomplete"),
+				text:         fieldText(c, labels),
+			})
+		}
+		scoreElements(c, labels, score, fields, checkboxes)
+	}
+}
+
+// scoreSubmit scores the text of a submit button of the form
+func scoreSubmit(text string, score *formScore) {
+	if containsAny(text, ssoWords) {
+		return
+	}
+	for _, kind := range formKinds {
+		if word := firstContained(text, formWords[kind]); word != "" && !hasReason(score, kind, "the submit button") {
+			score.add(kind, 40, fmt.Sprintf("the submit button reads %q", word))
+		}
+	}
+}
+
+// scoreLink scores the text and href of a link or a button which doesn't submit the form, signing in with another
+// provider or recovering the password hints at a login form
+func scoreLink(text string, href string, score *formScore) {
+	if containsAny(text, ssoWords) || containsAny(href, ssoTokens) {
+		kind := FormLogin
+		if firstContained(text, formWords[FormSignup]) != "" {
+			kind = FormSignup
+		}
+		if !hasReason(score, kind, "offers single sign-on") {
+			score.add(kind, 25, "offers single sign-on")
+		}
+		return
+	}
+	if firstContained(text, formWords[FormPasswordReset]) != "" && !hasReason(score, FormLogin, "links to") {
+		score.add(FormLogin, 15, "links to the password recovery")
+	}
+}
+
+// scoreFields scores the fields of the form
+func scoreFields(fields []*formField, score *formScore) {
+	var passwords, currentPasswords, newPasswords, identifiers, usernames, personal, others int
+	for _, field := range fields {
+		switch {
+		case field.inputType == "password":
+			passwords++
+			if strings.Contains(field.autocomplete, "current-password") {
+				currentPasswords++
+			}
+			if strings.Contains(field.autocomplete, "new-password") {
+				newPasswords++
+			}
+		case field.inputType == "search":
+			if !hasReason(score, FormSearch, "has a search field") {
+				score.add(FormSearch, 40, "has a search field")
+			}
+		case isIdentifier(field):
+			identifiers++
+			if strings.Contains(field.autocomplete, "username") || strings.Contains(field.autocomplete, "webauthn") ||
+				containsAny(field.tokens, usernameTokens) {
+				usernames++
+			}
+		case isSearch(field):
+			if !hasReason(score, FormSearch, "has a search field") {
+				score.add(FormSearch, 30, "has a search field")
+			}
+		case containsAny(compact(field.autocomplete)+" "+field.tokens, personalTokens):
+			personal++
+		default:
+			others++
+		}
+	}
+
+	switch {
+	case passwords == 1:
+		score.add(FormLogin, 40, "has a password field")
+	case passwords > 1 && identifiers > 0:
+		score.add(FormSignup, 40, "asks for the password twice")
+	case passwords > 1:
+		score.add(FormPasswordReset, 40, "asks for the new password twice")
+		score.add(FormSignup, 20, "asks for the password twice")
+	}
+	if currentPasswords > 0 {
+		score.add(FormLogin, 30, "the password field autocompletes the current password")
+	}
+	if newPasswords > 0 {
+		score.add(FormSignup, 20, "the password field autocompletes a new password")
+		score.add(FormPasswordReset, 20, "the password field autocompletes a new password")
+	}
+	switch {
+	case passwords == 1 && identifiers > 0:
+		score.add(FormLogin, 20, "has a username field")
+	case passwords == 1 && others == 1:
+		score.add(FormLogin, 10, "has a text field next to the password field")
+	case passwords == 0 && identifiers == 1 && usernames == 1 && personal == 0 && others == 0:
+		// the first step of a multi-step login asks for the username, the password is asked in the next step
+		score.add(FormLogin, 40, "asks for the username only, as the first step of a multi-step login")
+	}
+	if personal > 0 {
+		score.add(FormSignup, 20, "asks for personal details")
+	}
+}
+
+// isIdentifier returns whether the field asks for a username or an email
+func isIdentifier(field *formField) bool {
+	if field.inputType == "email" || strings.Contains(field.autocomplete, "username") ||
+		strings.Contains(field.autocomplete, "email") || strings.Contains(field.autocomplete, "webauthn") {
+		return true
+	}
+	if field.inputType != "" && field.inputType != "text" && field.inputType != "tel" {
+		return false
+	}
+	return containsAny(field.tokens, identifierTokens) || containsAny(field.text, identifierWords)
+}
+
+// isSearch returns whether the field asks for a search query
+func isSearch(field *formField) bool {
+	// the search tokens are short, so they have to match the whole name or id
+	for _, token := range strings.Fields(field.tokens) {
+		for _, searchToken := range searchTokens {
+			if token == searchToken {
+				return true
+			}
+		}
+	}
+	return containsAny(field.tokens, []string{"search", "query"}) || containsAny(field.text, formWords[FormSearch])
+}
+
+// hasPassword returns whether any of the fields is a password field
+func hasPassword(fields []*formField) bool {
+	for _, field := range fields {
+		if field.inputType == "password" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasReason returns whether a reason starting with the prefix was already given for the kind of form
+func hasReason(score *formScore, kind FormKind, prefix string) bool {
+	for _, reason := range score.reasons[kind] {
+		if strings.HasPrefix(reason, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasButton returns whether the element contains a button
+func hasButton(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		inputType := attrText(c, "type")
+		if c.Data == "button" || attrText(c, "role") == "button" || c.Data == "input" &&
+			(inputType == "submit" || inputType == "image" || inputType == "button") || hasButton(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldText returns the lowercased text of the labels, placeholder and aria-label of the field
+func fieldText(n *html.Node, labels map[string]string) string {
+	text := attrText(n, "placeholder", "aria-label", "title")
+	if id, _ := Attr(n, "id"); id != "" {
+		text += " " + labels[id]
+	}
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && p.Data == "label" {
+			text += " " + lower(Text(p))
+			break
+		}
+	}
+	return text
+}
+
+// attrText returns the lowercased values of the attributes of the node joined by spaces
+func attrText(n *html.Node, keys ...string) string {
+	var values []string
+	for _, key := range keys {
+		if value, _ := Attr(n, key); value != "" {
+			values = append(values, lower(value))
+		}
+	}
+	return strings.Join(values, " ")
+}
+
+// CollectLabels maps the ids referred to by the labels under the node, usually the document, to the lowercased text
+// of the labels
+func CollectLabels(n *html.Node) map[string]string {
+	labels := map[string]string{}
+	collectLabels(n, labels)
+	return labels
+}
+
+// AddLabel maps the id referred to by the label element to its lowercased text, it does nothing for the other nodes.
+// It collects the labels while the document is visited, see CollectLabels.
+func AddLabel(n *html.Node, labels map[string]string) {
+	if n.Type == html.ElementNode && n.Data == "label" {
+		if id, _ := Attr(n, "for"); id != "" {
+			labels[id] = lower(Text(n))
+		}
+	}
+}
+
+// collectLabels adds the labels under the node to the labels
+func collectLabels(n *html.Node, labels map[string]string) {
+	AddLabel(n, labels)
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		collectLabels(c, labels)
+	}
+}
+
+// root returns the document node of the node
+func root(n *html.Node) *html.Node {
+	for n.Parent != nil {
+		n = n.Parent
+	}
+	return n
+}
+
+// lower returns the value lowercased with its whitespace collapsed into single spaces
+func lower(value string) string {
+	return strings.ToLower(strings.Join(strings.Fields(value), " "))
+}
+
+// compact returns the lowercased letters, digits and spaces of the value, dropping the separators within the words
+// such as - and _
+func compact(value string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == ' ' {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, value)
+}
+
+// containsAny returns whether the value contains any of the words
+func containsAny(value string, words []string) bool {
+	return firstContained(value, words) != ""
+}
+
+// firstContained returns the first of the words the value contains, empty if it contains none
+func firstContained(value string, words []string) string {
+	for _, word := range words {
+		if strings.Contains(value, word) {
+			return word
+		}
+	}
+	return ""
+}
@@ -0,0 +1,152 @@
+package html_test
+
+import (
+	"golang.org/x/net/html"
+	"reflect"
+	"strings"
+	"testing"
+	iHtml "web-analyser/internal/utils/html"
+)
+
+func TestClassifyForm(t *testing.T) {
+	tests := []*struct {
+		name          string
+		htmlData      string
+		tag           string
+		expectedClass *iHtml.FormClass
+	}{
+		{
+			name: "Should classify a login form by its fields, buttons and links",
+			htmlData: `<html><body><form action="/session"><label for="u">E-Mail</label><input id="u" name="login">` +
+				`<input type="password" autocomplete="current-password"><input type="checkbox" name="remember_me">` +
+				`<a href="/password_reset">Forgot password?</a><input type="submit" value="Sign in"></form></body></html>`,
+			tag: "form",
+			expectedClass: &iHtml.FormClass{Kind: iHtml.FormLogin, Confidence: 100, Reasons: []string{
+				`the form attributes mention "session"`, "links to the password recovery",
+				`the submit button reads "sign in"`, "has a password field",
+				"the password field autocompletes the current password", "has a username field",
+				"offers to remember the user",
+			}},
+		},
+		{
+			name: "Should classify a login form by its labels and button text in another language",
+			htmlData: `<html><body><form><input type="text" name="benutzer" placeholder="Benutzername">` +
+				`<input type="password" name="pw"><button>Anmelden</button></form></body></html>`,
+			tag: "form",
+			expectedClass: &iHtml.FormClass{Kind: iHtml.FormLogin, Confidence: 100, Reasons: []string{
+				`the submit button reads "anmelden"`, "has a password field", "has a username field",
+			}},
+		},
+		{
+			name:     "Should classify a login form by the text input next to the password field",
+			htmlData: `<html><body><form><input><input type="password"><input type="submit" value="ログイン"></form></body></html>`,
+			tag:      "form",
+			expectedClass: &iHtml.FormClass{Kind: iHtml.FormLogin, Confidence: 90, Reasons: []string{
+				`the submit button reads "ログイン"`, "has a password field", "has a text field next to the password field",
+			}},
+		},
+		{
+			name: "Should classify the first step of a multi-step login asking for the username only",
+			htmlData: `<html><body><form action="/u/identifier"><input type="email" name="identifier" ` +
+				`autocomplete="username webauthn"><button>Next</button></form></body></html>`,
+			tag: "form",
+			expectedClass: &iHtml.FormClass{Kind: iHtml.FormLogin, Confidence: 40, Reasons: []string{
+				"asks for the username only, as the first step of a multi-step login",
+			}},
+		},
+		{
+			name: "Should classify the fields of a login form without a form element",
+			htmlData: `<html><body><div class="login-box"><input name="user"><input type="password">` +
+				`<button>Go</button></div></body></html>`,
+			tag: "div",
+			expectedClass: &iHtml.FormClass{Kind: iHtml.FormLogin, Confidence: 100, Reasons: []string{
+				`the form attributes mention "login"`, "has a password field", "has a username field",
+			}},
+		},
+		{
+			name: "Should classify a signup form asking for the password twice and offering single sign-on",
+			htmlData: `<html><body><form action="/users"><input name="first_name"><input type="email" name="email">` +
+				`<input type="password" autocomplete="new-password"><input type="password" name="password_confirmation">` +
+				`<input type="checkbox" name="terms"><button type="submit">Create account</button>` +
+				`<a href="/auth/google/oauth">Sign up with Google</a></form></body></html>`,
+			tag: "form",
+			expectedClass: &iHtml.FormClass{Kind: iHtml.FormSignup, Confidence: 100, Reasons: []string{
+				`the submit button reads "create account"`, "offers single sign-on", "asks for the password twice",
+				"the password field autocompletes a new password", "asks for personal details",
+				"asks to accept the terms",
+			}},
+		},
+		{
+			name: "Should classify a password reset form asking for the email",
+			htmlData: `<html><body><form action="/password/forgot"><input type="email" name="email">` +
+				`<button>Send reset link</button></form></body></html>`,
+			tag: "form",
+			expectedClass: &iHtml.FormClass{Kind: iHtml.FormPasswordReset, Confidence: 80, Reasons: []string{
+				`the form attributes mention "forgot"`, `the submit button reads "reset"`,
+			}},
+		},
+		{
+			name: "Should classify a password reset form asking for the new password twice",
+			htmlData: `<html><body><form><input type="password" autocomplete="new-password">` +
+				`<input type="password" autocomplete="new-password"><button>Save</button></form></body></html>`,
+			tag: "form",
+			expectedClass: &iHtml.FormClass{Kind: iHtml.FormPasswordReset, Confidence: 60, Reasons: []string{
+				"asks for the new password twice", "the password field autocompletes a new password",
+			}},
+		},
+		{
+			name: "Should classify a search form",
+			htmlData: `<html><body><form role="search" action="/results"><input name="q" placeholder="Suche">` +
+				`<button>Go</button></form></body></html>`,
+			tag: "form",
+			expectedClass: &iHtml.FormClass{Kind: iHtml.FormSearch, Confidence: 80, Reasons: []string{
+				"the form has the search role", "has a search field",
+			}},
+		},
+		{
+			name: "Should not classify a newsletter form",
+			htmlData: `<html><body><form action="/newsletter"><input type="email" name="email">` +
+				`<button>Subscribe</button></form></body></html>`,
+			tag:           "form",
+			expectedClass: nil,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, _ := html.Parse(strings.NewReader(tc.htmlData))
+			class := iHtml.ClassifyForm(findElement(doc, tc.tag), iHtml.CollectLabels(doc))
+			if !reflect.DeepEqual(tc.expectedClass, class) {
+				t.Fatalf("Expected:%+v, Got:%+v", tc.expectedClass, class)
+			}
+		})
+	}
+}
+
+func TestFormContainer(t *testing.T) {
+	tests := []*struct {
+		name       string
+		htmlData   string
+		expectedID string
+	}{
+		{
+			name: "Should return the closest ancestor having a button",
+			htmlData: `<html><body><div id="a"><div id="b"><input type="password"></div>` +
+				`<button>Go</button></div></body></html>`,
+			expectedID: "a",
+		},
+		{
+			name:       "Should return the parent if no ancestor has a button",
+			htmlData:   `<html><body><div id="a"><div id="b"><input type="password"></div></div></body></html>`,
+			expectedID: "b",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, _ := html.Parse(strings.NewReader(tc.htmlData))
+			id, _ := iHtml.Attr(iHtml.FormContainer(findElement(doc, "input")), "id")
+			if id != tc.expectedID {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedID, id)
+			}
+		})
+	}
+}
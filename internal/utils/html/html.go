@@ -1,8 +1,11 @@
 package html
 
 import (
+	"bytes"
 	"golang.org/x/net/html"
+	"mime"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -45,60 +48,133 @@ func Version(n *html.Node) string {
 	return version
 }
 
-// HasLoginForm returns whether the give form html node has a login form or not
-func HasLoginForm(n *html.Node) bool {
-	// actionValue is the action attribute of the form
-	actionValue := ""
-	for _, attr := range n.Attr {
-		if attr.Key == "action" {
-			actionValue = attr.Val
-			break
+// BaseHref returns the href of the first base element of the document, the links of the document are resolved
+// against it, returns empty if there is no base element with href
+func BaseHref(n *html.Node) string {
+	if n.Type == html.ElementNode && n.Data == "base" {
+		for _, attr := range n.Attr {
+			if attr.Key == "href" {
+				return strings.TrimSpace(attr.Val)
+			}
 		}
 	}
-	// we check if actionValue doesn't contain register and signup, that means it can be a login form
-	// then we check if the actionValue contain either login or signin
-	// return true if that is the case, otherwise we fall back to checking if the form has one username and
-	// one password input
-	if !strings.Contains(actionValue, "register") && !strings.Contains(actionValue, "signup") {
-		if strings.Contains(actionValue, "login") || strings.Contains(actionValue, "signin") {
-			return true
-		} else {
-			passwordInputFieldCount := 0
-			usernameInputFieldCount := 0
-			// iterate over all the input type fields in the form
-			for _, inputField := range InputFields(n) {
-				if inputField == "password" {
-					passwordInputFieldCount++
-				}
-				if inputField == "email" || inputField == "Text" {
-					usernameInputFieldCount++
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if href := BaseHref(c); href != "" {
+			return href
+		}
+	}
+	return ""
+}
+
+// MetaCharset returns the charset declared by the first meta element of the content declaring one, either by its
+// charset attribute or by the charset param of the content attribute of a Content-Type http-equiv meta element. The
+// content is expected to be the start of the page, it is scanned up to the body, returns empty if there is none.
+func MetaCharset(content []byte) string {
+	z := html.NewTokenizer(bytes.NewReader(content))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return ""
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if string(name) == "body" {
+				return ""
+			}
+			if string(name) != "meta" || !hasAttr {
+				continue
+			}
+			attrs := map[string]string{}
+			for more := true; more; {
+				var key, val []byte
+				key, val, more = z.TagAttr()
+				attrs[string(key)] = string(val)
+			}
+			if charset := strings.TrimSpace(attrs["charset"]); charset != "" {
+				return charset
+			}
+			if strings.EqualFold(strings.TrimSpace(attrs["http-equiv"]), "content-type") {
+				if _, params, err := mime.ParseMediaType(attrs["content"]); err == nil && params["charset"] != "" {
+					return params["charset"]
 				}
 			}
+		}
+	}
+}
+
+// Attr returns the value of the attribute of the node with the key, along with whether the node has the attribute
+func Attr(n *html.Node, key string) (string, bool) {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
+
+// Selector returns the CSS selector path of the element node from the html element, for example:
+// html > body > div#main > ul > li:nth-of-type(2) > a. The id of each element is appended along with its position
+// among the siblings of the same tag if it has some, so that the path points to the element only.
+func Selector(n *html.Node) string {
+	var segments []string
+	for e := n; e != nil && e.Type == html.ElementNode; e = e.Parent {
+		segment := e.Data
+		if id, _ := Attr(e, "id"); id != "" && !strings.ContainsAny(id, " \t\n") {
+			segment += "#" + id
+		}
 
-			// if we have one email or Text input type field and one password input type field
-			// return true
-			if usernameInputFieldCount == 1 && passwordInputFieldCount == 1 {
-				return true
+		position, count := 0, 0
+		for c := e; c != nil; c = c.PrevSibling {
+			if c.Type == html.ElementNode && c.Data == e.Data {
+				position++
 			}
 		}
+		for c := e.NextSibling; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && c.Data == e.Data {
+				count++
+			}
+		}
+		if count += position; count > 1 {
+			segment += ":nth-of-type(" + strconv.Itoa(position) + ")"
+		}
+		segments = append([]string{segment}, segments...)
 	}
-	return false
+	return strings.Join(segments, " > ")
 }
 
-// InputFields returns all the input tag types from a html node, it recursively calls itself to build the InputFields
-func InputFields(n *html.Node) []string {
-	if n.Type == html.ElementNode && n.Data == "input" {
-		var field string
+// InputField represents a field of a form
+type InputField struct {
+	Type         string // Type represents the type of the input, or select and textarea for those elements
+	Name         string // Name represents the name the field is submitted with
+	Required     bool   // Required represents if the field must be filled in to submit the form
+	Autocomplete string // Autocomplete represents the autocomplete tokens of the field
+}
+
+// InputFields returns all the input, select and textarea fields from a html node, it recursively calls itself to
+// build the InputFields. The type of an input without a type attribute is text.
+func InputFields(n *html.Node) []*InputField {
+	if n.Type == html.ElementNode && (n.Data == "input" || n.Data == "select" || n.Data == "textarea") {
+		field := &InputField{Type: n.Data}
+		if n.Data == "input" {
+			field.Type = "text"
+		}
 		for _, attr := range n.Attr {
-			if attr.Key == "type" {
-				field = attr.Val
-				break
+			switch attr.Key {
+			case "type":
+				if n.Data == "input" && strings.TrimSpace(attr.Val) != "" {
+					field.Type = strings.ToLower(strings.TrimSpace(attr.Val))
+				}
+			case "name":
+				field.Name = attr.Val
+			case "required":
+				field.Required = true
+			case "autocomplete":
+				field.Autocomplete = strings.ToLower(strings.TrimSpace(attr.Val))
 			}
 		}
-		return []string{field}
+		return []*InputField{field}
 	}
 
-	var fields []string
+	var fields []*InputField
 	for c := n.FirstChild; c != nil; c = c.NextSibling {
 		fields = append(fields, InputFields(c)...)
 	}
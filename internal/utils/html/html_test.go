@@ -3,6 +3,7 @@ package html_test
 import (
 	"go.uber.org/mock/gomock"
 	"golang.org/x/net/html"
+	"reflect"
 	"strings"
 	"testing"
 	iHtml "web-analyser/internal/utils/html"
@@ -103,6 +104,128 @@ func TestVersion(t *testing.T) {
 	}
 }
 
+func TestBaseHref(t *testing.T) {
+	tests := []*struct {
+		name         string
+		htmlData     string
+		expectedHref string
+	}{
+		{
+			name:         "Should return the href of the first base element with href",
+			htmlData:     "<html><head><base target='_blank'><base href=' /abc/ '><base href='/def/'></head></html>",
+			expectedHref: "/abc/",
+		},
+		{
+			name:         "Should return empty if there is no base element",
+			htmlData:     "<html><head><title>Title</title></head></html>",
+			expectedHref: "",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, _ := html.Parse(strings.NewReader(tc.htmlData))
+			href := iHtml.BaseHref(doc)
+			if href != tc.expectedHref {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedHref, href)
+			}
+		})
+	}
+}
+
+func TestMetaCharset(t *testing.T) {
+	tests := []*struct {
+		name            string
+		htmlData        string
+		expectedCharset string
+	}{
+		{
+			name:            "Should return the charset attribute of the meta element",
+			htmlData:        `<!DOCTYPE html><html><head><meta name="a"><meta charset=" Shift_JIS "></head></html>`,
+			expectedCharset: "Shift_JIS",
+		},
+		{
+			name: "Should return the charset of a Content-Type http-equiv meta element",
+			htmlData: `<html><head><meta http-equiv="Content-Type" content="text/html; charset=windows-1251">` +
+				`<meta charset="utf-8"></head></html>`,
+			expectedCharset: "windows-1251",
+		},
+		{
+			name:            "Should return empty if the charset is declared in the body",
+			htmlData:        `<html><head><title>Google</title></head><body><meta charset="utf-8"></body></html>`,
+			expectedCharset: "",
+		},
+		{
+			name:            "Should return empty if no charset is declared",
+			htmlData:        `<html><head><meta http-equiv="refresh" content="5"></head></html>`,
+			expectedCharset: "",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			charset := iHtml.MetaCharset([]byte(tc.htmlData))
+			if charset != tc.expectedCharset {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedCharset, charset)
+			}
+		})
+	}
+}
+
+func TestAttr(t *testing.T) {
+	tests := []*struct {
+		name          string
+		key           string
+		expectedValue string
+		expectedOk    bool
+	}{
+		{name: "Should return the value of the attribute", key: "alt", expectedValue: "Logo", expectedOk: true},
+		{name: "Should return the empty value of the attribute", key: "title", expectedValue: "", expectedOk: true},
+		{name: "Should return false if there is no attribute", key: "width", expectedValue: "", expectedOk: false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, _ := html.Parse(strings.NewReader("<html><body><img alt='Logo' title=''></body></html>"))
+			img := doc.FirstChild.LastChild.FirstChild
+			value, ok := iHtml.Attr(img, tc.key)
+			if value != tc.expectedValue || ok != tc.expectedOk {
+				t.Fatalf("Expected:%v %v, Got:%v %v", tc.expectedValue, tc.expectedOk, value, ok)
+			}
+		})
+	}
+}
+
+func TestSelector(t *testing.T) {
+	tests := []*struct {
+		name             string
+		htmlData         string
+		expectedSelector string
+	}{
+		{
+			name:             "Should return the path of the element from the html element",
+			htmlData:         "<html><body><main><p><a href='/'>Home</a></p></main></body></html>",
+			expectedSelector: "html > body > main > p > a",
+		},
+		{
+			name:             "Should append the id and the position among the siblings of the same tag",
+			htmlData:         "<html><body><ul id='menu'><li>One</li><p></p><li><a href='/'>Two</a></li></ul></body></html>",
+			expectedSelector: "html > body > ul#menu > li:nth-of-type(2) > a",
+		},
+		{
+			name:             "Should not append an id with spaces",
+			htmlData:         "<html><body><div id='a b'><a href='/'>Home</a></div><div></div></body></html>",
+			expectedSelector: "html > body > div:nth-of-type(1) > a",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, _ := html.Parse(strings.NewReader(tc.htmlData))
+			selector := iHtml.Selector(findElement(doc, "a"))
+			if selector != tc.expectedSelector {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedSelector, selector)
+			}
+		})
+	}
+}
+
 func TestHasLoginForm(t *testing.T) {
 	tests := []*struct {
 		name           string
@@ -159,6 +282,40 @@ func TestHasLoginForm(t *testing.T) {
 	}
 }
 
+func TestInputFields(t *testing.T) {
+	tests := []*struct {
+		name           string
+		htmlData       string
+		expectedFields []*iHtml.InputField
+	}{
+		{
+			name: "Should return the type, name, required and autocomplete of each field",
+			htmlData: `<html><form><input name="user" required autocomplete="Username"><div>` +
+				`<input type="PASSWORD" name="pw"></div><select name="lang"></select><textarea></textarea></form></html>`,
+			expectedFields: []*iHtml.InputField{
+				{Type: "text", Name: "user", Required: true, Autocomplete: "username"},
+				{Type: "password", Name: "pw"},
+				{Type: "select", Name: "lang"},
+				{Type: "textarea"},
+			},
+		},
+		{
+			name:           "Should return nil if no field is present",
+			htmlData:       "<html><form><button>Go</button></form></html>",
+			expectedFields: nil,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, _ := html.Parse(strings.NewReader(tc.htmlData))
+			fields := iHtml.InputFields(findElement(doc, "form"))
+			if !reflect.DeepEqual(tc.expectedFields, fields) {
+				t.Fatalf("Expected:%+v, Got:%+v", tc.expectedFields, fields)
+			}
+		})
+	}
+}
+
 // findFormNode finds the first form node
 func findFormNode(n *html.Node) *html.Node {
 	if n.Type != html.ElementNode {
@@ -179,3 +336,16 @@ func findFormNode(n *html.Node) *html.Node {
 	}
 	return formNode
 }
+
+// findElement finds the first element with the tag
+func findElement(n *html.Node, tag string) *html.Node {
+	if n.Type == html.ElementNode && n.Data == tag {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if e := findElement(c, tag); e != nil {
+			return e
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package html
+
+import (
+	"encoding/xml"
+	"golang.org/x/net/html"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// xmlNamespaces maps the XML namespaces of the elements and attributes to the namespaces of the html node tree, the
+// XHTML elements have no namespace like the elements of an HTML page
+var xmlNamespaces = map[string]string{
+	"http://www.w3.org/1999/xhtml":         "",
+	"http://www.w3.org/2000/svg":           "svg",
+	"http://www.w3.org/1998/Math/MathML":   "math",
+	"http://www.w3.org/1999/xlink":         "xlink",
+	"http://www.w3.org/XML/1998/namespace": "xml",
+}
+
+// doctypeRegex matches the name and the public and system identifiers of a doctype declaration
+var doctypeRegex = regexp.MustCompile(`(?is)^doctype\s+(\S+)(?:\s+public\s+["']([^"']*)["'](?:\s+["']([^"']*)["'])?` +
+	`|\s+system\s+["']([^"']*)["'])?`)
+
+// ParseXHTML parses the XHTML page, which has to be well-formed XML, and returns the html node tree of the page like
+// html.Parse does. The elements of the XHTML namespace have no namespace while the SVG and MathML elements have the
+// svg and math namespaces. The reader is expected to be decoded to UTF-8, the encoding declared by the XML
+// declaration is ignored.
+func ParseXHTML(r io.Reader) (*html.Node, error) {
+	d := xml.NewDecoder(r)
+	d.Entity = xml.HTMLEntity
+	d.CharsetReader = func(label string, input io.Reader) (io.Reader, error) { return input, nil }
+
+	doc := &html.Node{Type: html.DocumentNode}
+	parent := doc
+	for {
+		token, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			n := &html.Node{Type: html.ElementNode, Data: t.Name.Local, Namespace: xmlNamespace(t.Name.Space)}
+			for _, attr := range t.Attr {
+				n.Attr = append(n.Attr, xhtmlAttr(attr))
+			}
+			parent.AppendChild(n)
+			parent = n
+		case xml.EndElement:
+			parent = parent.Parent
+		case xml.CharData:
+			// the whitespace between the elements out of the root element isn't part of the page
+			if parent != doc {
+				parent.AppendChild(&html.Node{Type: html.TextNode, Data: string(t)})
+			}
+		case xml.Comment:
+			parent.AppendChild(&html.Node{Type: html.CommentNode, Data: string(t)})
+		case xml.Directive:
+			if n := doctype(string(t)); n != nil {
+				parent.AppendChild(n)
+			}
+		}
+	}
+	return doc, nil
+}
+
+// xmlNamespace returns the namespace of the html node tree for the XML namespace, the unknown namespaces are kept
+func xmlNamespace(space string) string {
+	if namespace, ok := xmlNamespaces[space]; ok {
+		return namespace
+	}
+	return space
+}
+
+// xhtmlAttr returns the attribute of the html node tree for the XML attribute, the namespace declarations are kept as
+// xmlns and xmlns:prefix attributes like html.Parse does
+func xhtmlAttr(attr xml.Attr) html.Attribute {
+	switch {
+	case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+		return html.Attribute{Key: "xmlns", Val: attr.Value}
+	case attr.Name.Space == "xmlns":
+		return html.Attribute{Key: "xmlns:" + attr.Name.Local, Val: attr.Value}
+	}
+	return html.Attribute{Namespace: xmlNamespace(attr.Name.Space), Key: attr.Name.Local, Val: attr.Value}
+}
+
+// doctype returns the doctype node of the directive along with its public and system identifiers like html.Parse
+// does, returns nil if the directive isn't a doctype declaration
+func doctype(directive string) *html.Node {
+	matches := doctypeRegex.FindStringSubmatch(strings.TrimSpace(directive))
+	if matches == nil {
+		return nil
+	}
+	n := &html.Node{Type: html.DoctypeNode, Data: strings.ToLower(matches[1])}
+	if matches[2] != "" {
+		n.Attr = append(n.Attr, html.Attribute{Key: "public", Val: matches[2]})
+	}
+	if system := matches[3] + matches[4]; system != "" {
+		n.Attr = append(n.Attr, html.Attribute{Key: "system", Val: system})
+	}
+	return n
+}
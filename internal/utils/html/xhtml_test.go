@@ -0,0 +1,76 @@
+package html_test
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+	iHtml "web-analyser/internal/utils/html"
+)
+
+func TestParseXHTML(t *testing.T) {
+	tests := []*struct {
+		name              string
+		xhtmlData         string
+		expectedVersion   string
+		expectedTitle     string
+		expectedNamespace string
+		expectedSelector  string
+		expectedError     bool
+	}{
+		{
+			name: "Should build the html node tree of the XHTML page",
+			xhtmlData: `<?xml version="1.0" encoding="ISO-8859-1"?>` + "\n" +
+				`<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Strict//EN" ` +
+				`"http://www.w3.org/TR/xhtml1/DTD/xhtml1-strict.dtd">` + "\n" +
+				`<html xmlns="http://www.w3.org/1999/xhtml" xmlns:svg="http://www.w3.org/2000/svg" xml:lang="en">` +
+				`<head><title>Caf&eacute; &amp; Bar</title></head><body><p id="a"><a href="/">Home</a></p>` +
+				`<svg:svg><svg:a href="/"/></svg:svg></body></html>`,
+			expectedVersion:   "xhtml 1.0",
+			expectedTitle:     "Café & Bar",
+			expectedNamespace: "",
+			expectedSelector:  "html > body > p#a > a",
+		},
+		{
+			name: "Should keep the namespace of the SVG elements",
+			xhtmlData: `<html xmlns="http://www.w3.org/1999/xhtml"><head><title></title></head><body>` +
+				`<svg xmlns="http://www.w3.org/2000/svg"><a href="/"/></svg></body></html>`,
+			expectedVersion:   "unknown",
+			expectedNamespace: "svg",
+			expectedSelector:  "html > body > svg > a",
+		},
+		{
+			name:          "Should return an error if the XHTML page is not well-formed",
+			xhtmlData:     `<html xmlns="http://www.w3.org/1999/xhtml"><body><p>Home</body></html>`,
+			expectedError: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := iHtml.ParseXHTML(strings.NewReader(tc.xhtmlData))
+			if (err != nil) != tc.expectedError {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedError, err)
+			}
+			if tc.expectedError {
+				return
+			}
+
+			version := "unknown"
+			if doc.FirstChild != nil && doc.FirstChild.Type == html.DoctypeNode {
+				version = iHtml.Version(doc.FirstChild)
+			}
+			if version != tc.expectedVersion {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedVersion, version)
+			}
+			if title := iHtml.Text(findElement(doc, "title")); title != tc.expectedTitle {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedTitle, title)
+			}
+			a := findElement(doc, "a")
+			if a.Namespace != tc.expectedNamespace {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedNamespace, a.Namespace)
+			}
+			if selector := iHtml.Selector(a); selector != tc.expectedSelector {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedSelector, selector)
+			}
+		})
+	}
+}
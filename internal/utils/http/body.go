@@ -0,0 +1,155 @@
+package http
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"github.com/andybalholm/brotli"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// AcceptEncoding is the Accept-Encoding header of the outbound requests, the client decompresses the bodies itself so
+// that the decompressed size can be limited
+const AcceptEncoding = "gzip, deflate, br"
+
+// minBombSize is the decompressed size in bytes the compression ratio of a body is checked from, the ratio of the
+// first blocks can be high even for the bodies which aren't decompression bombs
+const minBombSize = 1 << 20
+
+// Body is the body of the responses of the client, it's decompressed as per the Content-Encoding header and it's
+// truncated once it reaches the max body size or once the compression ratio exceeds the max compression ratio, so
+// that an endless body or a decompression bomb can't exhaust the memory
+type Body struct {
+	body       io.ReadCloser
+	encodings  []string
+	compressed *countingReader
+	reader     io.Reader
+	err        error
+	n          int64
+	maxSize    int64
+	maxRatio   int64
+	truncated  bool
+}
+
+// newBody returns the body of the response decompressed and limited to the max size and the max compression ratio,
+// the limits are disabled if 0. The Content-Encoding and Content-Length headers of the response are removed like the
+// http.Transport does when it decompresses the body itself.
+func newBody(resp *http.Response, maxSize int64, maxRatio int64) *Body {
+	b := &Body{body: resp.Body, maxSize: maxSize, maxRatio: maxRatio}
+	for _, encoding := range strings.Split(resp.Header.Get("Content-Encoding"), ",") {
+		if encoding = strings.ToLower(strings.TrimSpace(encoding)); encoding != "" && encoding != "identity" {
+			b.encodings = append(b.encodings, encoding)
+		}
+	}
+	if len(b.encodings) > 0 {
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
+		resp.ContentLength = -1
+		resp.Uncompressed = true
+	}
+	return b
+}
+
+// Read reads the decompressed body up to the limits, the body is flagged as truncated if it goes on beyond them
+func (b *Body) Read(p []byte) (int, error) {
+	if b.reader == nil && b.err == nil {
+		b.reader, b.err = b.decoder()
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	if b.truncated {
+		return 0, io.EOF
+	}
+	if b.maxSize > 0 && b.n >= b.maxSize {
+		// the body is truncated only if there is at least a byte beyond the max size
+		var next [1]byte
+		n, _ := io.ReadFull(b.reader, next[:])
+		b.truncated = n > 0
+		return 0, io.EOF
+	}
+
+	if b.maxSize > 0 && int64(len(p)) > b.maxSize-b.n {
+		p = p[:b.maxSize-b.n]
+	}
+	n, err := b.reader.Read(p)
+	b.n += int64(n)
+	if b.compressed != nil && b.maxRatio > 0 && b.n > minBombSize && b.n > b.compressed.n*b.maxRatio {
+		b.truncated = true
+	}
+	return n, err
+}
+
+// Close closes the body of the response
+func (b *Body) Close() error {
+	return b.body.Close()
+}
+
+// Truncated checks if the body was cut short because of the max body size or the max compression ratio
+func (b *Body) Truncated() bool {
+	return b.truncated
+}
+
+// IsTruncated checks if the body of the response was cut short by the client, see Body
+func IsTruncated(resp *http.Response) bool {
+	b, ok := resp.Body.(*Body)
+	return ok && b.Truncated()
+}
+
+// decoder returns the reader decompressing the body, the encodings are undone in the reverse order they were applied
+func (b *Body) decoder() (io.Reader, error) {
+	if len(b.encodings) == 0 {
+		return b.body, nil
+	}
+
+	b.compressed = &countingReader{r: b.body}
+	var r io.Reader = b.compressed
+	for i := len(b.encodings) - 1; i >= 0; i-- {
+		var err error
+		switch b.encodings[i] {
+		case "gzip", "x-gzip":
+			r, err = gzip.NewReader(r)
+		case "deflate":
+			r, err = deflateReader(r)
+		case "br":
+			r = brotli.NewReader(r)
+		default:
+			err = errors.New("unsupported content encoding: " + b.encodings[i])
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// deflateReader returns the reader decompressing the deflate body, which should be zlib-wrapped as per RFC 9110, but
+// the raw deflate bodies sent by some servers are decompressed as well
+func deflateReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+	// the zlib header declares the deflate method and is a multiple of 31, see RFC 1950
+	if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+// countingReader counts the bytes read from the reader
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,220 @@
+package http_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"github.com/andybalholm/brotli"
+	"io"
+	netHttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"web-analyser/config"
+	"web-analyser/internal/utils/http"
+)
+
+// page is the body the servers of the tests stream, repeated as many times as needed
+const page = "<p>web analyser</p>"
+
+// compressors compress the bodies as per the Content-Encoding
+var compressors = map[string]func(w io.Writer) io.WriteCloser{
+	"":        func(w io.Writer) io.WriteCloser { return nopWriteCloser{w} },
+	"gzip":    func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+	"deflate": func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+	"br":      func(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) },
+	"raw": func(w io.Writer) io.WriteCloser {
+		fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+		return fw
+	},
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// streamServer returns a server streaming the page repeated count times compressed as per the encoding, the page
+// is repeated until the client goes away if count is negative
+func streamServer(encoding string, count int) *httptest.Server {
+	return httptest.NewServer(netHttp.HandlerFunc(func(w netHttp.ResponseWriter, r *netHttp.Request) {
+		if encoding != "" {
+			// the raw deflate bodies are sent as deflate like some servers do
+			w.Header().Set("Content-Encoding", strings.Replace(encoding, "raw", "deflate", 1))
+		}
+		cw := compressors[encoding](w)
+		for i := 0; count < 0 || i < count; i++ {
+			if _, err := io.WriteString(cw, page); err != nil || r.Context().Err() != nil {
+				return
+			}
+			// the endless bodies are flushed every 100 pages so that the client gets them as they are streamed
+			if f, ok := cw.(interface{ Flush() error }); ok && count < 0 && i%100 == 99 {
+				f.Flush()
+				w.(netHttp.Flusher).Flush()
+			}
+		}
+		cw.Close()
+	}))
+}
+
+func TestClientImpl_Get_Body(t *testing.T) {
+	tests := []*struct {
+		name              string
+		encoding          string
+		count             int
+		maxBodySize       int64
+		expectedBody      string
+		expectedTruncated bool
+	}{
+		{
+			name:         "Should read the whole body below the max body size",
+			count:        10,
+			maxBodySize:  1000,
+			expectedBody: strings.Repeat(page, 10),
+		},
+		{
+			name:         "Should not truncate the body of the max body size",
+			count:        10,
+			maxBodySize:  int64(len(page) * 10),
+			expectedBody: strings.Repeat(page, 10),
+		},
+		{
+			name:              "Should truncate an endless body at the max body size",
+			count:             -1,
+			maxBodySize:       1000,
+			expectedBody:      strings.Repeat(page, 100)[:1000],
+			expectedTruncated: true,
+		},
+		{
+			name:         "Should read the whole body without a max body size",
+			count:        1000,
+			expectedBody: strings.Repeat(page, 1000),
+		},
+		{
+			name:         "Should decompress a gzip body",
+			encoding:     "gzip",
+			count:        10,
+			maxBodySize:  1000,
+			expectedBody: strings.Repeat(page, 10),
+		},
+		{
+			name:         "Should decompress a deflate body",
+			encoding:     "deflate",
+			count:        10,
+			maxBodySize:  1000,
+			expectedBody: strings.Repeat(page, 10),
+		},
+		{
+			name:         "Should decompress a raw deflate body",
+			encoding:     "raw",
+			count:        10,
+			maxBodySize:  1000,
+			expectedBody: strings.Repeat(page, 10),
+		},
+		{
+			name:         "Should decompress a brotli body",
+			encoding:     "br",
+			count:        10,
+			maxBodySize:  1000,
+			expectedBody: strings.Repeat(page, 10),
+		},
+		{
+			name:              "Should truncate an endless gzip body at the max decompressed size",
+			encoding:          "gzip",
+			count:             -1,
+			maxBodySize:       1000,
+			expectedBody:      strings.Repeat(page, 100)[:1000],
+			expectedTruncated: true,
+		},
+		{
+			name:              "Should truncate an endless brotli body at the max decompressed size",
+			encoding:          "br",
+			count:             -1,
+			maxBodySize:       1000,
+			expectedBody:      strings.Repeat(page, 100)[:1000],
+			expectedTruncated: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := streamServer(tc.encoding, tc.count)
+			defer server.Close()
+
+			client, _ := http.NewHttpClient(&config.ClientConf{AllowedAddresses: []string{"127.0.0.1"},
+				MaxBodySize: tc.maxBodySize})
+			resp, err := client.Get(context.Background(), server.URL)
+			if err != nil {
+				t.Fatalf("Expected:%v, Got:%v", nil, err)
+			}
+			b, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			if err != nil {
+				t.Fatalf("Expected:%v, Got:%v", nil, err)
+			}
+			if string(b) != tc.expectedBody {
+				t.Fatalf("Expected:%v, Got:%v", len(tc.expectedBody), len(b))
+			}
+			if truncated := http.IsTruncated(resp); truncated != tc.expectedTruncated {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedTruncated, truncated)
+			}
+			if encoding := resp.Header.Get("Content-Encoding"); encoding != "" {
+				t.Fatalf("Expected:%v, Got:%v", "", encoding)
+			}
+		})
+	}
+}
+
+func TestClientImpl_Get_DecompressionBomb(t *testing.T) {
+	// 16 MiB of zeros compress to about 16 KiB
+	size := 16 << 20
+	var bomb bytes.Buffer
+	gw, _ := gzip.NewWriterLevel(&bomb, gzip.BestCompression)
+	gw.Write(make([]byte, size))
+	gw.Close()
+
+	server := httptest.NewServer(netHttp.HandlerFunc(func(w netHttp.ResponseWriter, r *netHttp.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(bomb.Bytes())
+	}))
+	defer server.Close()
+
+	client, _ := http.NewHttpClient(&config.ClientConf{AllowedAddresses: []string{"127.0.0.1"},
+		MaxCompressionRatio: 100})
+	resp, err := client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Expected:%v, Got:%v", nil, err)
+	}
+	n, err := io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	// the body is truncated once the ratio exceeds the max compression ratio, long before it is fully decompressed
+	if err != nil || n >= int64(size)/4 || !http.IsTruncated(resp) {
+		t.Fatalf("Expected:%v, Got:%v %v %v", "truncated", n, err, http.IsTruncated(resp))
+	}
+}
+
+func TestClientImpl_Get_UnsupportedEncoding(t *testing.T) {
+	server := httptest.NewServer(netHttp.HandlerFunc(func(w netHttp.ResponseWriter, r *netHttp.Request) {
+		if encoding := r.Header.Get("Accept-Encoding"); encoding != http.AcceptEncoding {
+			t.Errorf("Expected:%v, Got:%v", http.AcceptEncoding, encoding)
+		}
+		w.Header().Set("Content-Encoding", "zstd")
+		io.WriteString(w, page)
+	}))
+	defer server.Close()
+
+	client, _ := http.NewHttpClient(&config.ClientConf{AllowedAddresses: []string{"127.0.0.1"}})
+	resp, err := client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Expected:%v, Got:%v", nil, err)
+	}
+	_, err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err == nil || err.Error() != "unsupported content encoding: zstd" {
+		t.Fatalf("Expected:%v, Got:%v", "unsupported content encoding: zstd", err)
+	}
+}
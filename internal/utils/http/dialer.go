@@ -0,0 +1,182 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ErrBlockedAddress is returned when the client is not allowed to connect to the address of the host
+var ErrBlockedAddress = errors.New("blocked address")
+
+// blockedNetworks are the networks which are not reachable from the internet, connecting to them would allow the
+// users to reach the internal services and the cloud metadata endpoints, for example: http://169.254.169.254
+var blockedNetworks = parseNetworks(
+	"0.0.0.0/8",      // this network
+	"10.0.0.0/8",     // private
+	"100.64.0.0/10",  // carrier grade nat, includes the alibaba cloud metadata 100.100.100.200
+	"127.0.0.0/8",    // loopback
+	"169.254.0.0/16", // link local, includes the aws, gcp and azure metadata 169.254.169.254
+	"172.16.0.0/12",  // private
+	"192.0.0.0/24",   // ietf protocol assignments
+	"192.168.0.0/16", // private
+	"198.18.0.0/15",  // benchmarking
+	"224.0.0.0/4",    // multicast
+	"240.0.0.0/4",    // reserved, includes the broadcast 255.255.255.255
+	"::/128",         // unspecified
+	"::1/128",        // loopback
+	"64:ff9b::/96",   // ipv4 translation, could map to any of the blocked ipv4 networks
+	"fc00::/7",       // unique local, includes the aws metadata fd00:ec2::254
+	"fe80::/10",      // link local
+	"ff00::/8",       // multicast
+)
+
+// AddressFilter decides which addresses the http client is allowed to connect to. The denied hosts and networks
+// are always blocked, the allowed hosts and networks are allowed even if they are part of the blocked networks.
+type AddressFilter struct {
+	allowedHosts    map[string]struct{}
+	allowedNetworks []*net.IPNet
+	deniedHosts     map[string]struct{}
+	deniedNetworks  []*net.IPNet
+}
+
+// NewAddressFilter creates a new instance of AddressFilter, each of the allowed and denied entries is either a
+// CIDR, an IP address or a hostname
+func NewAddressFilter(allowed []string, denied []string) *AddressFilter {
+	f := &AddressFilter{}
+	f.allowedHosts, f.allowedNetworks = splitAddresses(allowed)
+	f.deniedHosts, f.deniedNetworks = splitAddresses(denied)
+	return f
+}
+
+// AllowsHost checks if the host can be connected to before resolving it
+func (f *AddressFilter) AllowsHost(host string) bool {
+	_, denied := f.deniedHosts[strings.ToLower(host)]
+	return !denied
+}
+
+// AllowsIP checks if the IP address the host resolved to can be connected to
+func (f *AddressFilter) AllowsIP(host string, ip net.IP) bool {
+	if !f.AllowsHost(host) || containsIP(f.deniedNetworks, ip) {
+		return false
+	}
+	if _, ok := f.allowedHosts[strings.ToLower(host)]; ok || containsIP(f.allowedNetworks, ip) {
+		return true
+	}
+	return !containsIP(blockedNetworks, ip)
+}
+
+// resolver resolves the host to its IP addresses
+type resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// contextDialer connects to the address, either directly or through a proxy, bound to the context
+type contextDialer interface {
+	DialContext(ctx context.Context, network string, address string) (net.Conn, error)
+}
+
+// filteredDialer is a dialer which connects only to the addresses allowed by the filter. The host is resolved once
+// and the connection is made to the checked IP address, so that the host can't resolve to a different address
+// between the check and the connection, i.e. dns rebinding. Since every request, including the redirects, dials a
+// new connection or reuses one made to a checked address, each redirect is checked as well. The connection is made
+// through the dialer, which is given the checked IP address even if it connects through a proxy.
+type filteredDialer struct {
+	filter   *AddressFilter
+	resolver resolver
+	dialer   contextDialer
+}
+
+// DialContext resolves the host of the address and connects to the first allowed IP address it resolves to
+func (d *filteredDialer) DialContext(ctx context.Context, network string, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	ipAddrs, err := d.resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	err = &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	for _, ipAddr := range ipAddrs {
+		var conn net.Conn
+		if conn, err = d.dialer.DialContext(ctx, network, net.JoinHostPort(ipAddr.IP.String(), port)); err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
+
+// resolve resolves the host and returns its IP addresses, it returns an ErrBlockedAddress error unless the host and
+// all its addresses are allowed, since a host resolving to both public and internal addresses can't be trusted
+func (d *filteredDialer) resolve(ctx context.Context, host string) ([]net.IPAddr, error) {
+	if !d.filter.AllowsHost(host) {
+		return nil, fmt.Errorf("%w: %v", ErrBlockedAddress, host)
+	}
+
+	ipAddrs, err := d.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	for _, ipAddr := range ipAddrs {
+		if !d.filter.AllowsIP(host, ipAddr.IP) {
+			return nil, fmt.Errorf("%w: %v resolves to %v", ErrBlockedAddress, host, ipAddr.IP)
+		}
+	}
+	return ipAddrs, nil
+}
+
+// splitAddresses splits the addresses into the hostnames and the networks, the IP addresses are converted into
+// networks containing only that address
+func splitAddresses(addresses []string) (map[string]struct{}, []*net.IPNet) {
+	hosts := map[string]struct{}{}
+	var networks []*net.IPNet
+	for _, address := range addresses {
+		address = strings.ToLower(strings.TrimSpace(address))
+		if address == "" {
+			continue
+		}
+		if _, network, err := net.ParseCIDR(address); err == nil {
+			networks = append(networks, network)
+		} else if ip := net.ParseIP(address); ip != nil {
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		} else {
+			hosts[address] = struct{}{}
+		}
+	}
+	return hosts, networks
+}
+
+// parseNetworks parses the CIDRs, it panics in case of an invalid CIDR since they are hardcoded
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// containsIP checks if any of the networks contains the IP, the IPv4-mapped IPv6 addresses, for example:
+// ::ffff:127.0.0.1, are matched against the IPv4 networks
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package http_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	netHttp "net/http"
+	"net/http/httptest"
+	"testing"
+	"web-analyser/config"
+	"web-analyser/internal/utils/http"
+)
+
+func TestAddressFilter_AllowsIP(t *testing.T) {
+	tests := []*struct {
+		name    string
+		host    string
+		ip      string
+		allowed []string
+		denied  []string
+		expect  bool
+	}{
+		{name: "public ipv4", host: "google.com", ip: "142.250.185.78", expect: true},
+		{name: "public ipv6", host: "google.com", ip: "2a00:1450:4001:80e::200e", expect: true},
+		{name: "loopback ipv4", host: "localhost", ip: "127.0.0.1"},
+		{name: "loopback ipv6", host: "localhost", ip: "::1"},
+		{name: "private", host: "internal", ip: "10.1.2.3"},
+		{name: "metadata", host: "169.254.169.254", ip: "169.254.169.254"},
+		{name: "ipv6 metadata", host: "fd00:ec2::254", ip: "fd00:ec2::254"},
+		{name: "ipv6 link local", host: "fe80::1", ip: "fe80::1"},
+		{name: "ipv4 mapped ipv6 loopback", host: "::ffff:127.0.0.1", ip: "::ffff:127.0.0.1"},
+		{name: "unspecified", host: "0.0.0.0", ip: "0.0.0.0"},
+		{name: "allowed ip", host: "localhost", ip: "127.0.0.1", allowed: []string{"127.0.0.1"}, expect: true},
+		{name: "allowed network", host: "internal", ip: "10.1.2.3", allowed: []string{"10.0.0.0/8"}, expect: true},
+		{name: "allowed host", host: "Internal", ip: "10.1.2.3", allowed: []string{"internal"}, expect: true},
+		{name: "denied host", host: "google.com", ip: "142.250.185.78", denied: []string{"GOOGLE.com"}},
+		{name: "denied network", host: "google.com", ip: "142.250.185.78", denied: []string{"142.250.0.0/16"}},
+		{name: "denied wins over allowed", host: "localhost", ip: "127.0.0.1", allowed: []string{"localhost"},
+			denied: []string{"127.0.0.1"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			filter := http.NewAddressFilter(tc.allowed, tc.denied)
+			allowed := filter.AllowsIP(tc.host, net.ParseIP(tc.ip))
+			if allowed != tc.expect {
+				t.Fatalf("Expected:%v, Got:%v", tc.expect, allowed)
+			}
+		})
+	}
+}
+
+func TestNewHttpClient(t *testing.T) {
+	server := httptest.NewServer(netHttp.HandlerFunc(func(w netHttp.ResponseWriter, r *netHttp.Request) {
+		if r.URL.Path == "/metadata" {
+			netHttp.Redirect(w, r, "http://169.254.169.254/latest/meta-data", netHttp.StatusFound)
+			return
+		}
+		w.WriteHeader(netHttp.StatusOK)
+	}))
+	defer server.Close()
+
+	tests := []*struct {
+		name        string
+		url         string
+		conf        *config.ClientConf
+		expectBlock bool
+	}{
+		{
+			name:        "Should block the loopback address by default",
+			url:         server.URL,
+			conf:        &config.ClientConf{},
+			expectBlock: true,
+		},
+		{
+			name: "Should load the allowed address",
+			url:  server.URL,
+			conf: &config.ClientConf{AllowedAddresses: []string{"127.0.0.0/8"}},
+		},
+		{
+			name:        "Should block the denied host before resolving it",
+			url:         "http://localhost.invalid/",
+			conf:        &config.ClientConf{DeniedAddresses: []string{"localhost.invalid"}},
+			expectBlock: true,
+		},
+		{
+			name:        "Should block the redirect to an internal address",
+			url:         server.URL + "/metadata",
+			conf:        &config.ClientConf{AllowedAddresses: []string{"127.0.0.1"}},
+			expectBlock: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client, _ := http.NewHttpClient(tc.conf)
+			resp, err := client.Get(context.Background(), tc.url)
+			if resp != nil {
+				resp.Body.Close()
+			}
+			if blocked := errors.Is(err, http.ErrBlockedAddress); blocked != tc.expectBlock {
+				t.Fatalf("Expected:%v, Got:%v %v", tc.expectBlock, blocked, err)
+			}
+			if !tc.expectBlock && err != nil {
+				t.Fatalf("Expected:%v, Got:%v", nil, err)
+			}
+		})
+	}
+}
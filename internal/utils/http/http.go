@@ -1,30 +1,217 @@
 package http
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"golang.org/x/net/proxy"
+	"mime"
+	"net"
 	"net/http"
-	"regexp"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"web-analyser/config"
+	iCtx "web-analyser/internal/utils/ctx"
 )
 
+// Client sends the outbound requests, each request is bound to its context so that it is cancelled along with the
+// context and it forwards the request id of the context in the X-Request-ID header
 type Client interface {
-	Get(url string) (resp *http.Response, err error)
+	Get(ctx context.Context, url string) (*http.Response, error)
+	Do(req *http.Request) (*http.Response, error)
 }
 
-const urlPattern = `http(s?)(:\/\/)((www\.)?)(([^.]+)\.)?([a-zA-z0-9\-_]+)(\.[a-zA-z0-9\-_]+)(\/[^\s]*)?`
+const (
+	ContentTypeJSON = "application/json"
+	ContentTypeHTML = "text/html"
 
-// NewHttpClient returns a new http client
-func NewHttpClient(c *config.ClientConf) *http.Client {
-	return &http.Client{
-		Timeout: c.Timeout,
+	// HeaderRequestID is the header carrying the id of the request, received from the callers and forwarded to the
+	// outbound requests
+	HeaderRequestID = "X-Request-ID"
+)
+
+type ClientImpl struct {
+	client              *http.Client
+	userAgent           string
+	headers             http.Header
+	maxBodySize         int64
+	maxCompressionRatio int64
+}
+
+// NewHttpClient returns a new http client, it connects only to the addresses allowed by the allowed and denied
+// addresses of the config, see AddressFilter, and it limits the response bodies as per the config, see Body. It
+// returns an error if the headers, the proxy or the CA file of the config are invalid.
+func NewHttpClient(c *config.ClientConf) (*ClientImpl, error) {
+	client, err := newHttpClient(c)
+	if err != nil {
+		return nil, err
+	}
+	headers, err := ParseHeaders(c.Headers)
+	if err != nil {
+		return nil, fmt.Errorf("invalid client headers: %w", err)
+	}
+	return &ClientImpl{client: client, userAgent: c.UserAgent, headers: headers, maxBodySize: c.MaxBodySize,
+		maxCompressionRatio: c.MaxCompressionRatio}, nil
+}
+
+// NewNoRedirectHttpClient returns a new http client which doesn't follow the redirects, the redirect response is
+// returned instead so that the caller can follow and track the redirects itself
+func NewNoRedirectHttpClient(c *config.ClientConf) (*ClientImpl, error) {
+	client, err := NewHttpClient(c)
+	if err != nil {
+		return nil, err
+	}
+	client.client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return client, nil
+}
+
+// Get sends a GET request to the url bound to the context
+func (c *ClientImpl) Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
 	}
+	return c.Do(req)
 }
 
-// IsValidURL checks if the given string is a expect URL using regex
-func IsValidURL(input string) bool {
-	// Using a basic URL regex, examples: http://abc.def.com, http://www.abc.def.com/abc
-	match, err := regexp.MatchString(urlPattern, input)
+// Do sends the request, setting the X-Request-ID header to the request id of its context and the Accept-Encoding
+// header unless they are already set. The request options of the context take precedence over the user agent and the
+// headers of the config, see WithRequestOptions. The body of the response is decompressed and limited, see Body.
+func (c *ClientImpl) Do(req *http.Request) (*http.Response, error) {
+	// the request of the caller is not modified
+	req = req.Clone(req.Context())
+	if requestID := iCtx.RequestID(req.Context()); requestID != "" && req.Header.Get(HeaderRequestID) == "" {
+		req.Header.Set(HeaderRequestID, requestID)
+	}
+	if req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", AcceptEncoding)
+	}
+	settings := settingsFrom(req.Context())
+	if settings != nil {
+		settings.apply(req)
+	}
+	if c.userAgent != "" && req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	for name, values := range c.headers {
+		if _, ok := req.Header[name]; !ok {
+			req.Header[name] = slices.Clone(values)
+		}
+	}
+
+	resp, err := c.client.Do(req)
 	if err != nil {
-		return false
+		return resp, err
+	}
+	if settings != nil {
+		settings.jar.SetCookies(resp.Request.URL, resp.Cookies())
+	}
+	resp.Body = newBody(resp, c.maxBodySize, c.maxCompressionRatio)
+	return resp, nil
+}
+
+// newHttpClient returns the http client connecting only to the allowed addresses, either directly or through the
+// http, https or socks5 proxy of the config
+func newHttpClient(c *config.ClientConf) (*http.Client, error) {
+	netDialer := &net.Dialer{Timeout: c.Timeout}
+	dialer := &filteredDialer{
+		filter:   NewAddressFilter(c.AllowedAddresses, c.DeniedAddresses),
+		resolver: net.DefaultResolver,
+		dialer:   netDialer,
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	// the proxy of the environment is ignored, only the proxy of the config is used
+	transport.Proxy = nil
+	// the bodies are decompressed by the client, see Body
+	transport.DisableCompression = true
+
+	if c.CAFile != "" || c.InsecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: c.InsecureSkipVerify}
+	}
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client CA file: %w", err)
+		}
+		// the certificates of the CA file are trusted on top of the ones of the system
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("invalid client CA file: no certificate found in %v", c.CAFile)
+		}
+		transport.TLSClientConfig.RootCAs = pool
+	}
+
+	if c.ProxyURL != "" {
+		proxyURL, err := url.Parse(c.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client proxy: %w", err)
+		}
+		// the hosts are resolved and checked by the client and the proxy is asked to connect to the checked IP
+		// addresses, rather than being handed over the hosts it would resolve itself, while the proxy itself, which
+		// is set by the config, is trusted even if internal
+		switch proxyURL.Scheme {
+		case "http", "https":
+			dialer.dialer = newConnectDialer(proxyURL, netDialer, transport.TLSClientConfig)
+		case "socks5":
+			socksDialer, err := proxy.FromURL(proxyURL, netDialer)
+			if err != nil {
+				return nil, fmt.Errorf("invalid client proxy: %w", err)
+			}
+			dialer.dialer = socksDialer.(proxy.ContextDialer)
+		default:
+			return nil, fmt.Errorf("invalid client proxy: unsupported scheme %v, expected http, https or socks5",
+				proxyURL.Scheme)
+		}
 	}
-	return match
+
+	return &http.Client{
+		Timeout:   c.Timeout,
+		Transport: transport,
+	}, nil
+}
+
+// IsRedirect checks if the response is a redirect having a Location to follow
+func IsRedirect(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect,
+		http.StatusPermanentRedirect:
+		return resp.Header.Get("Location") != ""
+	}
+	return false
+}
+
+// AcceptsJSON checks if the client prefers a JSON response over an HTML one based on the Accept header,
+// the media type with the higher quality value wins and in case of a tie the one listed first wins
+func AcceptsJSON(r *http.Request) bool {
+	preferred, preferredQuality := "", 0.0
+	for _, mediaRange := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(mediaRange)
+		if err != nil || (mediaType != ContentTypeJSON && mediaType != ContentTypeHTML) {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+
+		if quality > preferredQuality {
+			preferred, preferredQuality = mediaType, quality
+		}
+	}
+
+	return preferred == ContentTypeJSON
 }
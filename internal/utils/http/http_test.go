@@ -1,56 +1,379 @@
 package http_test
 
 import (
+	"context"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net"
+	netHttp "net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"testing"
+	"web-analyser/config"
+	iCtx "web-analyser/internal/utils/ctx"
 	"web-analyser/internal/utils/http"
 )
 
-type testCase struct {
-	url    string
-	expect bool
+func TestAcceptsJSON(t *testing.T) {
+	tests := []*struct {
+		accept string
+		expect bool
+	}{
+		{accept: "", expect: false},
+		{accept: "*/*", expect: false},
+		{accept: "application/json", expect: true},
+		{accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", expect: false},
+		{accept: "application/json, text/html", expect: true},
+		{accept: "text/html, application/json", expect: false},
+		{accept: "text/html;q=0.5, application/json", expect: true},
+		{accept: "application/json;q=0", expect: false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.accept, func(t *testing.T) {
+			r := httptest.NewRequest(netHttp.MethodGet, "/", nil)
+			r.Header.Set("Accept", tc.accept)
+			acceptsJSON := http.AcceptsJSON(r)
+			if acceptsJSON != tc.expect {
+				t.Fatalf("Expected:%v, Got:%v", tc.expect, acceptsJSON)
+			}
+		})
+	}
 }
 
-var tests = []*testCase{
-	{
-		url:    "http://www.google.com",
-		expect: true,
-	},
-	{
-		url:    "www.google.com",
-		expect: false,
-	},
-	{
-		url:    "htp://www.google.com",
-		expect: false,
-	},
-	{
-		url:    "//www.google.com",
-		expect: false,
-	},
-	{
-		url:    "http://google.com",
-		expect: true,
-	},
-	{
-		url:    "http://google",
-		expect: false,
-	},
-	{
-		url:    "http://www.google.com/abcdef/123456",
-		expect: true,
-	},
-	{
-		url:    "http://abc.def/",
-		expect: true,
-	},
+func TestClientImpl_Get(t *testing.T) {
+	// the server echoes the request id it receives
+	server := httptest.NewServer(netHttp.HandlerFunc(func(w netHttp.ResponseWriter, r *netHttp.Request) {
+		w.Header().Set(http.HeaderRequestID, r.Header.Get(http.HeaderRequestID))
+	}))
+	defer server.Close()
+
+	tests := []*struct {
+		name            string
+		ctx             func() context.Context
+		expectedID      string
+		expectCancelled bool
+	}{
+		{
+			name:       "Should forward the request id of the context",
+			ctx:        func() context.Context { return iCtx.SetRequestID(context.Background(), "request") },
+			expectedID: "request",
+		},
+		{
+			name: "Should not set the header without a request id",
+			ctx:  context.Background,
+		},
+		{
+			name: "Should not send the request once the context is cancelled",
+			ctx: func() context.Context {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx
+			},
+			expectCancelled: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client, _ := http.NewHttpClient(&config.ClientConf{AllowedAddresses: []string{"127.0.0.1"}})
+			resp, err := client.Get(tc.ctx(), server.URL)
+			var received string
+			if resp != nil {
+				received = resp.Header.Get(http.HeaderRequestID)
+				resp.Body.Close()
+			}
+
+			if cancelled := errors.Is(err, context.Canceled); cancelled != tc.expectCancelled {
+				t.Fatalf("Expected:%v, Got:%v %v", tc.expectCancelled, cancelled, err)
+			}
+			if received != tc.expectedID {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedID, received)
+			}
+		})
+	}
+}
+
+// newSocksProxy starts a socks5 proxy without authentication which connects to the target whatever the address it is
+// asked to connect to, the addresses asked for are sent to the returned channel
+func newSocksProxy(t *testing.T, target string) (string, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	addresses := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				// the greeting, then the connect request: version, command, reserved and address type
+				header := make([]byte, 2)
+				if _, err := io.ReadFull(conn, header); err != nil {
+					return
+				}
+				if _, err := io.ReadFull(conn, make([]byte, header[1])); err != nil {
+					return
+				}
+				conn.Write([]byte{5, 0})
+				request := make([]byte, 4)
+				if _, err := io.ReadFull(conn, request); err != nil {
+					return
+				}
+				var host string
+				switch request[3] {
+				case 1, 4:
+					ip := make(net.IP, map[byte]int{1: net.IPv4len, 4: net.IPv6len}[request[3]])
+					io.ReadFull(conn, ip)
+					host = ip.String()
+				case 3:
+					length := make([]byte, 1)
+					io.ReadFull(conn, length)
+					name := make([]byte, length[0])
+					io.ReadFull(conn, name)
+					host = string(name)
+				}
+				port := make([]byte, 2)
+				io.ReadFull(conn, port)
+				addresses <- net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port))))
+
+				upstream, err := net.Dial("tcp", target)
+				if err != nil {
+					return
+				}
+				defer upstream.Close()
+				conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+				go io.Copy(upstream, conn)
+				io.Copy(conn, upstream)
+			}()
+		}
+	}()
+	return "socks5://" + listener.Addr().String(), addresses
 }
 
-func TestIsValidURL(t *testing.T) {
+// newConnectProxy starts an http proxy, or an https one if secure, without authentication which tunnels the CONNECT
+// requests to the target whatever the address it is asked to connect to, the addresses asked for are sent to the
+// returned channel. It returns the URL of the proxy and the CA file trusting its certificate.
+func newConnectProxy(t *testing.T, target string, secure bool) (string, <-chan string, string) {
+	addresses := make(chan string, 10)
+	// the tunnels are closed along with the test, so that the proxy can be closed
+	done := make(chan struct{})
+	proxy := httptest.NewUnstartedServer(netHttp.HandlerFunc(func(w netHttp.ResponseWriter, r *netHttp.Request) {
+		if r.Method != netHttp.MethodConnect {
+			w.WriteHeader(netHttp.StatusMethodNotAllowed)
+			return
+		}
+		addresses <- r.Host
+		upstream, err := net.Dial("tcp", target)
+		if err != nil {
+			w.WriteHeader(netHttp.StatusBadGateway)
+			return
+		}
+		defer upstream.Close()
+		conn, buffered, _ := w.(netHttp.Hijacker).Hijack()
+		defer conn.Close()
+		io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+		go io.Copy(upstream, buffered)
+		go io.Copy(conn, upstream)
+		<-done
+	}))
+	if !secure {
+		proxy.Start()
+	} else {
+		proxy.StartTLS()
+	}
+	t.Cleanup(proxy.Close)
+	t.Cleanup(func() { close(done) })
+	if !secure {
+		return proxy.URL, addresses, ""
+	}
+
+	caFile := filepath.Join(t.TempDir(), "proxy.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: proxy.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return proxy.URL, addresses, caFile
+}
+
+func TestNewHttpClient_Proxy(t *testing.T) {
+	handler := netHttp.HandlerFunc(func(w netHttp.ResponseWriter, r *netHttp.Request) {
+		w.Header().Set("X-Host", r.Host)
+		if r.TLS != nil {
+			w.Header().Set("X-Server-Name", r.TLS.ServerName)
+		}
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	tlsServer := httptest.NewTLSServer(handler)
+	defer tlsServer.Close()
+
+	// newProxy starts the proxy of the scheme connecting to the target, along with the CA file trusting the proxy
+	newProxy := map[string]func(t *testing.T, target string) (string, <-chan string, string){
+		"socks5": func(t *testing.T, target string) (string, <-chan string, string) {
+			proxyURL, addresses := newSocksProxy(t, target)
+			return proxyURL, addresses, ""
+		},
+		"http": func(t *testing.T, target string) (string, <-chan string, string) {
+			return newConnectProxy(t, target, false)
+		},
+		"https": func(t *testing.T, target string) (string, <-chan string, string) {
+			return newConnectProxy(t, target, true)
+		},
+	}
+
+	tests := []*struct {
+		name               string
+		url                string
+		allowed            []string
+		expectedAddresses  []string
+		expectedHost       string
+		expectedServerName string
+		expectBlock        bool
+	}{
+		{
+			name:              "Should connect through the proxy to the checked IP even if the proxy is internal",
+			url:               "http://93.184.216.34/page",
+			expectedAddresses: []string{"93.184.216.34:80"},
+			expectedHost:      "93.184.216.34",
+		},
+		{
+			name:              "Should hand over the checked IP of the host to the proxy rather than the host",
+			url:               "http://localhost/page",
+			allowed:           []string{"localhost"},
+			expectedAddresses: []string{"127.0.0.1:80", "[::1]:80"},
+			expectedHost:      "localhost",
+		},
+		{
+			name:               "Should make the TLS handshake with the host through the tunnel to the checked IP",
+			url:                "https://localhost/page",
+			allowed:            []string{"localhost"},
+			expectedAddresses:  []string{"127.0.0.1:443", "[::1]:443"},
+			expectedHost:       "localhost",
+			expectedServerName: "localhost",
+		},
+		{
+			name:        "Should block the internal address before handing it over to the proxy",
+			url:         "http://169.254.169.254/latest/meta-data",
+			expectBlock: true,
+		},
+	}
+	for _, scheme := range []string{"socks5", "http", "https"} {
+		for _, tc := range tests {
+			t.Run(scheme+" "+tc.name, func(t *testing.T) {
+				target := server.Listener.Addr().String()
+				if tc.expectedServerName != "" {
+					target = tlsServer.Listener.Addr().String()
+				}
+				proxyURL, addresses, caFile := newProxy[scheme](t, target)
+				// the certificate of the website is issued for example.com, it is the server name which is checked
+				client, err := http.NewHttpClient(&config.ClientConf{ProxyURL: proxyURL, AllowedAddresses: tc.allowed,
+					CAFile: caFile, InsecureSkipVerify: tc.expectedServerName != ""})
+				if err != nil {
+					t.Fatalf("Expected:%v, Got:%v", nil, err)
+				}
+				resp, err := client.Get(context.Background(), tc.url)
+				var host, serverName string
+				if resp != nil {
+					host, serverName = resp.Header.Get("X-Host"), resp.Header.Get("X-Server-Name")
+					resp.Body.Close()
+				}
+
+				if blocked := errors.Is(err, http.ErrBlockedAddress); blocked != tc.expectBlock {
+					t.Fatalf("Expected:%v, Got:%v %v", tc.expectBlock, blocked, err)
+				}
+				if tc.expectBlock {
+					if len(addresses) != 0 {
+						t.Fatalf("Expected:%v, Got:%v", 0, len(addresses))
+					}
+					return
+				}
+				if address := <-addresses; !slices.Contains(tc.expectedAddresses, address) {
+					t.Fatalf("Expected:%v, Got:%v", tc.expectedAddresses, address)
+				}
+				// the host is still sent to the website, only the connection is made to its IP
+				if host != tc.expectedHost || serverName != tc.expectedServerName {
+					t.Fatalf("Expected:%v %v, Got:%v %v", tc.expectedHost, tc.expectedServerName, host, serverName)
+				}
+			})
+		}
+	}
+}
+
+func TestNewHttpClient_TLS(t *testing.T) {
+	server := httptest.NewTLSServer(netHttp.HandlerFunc(func(w netHttp.ResponseWriter, r *netHttp.Request) {}))
+	defer server.Close()
+
+	// the certificate of the server is signed by no CA of the system
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []*struct {
+		name          string
+		conf          *config.ClientConf
+		expectedError bool
+	}{
+		{
+			name:          "Should refuse the certificate of an unknown CA",
+			conf:          &config.ClientConf{AllowedAddresses: []string{"127.0.0.1"}},
+			expectedError: true,
+		},
+		{
+			name: "Should trust the certificate of the CA file",
+			conf: &config.ClientConf{AllowedAddresses: []string{"127.0.0.1"}, CAFile: caFile},
+		},
+		{
+			name: "Should skip the verification of the certificate",
+			conf: &config.ClientConf{AllowedAddresses: []string{"127.0.0.1"}, InsecureSkipVerify: true},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client, err := http.NewHttpClient(tc.conf)
+			if err != nil {
+				t.Fatalf("Expected:%v, Got:%v", nil, err)
+			}
+			resp, err := client.Get(context.Background(), server.URL)
+			if resp != nil {
+				resp.Body.Close()
+			}
+			if (err != nil) != tc.expectedError {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestNewHttpClient_InvalidConf(t *testing.T) {
+	notPEM := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []*struct {
+		name string
+		conf *config.ClientConf
+	}{
+		{name: "Should refuse a proxy of another scheme", conf: &config.ClientConf{ProxyURL: "ftp://proxy:21"}},
+		{name: "Should refuse a missing CA file", conf: &config.ClientConf{CAFile: "missing.pem"}},
+		{name: "Should refuse a CA file without certificates", conf: &config.ClientConf{CAFile: notPEM}},
+		{name: "Should refuse a header without a value", conf: &config.ClientConf{Headers: []string{"X-Env"}}},
+	}
 	for _, tc := range tests {
-		t.Run(tc.url, func(t *testing.T) {
-			validURL := http.IsValidURL(tc.url)
-			if validURL != tc.expect {
-				t.Fatalf("Expected:%v, Got:%v", tc.expect, validURL)
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := http.NewHttpClient(tc.conf); err == nil {
+				t.Fatalf("Expected:%v, Got:%v", "error", err)
 			}
 		})
 	}
@@ -0,0 +1,199 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"golang.org/x/net/http/httpguts"
+	"golang.org/x/net/publicsuffix"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	iError "web-analyser/internal/utils/error"
+)
+
+// Secret is a credential of the request options, it is redacted when formatted or marshalled so that it is never
+// logged nor sent back to the user
+type Secret string
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return "[redacted]"
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// RequestOptions are the options of the outbound requests of an analysis set by the user, they take precedence over
+// the user agent and the headers of the config. The user agent is sent to every host, while the headers and the
+// credentials are only sent to the origin of the analysed URL so that they don't leak to the sites it links to.
+type RequestOptions struct {
+	UserAgent string            `json:"userAgent,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Username  string            `json:"username,omitempty"` // Username is sent along with the Password as basic auth
+	Password  Secret            `json:"password,omitempty"`
+	Token     Secret            `json:"token,omitempty"` // Token is sent as bearer auth
+	// Cookies are the name=value pairs, separated by ;, set for the analysed URL before the first request, they are a
+	// Secret since they usually hold a session
+	Cookies Secret `json:"cookies,omitempty"`
+
+	invalidHeaders bool
+}
+
+// RequestOptionsError is returned when the request options are invalid, the reason is meant to be conveyed to the user
+type RequestOptionsError struct {
+	Reason iError.Msg
+}
+
+func (e *RequestOptionsError) Error() string {
+	return string(e.Reason)
+}
+
+// FormRequestOptions returns the request options of the form values of the request, the headers are a Name: value
+// pair per line and the invalid lines are reported by Validate. The credentials are only read from the body, so that
+// they don't end up in the URLs of the requests, which are logged.
+func FormRequestOptions(r *http.Request) *RequestOptions {
+	options := &RequestOptions{
+		UserAgent: strings.TrimSpace(r.FormValue("userAgent")),
+		Username:  r.PostFormValue("username"),
+		Password:  Secret(r.PostFormValue("password")),
+		Token:     Secret(strings.TrimSpace(r.PostFormValue("token"))),
+		Cookies:   Secret(strings.TrimSpace(r.PostFormValue("cookies"))),
+	}
+
+	headers, err := ParseHeaders(strings.Split(r.FormValue("headers"), "\n"))
+	options.invalidHeaders = err != nil
+	if len(headers) > 0 {
+		options.Headers = make(map[string]string, len(headers))
+		for name := range headers {
+			options.Headers[name] = headers.Get(name)
+		}
+	}
+	return options
+}
+
+// Validate checks that the user agent and the headers are valid header values, that either the basic or the bearer
+// credentials are set and that the cookies are name=value pairs. In case they are not, it returns a
+// *RequestOptionsError with the reason.
+func (o *RequestOptions) Validate() error {
+	if o.invalidHeaders || !httpguts.ValidHeaderFieldValue(o.UserAgent) {
+		return &RequestOptionsError{Reason: iError.InvalidHeaderError}
+	}
+	for name, value := range o.Headers {
+		if !httpguts.ValidHeaderFieldName(name) || !httpguts.ValidHeaderFieldValue(value) {
+			return &RequestOptionsError{Reason: iError.InvalidHeaderError}
+		}
+	}
+
+	basic := o.Username != "" || o.Password != ""
+	if (basic && o.Token != "") || strings.Contains(o.Username, ":") ||
+		!httpguts.ValidHeaderFieldValue(o.Username+string(o.Password)+string(o.Token)) {
+		return &RequestOptionsError{Reason: iError.InvalidCredentialsError}
+	}
+	if !validCookies(string(o.Cookies)) {
+		return &RequestOptionsError{Reason: iError.InvalidCookiesError}
+	}
+	return nil
+}
+
+// validCookies checks that each of the pairs separated by ; is a valid name=value cookie
+func validCookies(cookies string) bool {
+	var pairs int
+	for _, pair := range strings.Split(cookies, ";") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		if !strings.Contains(pair, "=") {
+			return false
+		}
+		pairs++
+	}
+	return len(parseCookies(cookies)) == pairs
+}
+
+// ParseHeaders parses the Name: value pairs into headers, the blank lines are skipped. In case a pair is not valid,
+// it returns a *RequestOptionsError with the reason.
+func ParseHeaders(lines []string) (http.Header, error) {
+	headers := http.Header{}
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !ok || !httpguts.ValidHeaderFieldName(name) || !httpguts.ValidHeaderFieldValue(value) {
+			return nil, &RequestOptionsError{Reason: iError.InvalidHeaderError}
+		}
+		headers.Add(name, value)
+	}
+	return headers, nil
+}
+
+// requestOptionsKey is the context key of the request options
+type requestOptionsKey struct{}
+
+// requestSettings are the request options of an analysis along with its origin and its cookie jar
+type requestSettings struct {
+	options *RequestOptions
+	origin  *url.URL
+	jar     http.CookieJar
+}
+
+// WithRequestOptions returns a copy of the context in which the outbound requests of the analysis of the URL are sent
+// with the options. Each analysis gets its own cookie jar holding the cookies of the options, so that the cookies set
+// by the pages, such as a session set before a redirect, are sent back for the rest of the analysis.
+func WithRequestOptions(ctx context.Context, u *url.URL, options *RequestOptions) context.Context {
+	if options == nil {
+		return ctx
+	}
+	// the error is always nil
+	jar, _ := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	jar.SetCookies(u, parseCookies(string(options.Cookies)))
+	return context.WithValue(ctx, requestOptionsKey{}, &requestSettings{options: options, origin: u, jar: jar})
+}
+
+// settingsFrom returns the request settings of the context, nil if it has none
+func settingsFrom(ctx context.Context) *requestSettings {
+	settings, _ := ctx.Value(requestOptionsKey{}).(*requestSettings)
+	return settings
+}
+
+// apply sets the user agent, the headers, the credentials and the cookies of the options on the request, the headers
+// already set on the request are kept
+func (s *requestSettings) apply(req *http.Request) {
+	if s.options.UserAgent != "" && req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", s.options.UserAgent)
+	}
+	for _, cookie := range s.jar.Cookies(req.URL) {
+		req.AddCookie(cookie)
+	}
+
+	if !IsSameOrigin(req.URL, s.origin) {
+		return
+	}
+	for name, value := range s.options.Headers {
+		if req.Header.Get(name) == "" {
+			req.Header.Set(name, value)
+		}
+	}
+	if req.Header.Get("Authorization") == "" {
+		switch {
+		case s.options.Token != "":
+			req.Header.Set("Authorization", "Bearer "+string(s.options.Token))
+		case s.options.Username != "" || s.options.Password != "":
+			req.SetBasicAuth(s.options.Username, string(s.options.Password))
+		}
+	}
+}
+
+// parseCookies parses the name=value pairs separated by ; into cookies valid for the whole site
+func parseCookies(cookies string) []*http.Cookie {
+	parsed := (&http.Request{Header: http.Header{"Cookie": []string{cookies}}}).Cookies()
+	for _, cookie := range parsed {
+		cookie.Path = "/"
+	}
+	return parsed
+}
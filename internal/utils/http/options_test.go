@@ -0,0 +1,187 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	netHttp "net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"web-analyser/config"
+	iError "web-analyser/internal/utils/error"
+	"web-analyser/internal/utils/http"
+)
+
+func TestRequestOptions_Validate(t *testing.T) {
+	tests := []*struct {
+		name           string
+		options        *http.RequestOptions
+		expectedReason iError.Msg
+	}{
+		{
+			name: "Should accept the valid options",
+			options: &http.RequestOptions{UserAgent: "Mozilla/5.0", Headers: map[string]string{"X-Env": "staging"},
+				Username: "user", Password: "pass", Cookies: "session=abc; lang=en"},
+		},
+		{
+			name:           "Should refuse an invalid header name",
+			options:        &http.RequestOptions{Headers: map[string]string{"X Env": "staging"}},
+			expectedReason: iError.InvalidHeaderError,
+		},
+		{
+			name:           "Should refuse a user agent spanning lines",
+			options:        &http.RequestOptions{UserAgent: "Mozilla/5.0\r\nX-Env: staging"},
+			expectedReason: iError.InvalidHeaderError,
+		},
+		{
+			name:           "Should refuse both the basic and the bearer credentials",
+			options:        &http.RequestOptions{Username: "user", Password: "pass", Token: "token"},
+			expectedReason: iError.InvalidCredentialsError,
+		},
+		{
+			name:           "Should refuse a username with a colon",
+			options:        &http.RequestOptions{Username: "us:er", Password: "pass"},
+			expectedReason: iError.InvalidCredentialsError,
+		},
+		{
+			name:           "Should refuse cookies which are not name=value pairs",
+			options:        &http.RequestOptions{Cookies: "lang=en; session"},
+			expectedReason: iError.InvalidCookiesError,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var reason iError.Msg
+			if err := tc.options.Validate(); err != nil {
+				reason = err.(*http.RequestOptionsError).Reason
+			}
+			if reason != tc.expectedReason {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedReason, reason)
+			}
+		})
+	}
+}
+
+func TestFormRequestOptions(t *testing.T) {
+	form := url.Values{"userAgent": {" Mozilla/5.0 "}, "headers": {"X-Env: staging\r\n\r\nx-team: web\r\n"},
+		"username": {"user"}, "password": {"pass"}, "cookies": {"session=abc"}}
+	r := httptest.NewRequest(netHttp.MethodPost, "/", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	options := http.FormRequestOptions(r)
+	expected := &http.RequestOptions{UserAgent: "Mozilla/5.0",
+		Headers:  map[string]string{"X-Env": "staging", "X-Team": "web"},
+		Username: "user", Password: "pass", Cookies: "session=abc"}
+	if !reflect.DeepEqual(expected, options) {
+		t.Fatalf("Expected:%+v, Got:%+v", expected, options)
+	}
+
+	// the invalid header lines are reported by Validate, while the credentials of the URL are ignored
+	r = httptest.NewRequest(netHttp.MethodPost, "/?password=pass&cookies=session%3Dabc&headers=X-Env", nil)
+	options = http.FormRequestOptions(r)
+	if options.Password != "" || options.Cookies != "" || options.Validate() == nil {
+		t.Fatalf("Expected:%v, Got:%+v %v", iError.InvalidHeaderError, options, options.Validate())
+	}
+}
+
+func TestSecret(t *testing.T) {
+	options := &http.RequestOptions{Username: "user", Password: "hunter2", Token: "s3cr3t", Cookies: "session=abc"}
+	b, _ := json.Marshal(options)
+	for _, formatted := range []string{fmt.Sprintf("%v", options), string(b)} {
+		if strings.Contains(formatted, "hunter2") || strings.Contains(formatted, "s3cr3t") ||
+			strings.Contains(formatted, "session=abc") {
+			t.Fatalf("Expected:%v, Got:%v", "redacted", formatted)
+		}
+	}
+}
+
+func TestClientImpl_Do_RequestOptions(t *testing.T) {
+	// the servers echo the headers and the cookies they receive, the login page sets a session and redirects
+	echo := func(w netHttp.ResponseWriter, r *netHttp.Request) {
+		if r.URL.Path == "/login" {
+			netHttp.SetCookie(w, &netHttp.Cookie{Name: "session", Value: "abc", Path: "/"})
+			netHttp.Redirect(w, r, "/", netHttp.StatusFound)
+			return
+		}
+		for _, name := range []string{"User-Agent", "X-Env", "X-Team", "Authorization", "Cookie"} {
+			w.Header().Set("Echo-"+name, r.Header.Get(name))
+		}
+	}
+	site := httptest.NewServer(netHttp.HandlerFunc(echo))
+	defer site.Close()
+	other := httptest.NewServer(netHttp.HandlerFunc(echo))
+	defer other.Close()
+
+	client, err := http.NewNoRedirectHttpClient(&config.ClientConf{AllowedAddresses: []string{"127.0.0.1"},
+		UserAgent: "web-analyser", Headers: []string{"X-Team: web"}})
+	if err != nil {
+		t.Fatalf("Expected:%v, Got:%v", nil, err)
+	}
+	get := func(ctx context.Context, u string) netHttp.Header {
+		resp, err := client.Get(ctx, u)
+		if err != nil {
+			t.Fatalf("Expected:%v, Got:%v", nil, err)
+		}
+		resp.Body.Close()
+		return resp.Header
+	}
+
+	tests := []*struct {
+		name     string
+		options  *http.RequestOptions
+		paths    []string
+		url      string
+		expected map[string]string
+	}{
+		{
+			name: "Should send the user agent and the headers of the config without options",
+			url:  site.URL,
+			expected: map[string]string{"User-Agent": "web-analyser", "X-Env": "", "X-Team": "web",
+				"Authorization": "", "Cookie": ""},
+		},
+		{
+			name: "Should send the options to the origin of the analysed URL",
+			options: &http.RequestOptions{UserAgent: "Mozilla/5.0", Headers: map[string]string{"X-Env": "staging"},
+				Username: "user", Password: "pass", Cookies: "lang=en"},
+			url: site.URL,
+			expected: map[string]string{"User-Agent": "Mozilla/5.0", "X-Env": "staging", "X-Team": "web",
+				"Authorization": "Basic dXNlcjpwYXNz", "Cookie": "lang=en"},
+		},
+		{
+			// the cookies are scoped to the host whatever the port, as per RFC 6265
+			name: "Should send only the user agent and the cookies of the options to the other origins",
+			options: &http.RequestOptions{UserAgent: "Mozilla/5.0", Headers: map[string]string{"X-Env": "staging"},
+				Token: "token", Cookies: "lang=en"},
+			url: other.URL,
+			expected: map[string]string{"User-Agent": "Mozilla/5.0", "X-Env": "", "X-Team": "web",
+				"Authorization": "", "Cookie": "lang=en"},
+		},
+		{
+			name:    "Should send back the cookies set by the previous responses",
+			options: &http.RequestOptions{Token: "token"},
+			paths:   []string{"/login"},
+			url:     site.URL,
+			expected: map[string]string{"User-Agent": "web-analyser", "X-Env": "", "X-Team": "web",
+				"Authorization": "Bearer token", "Cookie": "session=abc"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			siteURL, _ := url.Parse(site.URL)
+			ctx := http.WithRequestOptions(context.Background(), siteURL, tc.options)
+			for _, path := range tc.paths {
+				get(ctx, site.URL+path)
+			}
+
+			header := get(ctx, tc.url)
+			for name, expected := range tc.expected {
+				if value := header.Get("Echo-" + name); value != expected {
+					t.Fatalf("Expected:%v, Got:%v %v", expected, value, name)
+				}
+			}
+		})
+	}
+}
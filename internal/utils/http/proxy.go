@@ -0,0 +1,117 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// connectDialer connects through an http or https proxy, asking the proxy to tunnel the connection with a CONNECT
+// request. It is given the checked IP address by the filteredDialer, so the proxy never resolves the hosts itself
+// and the hosts can't resolve to an internal address at the proxy. The requests of the websites, including the plain
+// http ones, are sent through the tunnel, so the TLS handshake is made end to end with the host of the request as
+// the server name.
+type connectDialer struct {
+	proxyURL *url.URL
+	dialer   *net.Dialer
+	// tlsConfig is the TLS config of the connection to an https proxy, nil for an http proxy
+	tlsConfig *tls.Config
+}
+
+// newConnectDialer returns a dialer connecting through the http or https proxy, the connection to an https proxy is
+// secured with a copy of the TLS config, nil if the system certificates are trusted
+func newConnectDialer(proxyURL *url.URL, dialer *net.Dialer, tlsConfig *tls.Config) *connectDialer {
+	d := &connectDialer{proxyURL: proxyURL, dialer: dialer}
+	if proxyURL.Scheme == "https" {
+		d.tlsConfig = &tls.Config{}
+		if tlsConfig != nil {
+			d.tlsConfig = tlsConfig.Clone()
+		}
+		d.tlsConfig.ServerName = proxyURL.Hostname()
+	}
+	return d
+}
+
+// DialContext connects to the proxy and asks it to connect to the address, it returns the tunnelled connection once
+// the proxy responds with 200
+func (d *connectDialer) DialContext(ctx context.Context, network string, address string) (net.Conn, error) {
+	conn, err := d.dialer.DialContext(ctx, network, d.proxyAddress())
+	if err != nil {
+		return nil, err
+	}
+	// the proxy is given until the deadline of the context to set up the tunnel
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if d.tlsConfig != nil {
+		tlsConn := tls.Client(conn, d.tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: http.Header{},
+	}
+	if user := d.proxyURL.User; user != nil {
+		password, _ := user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy refused to connect to %v: %v", address, resp.Status)
+	}
+	conn.SetDeadline(time.Time{})
+
+	// the website may respond before the tunnel is read, the bytes already buffered are read first
+	if reader.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, reader: reader}, nil
+	}
+	return conn, nil
+}
+
+// proxyAddress returns the host and port of the proxy, the port defaults to the one of its scheme
+func (d *connectDialer) proxyAddress() string {
+	if port := d.proxyURL.Port(); port != "" {
+		return d.proxyURL.Host
+	}
+	port := "80"
+	if d.proxyURL.Scheme == "https" {
+		port = "443"
+	}
+	return net.JoinHostPort(d.proxyURL.Hostname(), port)
+}
+
+// bufferedConn is a connection whose first bytes were already read into the reader
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
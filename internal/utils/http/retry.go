@@ -0,0 +1,232 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"web-analyser/config"
+)
+
+// drainSize is the max number of bytes of the body of a retried response read before closing it, so that the
+// connection can be reused by the next attempt
+const drainSize = 4 << 10
+
+// RetryClient is a Client retrying the requests which fail with a transient error: a timeout, a reset connection or
+// a 429, 502, 503 or 504 response. The retries are spaced out by an exponential backoff along with a random jitter,
+// or by the Retry-After of the response, and they are limited by the max attempts and the budget of the config along
+// with the deadline and the retry deadline of the context of the request, see WithRetryDeadline. Only the idempotent
+// requests are retried, since the others could have been processed before failing.
+type RetryClient struct {
+	client      Client
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	budget      time.Duration
+	timeout     time.Duration
+}
+
+// NewRetryClient returns a new RetryClient sending the requests with the client
+func NewRetryClient(client Client, c *config.ClientConf) *RetryClient {
+	return &RetryClient{
+		client:      client,
+		maxAttempts: max(c.RetryMaxAttempts, 1),
+		baseDelay:   c.RetryBaseDelay,
+		maxDelay:    c.RetryMaxDelay,
+		budget:      c.RetryBudget,
+		timeout:     c.Timeout,
+	}
+}
+
+// Get sends a GET request to the url bound to the context
+func (c *RetryClient) Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+// Do sends the request, retrying it while it fails with a transient error. The response or the error of the last
+// attempt is returned, the bodies of the retried responses are discarded. The retries are recorded in the RetryStats
+// of the context, see WithRetryStats. The context error is returned if it is done while waiting for a retry.
+func (c *RetryClient) Do(req *http.Request) (*http.Response, error) {
+	if !retryableRequest(req) {
+		return c.client.Do(req)
+	}
+
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		resp, err := c.client.Do(req)
+		if attempt >= c.maxAttempts || req.Context().Err() != nil || !retryableResponse(resp, err) {
+			return resp, err
+		}
+		delay, ok := c.delay(attempt, resp)
+		if !ok || !c.inTime(req.Context(), start, delay) {
+			return resp, err
+		}
+
+		if resp != nil {
+			io.CopyN(io.Discard, resp.Body, drainSize)
+			resp.Body.Close()
+		}
+		if err := wait(req.Context(), delay); err != nil {
+			return nil, err
+		}
+		if req, err = rewind(req); err != nil {
+			return nil, err
+		}
+		if stats := retryStatsFrom(req.Context()); stats != nil {
+			stats.retries.Add(1)
+		}
+	}
+}
+
+// delay returns the delay before the retry following the attempt. It is the Retry-After of the response if any, else
+// the base delay doubled on every attempt up to the max delay, half of which is randomised so that the clients
+// failing together don't retry together. It returns false if the Retry-After asks for longer than the max delay.
+func (c *RetryClient) delay(attempt int, resp *http.Response) (time.Duration, bool) {
+	if resp != nil {
+		if after, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			return after, after <= c.maxDelay
+		}
+	}
+
+	delay := c.baseDelay
+	for i := 1; i < attempt && delay < c.maxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, c.maxDelay)
+	if delay > 0 {
+		delay = delay/2 + time.Duration(rand.Int63n(int64(delay-delay/2)+1))
+	}
+	return delay, true
+}
+
+// inTime checks if the retry after the delay can end, within the timeout of the client, before the budget started at
+// the first attempt is spent, the deadline and the retry deadline of the context, so that no retry is made which the
+// caller would not wait for
+func (c *RetryClient) inTime(ctx context.Context, start time.Time, delay time.Duration) bool {
+	end := time.Now().Add(delay + c.timeout)
+	if c.budget > 0 && end.After(start.Add(c.budget)) {
+		return false
+	}
+	if deadline, ok := ctx.Deadline(); ok && end.After(deadline) {
+		return false
+	}
+	if deadline, ok := ctx.Value(retryDeadlineKey{}).(time.Time); ok && end.After(deadline) {
+		return false
+	}
+	return true
+}
+
+// retryableRequest checks if the request is idempotent and if its body, if any, can be sent again
+func retryableRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	}
+	return false
+}
+
+// retryableResponse checks if the response or the error of an attempt is transient: a timeout, a connection reset or
+// closed by the server, or a response asking to try again later. The blocked addresses are never retried.
+func retryableResponse(resp *http.Response, err error) bool {
+	if err != nil {
+		var netErr net.Error
+		if errors.Is(err, ErrBlockedAddress) {
+			return false
+		}
+		return (errors.As(err, &netErr) && netErr.Timeout()) || errors.Is(err, syscall.ECONNRESET) ||
+			errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter parses the Retry-After header, either a number of seconds or an http date, into the delay to wait. It
+// returns false if the header is missing or invalid.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 32); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
+
+// wait waits for the delay, it returns the context error if the context is done before
+func wait(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// rewind returns a copy of the request with a new body, so that it can be sent again
+func rewind(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Body = body
+	return req, nil
+}
+
+// RetryStats records the retries of the requests sent by a RetryClient with a context returned by WithRetryStats
+type RetryStats struct {
+	retries atomic.Int64
+}
+
+// Retries returns the number of retries recorded
+func (s *RetryStats) Retries() int {
+	return int(s.retries.Load())
+}
+
+// retryStatsKey is the context key of the retry stats
+type retryStatsKey struct{}
+
+// WithRetryStats returns a copy of the context in which the retries of the requests are recorded in the stats
+func WithRetryStats(ctx context.Context, stats *RetryStats) context.Context {
+	return context.WithValue(ctx, retryStatsKey{}, stats)
+}
+
+// retryStatsFrom returns the retry stats of the context, nil if it has none
+func retryStatsFrom(ctx context.Context) *RetryStats {
+	stats, _ := ctx.Value(retryStatsKey{}).(*RetryStats)
+	return stats
+}
+
+// retryDeadlineKey is the context key of the retry deadline
+type retryDeadlineKey struct{}
+
+// WithRetryDeadline returns a copy of the context in which the requests are retried only while the retry can end
+// before the deadline. Unlike the deadline of the context, it doesn't cancel the requests, so that the work following
+// them, such as checking the links of the page, still has its own time.
+func WithRetryDeadline(ctx context.Context, deadline time.Time) context.Context {
+	if current, ok := ctx.Value(retryDeadlineKey{}).(time.Time); ok && current.Before(deadline) {
+		return ctx
+	}
+	return context.WithValue(ctx, retryDeadlineKey{}, deadline)
+}
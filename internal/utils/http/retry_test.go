@@ -0,0 +1,239 @@
+package http_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	netHttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"web-analyser/config"
+	"web-analyser/internal/utils/http"
+)
+
+func TestRetryClient_Do(t *testing.T) {
+	// fail returns a handler failing the first attempts with the failure and responding with the body afterwards
+	fail := func(attempts int32, failure func(w netHttp.ResponseWriter)) func(int32, netHttp.ResponseWriter) {
+		return func(attempt int32, w netHttp.ResponseWriter) {
+			if attempt <= attempts {
+				failure(w)
+				return
+			}
+			io.WriteString(w, page)
+		}
+	}
+	status := func(statusCode int, retryAfter string) func(w netHttp.ResponseWriter) {
+		return func(w netHttp.ResponseWriter) {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(statusCode)
+		}
+	}
+	reset := func(w netHttp.ResponseWriter) {
+		conn, _, _ := w.(netHttp.Hijacker).Hijack()
+		conn.Close()
+	}
+	timeout := func(w netHttp.ResponseWriter) {
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	tests := []*struct {
+		name               string
+		method             string
+		body               string
+		handler            func(attempt int32, w netHttp.ResponseWriter)
+		budget             time.Duration
+		deadline           time.Duration
+		retryDeadline      time.Duration
+		expectedStatusCode int
+		expectedAttempts   int32
+		expectedRetries    int
+	}{
+		{
+			name:               "Should retry a 503 until it succeeds",
+			handler:            fail(2, status(503, "")),
+			expectedStatusCode: 200,
+			expectedAttempts:   3,
+			expectedRetries:    2,
+		},
+		{
+			name:               "Should retry a 429 after its Retry-After",
+			handler:            fail(1, status(429, "0")),
+			expectedStatusCode: 200,
+			expectedAttempts:   2,
+			expectedRetries:    1,
+		},
+		{
+			name:               "Should retry a 502 after its Retry-After date",
+			handler:            fail(1, status(502, "Mon, 02 Jan 2006 15:04:05 GMT")),
+			expectedStatusCode: 200,
+			expectedAttempts:   2,
+			expectedRetries:    1,
+		},
+		{
+			name:               "Should return the last response once the attempts are exhausted",
+			handler:            fail(5, status(504, "")),
+			expectedStatusCode: 504,
+			expectedAttempts:   3,
+			expectedRetries:    2,
+		},
+		{
+			name:               "Should not retry a Retry-After longer than the max delay",
+			handler:            fail(1, status(503, "120")),
+			expectedStatusCode: 503,
+			expectedAttempts:   1,
+		},
+		{
+			name:               "Should not retry once the budget is spent",
+			handler:            fail(1, status(503, "")),
+			budget:             time.Nanosecond,
+			expectedStatusCode: 503,
+			expectedAttempts:   1,
+		},
+		{
+			name:               "Should not retry when the retry could not end before the deadline of the context",
+			handler:            fail(1, status(503, "")),
+			deadline:           50 * time.Millisecond,
+			expectedStatusCode: 503,
+			expectedAttempts:   1,
+		},
+		{
+			name:               "Should not retry when the retry could not end before the retry deadline of the context",
+			handler:            fail(1, status(503, "")),
+			retryDeadline:      50 * time.Millisecond,
+			expectedStatusCode: 503,
+			expectedAttempts:   1,
+		},
+		{
+			name:               "Should retry when the retry can end before the retry deadline of the context",
+			handler:            fail(1, status(503, "")),
+			retryDeadline:      time.Minute,
+			expectedStatusCode: 200,
+			expectedAttempts:   2,
+			expectedRetries:    1,
+		},
+		{
+			name:               "Should not retry a response which is not transient",
+			handler:            fail(1, status(500, "")),
+			expectedStatusCode: 500,
+			expectedAttempts:   1,
+		},
+		{
+			name:               "Should retry a connection reset by the server",
+			handler:            fail(1, reset),
+			expectedStatusCode: 200,
+			expectedAttempts:   2,
+			expectedRetries:    1,
+		},
+		{
+			name:               "Should retry a timeout",
+			handler:            fail(1, timeout),
+			expectedStatusCode: 200,
+			expectedAttempts:   2,
+			expectedRetries:    1,
+		},
+		{
+			name:               "Should retry an idempotent request sending its body again",
+			method:             netHttp.MethodPut,
+			body:               page,
+			handler:            fail(1, status(503, "")),
+			expectedStatusCode: 200,
+			expectedAttempts:   2,
+			expectedRetries:    1,
+		},
+		{
+			name:               "Should not retry a request which is not idempotent",
+			method:             netHttp.MethodPost,
+			body:               page,
+			handler:            fail(1, status(503, "")),
+			expectedStatusCode: 503,
+			expectedAttempts:   1,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(netHttp.HandlerFunc(func(w netHttp.ResponseWriter, r *netHttp.Request) {
+				if b, _ := io.ReadAll(r.Body); string(b) != tc.body {
+					t.Errorf("Expected:%v, Got:%v", tc.body, string(b))
+				}
+				tc.handler(attempts.Add(1), w)
+			}))
+			defer server.Close()
+
+			conf := &config.ClientConf{AllowedAddresses: []string{"127.0.0.1"}, Timeout: 100 * time.Millisecond,
+				RetryMaxAttempts: 3, RetryBaseDelay: time.Millisecond, RetryMaxDelay: 10 * time.Millisecond,
+				RetryBudget: tc.budget}
+			impl, _ := http.NewHttpClient(conf)
+			client := http.NewRetryClient(impl, conf)
+
+			stats := &http.RetryStats{}
+			method := tc.method
+			if method == "" {
+				method = netHttp.MethodGet
+			}
+			ctx := http.WithRetryStats(context.Background(), stats)
+			if tc.deadline > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tc.deadline)
+				defer cancel()
+			}
+			if tc.retryDeadline > 0 {
+				ctx = http.WithRetryDeadline(ctx, time.Now().Add(tc.retryDeadline))
+			}
+			req, _ := netHttp.NewRequestWithContext(ctx, method, server.URL, strings.NewReader(tc.body))
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Expected:%v, Got:%v", nil, err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tc.expectedStatusCode {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedStatusCode, resp.StatusCode)
+			}
+			if attempts.Load() != tc.expectedAttempts {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedAttempts, attempts.Load())
+			}
+			if stats.Retries() != tc.expectedRetries {
+				t.Fatalf("Expected:%v, Got:%v", tc.expectedRetries, stats.Retries())
+			}
+		})
+	}
+}
+
+func TestRetryClient_Get_Blocked(t *testing.T) {
+	conf := &config.ClientConf{RetryMaxAttempts: 3, RetryBaseDelay: time.Millisecond}
+	impl, _ := http.NewHttpClient(conf)
+	client := http.NewRetryClient(impl, conf)
+
+	stats := &http.RetryStats{}
+	_, err := client.Get(http.WithRetryStats(context.Background(), stats), "http://127.0.0.1")
+	if !errors.Is(err, http.ErrBlockedAddress) || stats.Retries() != 0 {
+		t.Fatalf("Expected:%v, Got:%v %v", http.ErrBlockedAddress, err, stats.Retries())
+	}
+}
+
+func TestRetryClient_Get_Cancelled(t *testing.T) {
+	server := httptest.NewServer(netHttp.HandlerFunc(func(w netHttp.ResponseWriter, r *netHttp.Request) {
+		w.WriteHeader(netHttp.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	conf := &config.ClientConf{AllowedAddresses: []string{"127.0.0.1"}, RetryMaxAttempts: 3,
+		RetryBaseDelay: time.Minute, RetryMaxDelay: time.Minute}
+	impl, _ := http.NewHttpClient(conf)
+	client := http.NewRetryClient(impl, conf)
+
+	// the context is cancelled while waiting for the retry
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	time.AfterFunc(50*time.Millisecond, cancel)
+	resp, err := client.Get(ctx, server.URL)
+	if resp != nil || !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected:%v, Got:%v %v", context.Canceled, resp, err)
+	}
+}
@@ -0,0 +1,199 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	iError "web-analyser/internal/utils/error"
+)
+
+// defaultScheme is prefixed to the URLs entered without a scheme, for example: www.google.com
+const defaultScheme = "https"
+
+// URLValidationError is returned when the URL can't be analysed, the reason is meant to be conveyed to the user
+type URLValidationError struct {
+	Reason iError.Msg
+}
+
+func (e *URLValidationError) Error() string {
+	return string(e.Reason)
+}
+
+// ValidateURL parses the input as an http or https URL and returns the URL with the host converted to ascii,
+// for example: https://bücher.de becomes https://xn--bcher-kva.de. The host can be a domain name, including single
+// label names like localhost, or an IPv4 or IPv6 address, along with an optional port. The default scheme is
+// prefixed if the input has none. In case the input is not valid, it returns a *URLValidationError with the reason.
+func ValidateURL(input string) (*url.URL, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return nil, &URLValidationError{Reason: iError.EmptyURLError}
+	}
+	if !hasScheme(input) {
+		input = defaultScheme + "://" + strings.TrimPrefix(input, "//")
+	}
+
+	u, err := url.Parse(input)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) && strings.Contains(urlErr.Err.Error(), "invalid port") {
+			return nil, &URLValidationError{Reason: iError.InvalidPortError}
+		}
+		return nil, &URLValidationError{Reason: iError.InvalidURLError}
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, &URLValidationError{Reason: iError.UnsupportedSchemeError}
+	}
+	if u.User != nil {
+		return nil, &URLValidationError{Reason: iError.UserInfoError}
+	}
+	if u.Hostname() == "" {
+		return nil, &URLValidationError{Reason: iError.MissingHostError}
+	}
+	host, port := u.Hostname(), u.Port()
+	if port != "" {
+		if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+			return nil, &URLValidationError{Reason: iError.InvalidPortError}
+		}
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		// IPv6 addresses must be enclosed in brackets, IPv4 addresses must not
+		if strings.HasPrefix(u.Host, "[") == (ip.To4() != nil) {
+			return nil, &URLValidationError{Reason: iError.InvalidHostError}
+		}
+	} else {
+		// the host is a domain name, the lookup profile rejects the invalid characters and labels, the top level
+		// domain can't be numeric so such hosts are invalid IP addresses, for example: 256.0.0.1
+		labels := strings.Split(strings.TrimSuffix(host, "."), ".")
+		if _, err := strconv.Atoi(labels[len(labels)-1]); err == nil {
+			return nil, &URLValidationError{Reason: iError.InvalidHostError}
+		}
+		if host, err = idna.Lookup.ToASCII(host); err != nil {
+			return nil, &URLValidationError{Reason: iError.InvalidHostError}
+		}
+	}
+
+	u.Host = host
+	if strings.Contains(host, ":") {
+		u.Host = "[" + host + "]"
+	}
+	if port != "" {
+		u.Host = net.JoinHostPort(host, port)
+	}
+	return u, nil
+}
+
+// hasScheme checks if the input starts with a scheme, for example: mailto:abc@def.com, unlike a host followed by a
+// port, for example: localhost:8080
+func hasScheme(input string) bool {
+	if strings.Contains(input, "://") {
+		return true
+	}
+
+	scheme, rest, found := strings.Cut(input, ":")
+	if !found || scheme == "" || (rest != "" && rest[0] >= '0' && rest[0] <= '9') {
+		return false
+	}
+	for i, c := range scheme {
+		isLetter := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		isOther := (c >= '0' && c <= '9') || c == '+' || c == '-' || c == '.'
+		if !isLetter && (i == 0 || !isOther) {
+			return false
+		}
+	}
+	return true
+}
+
+// SameSitePolicy decides whether two hosts belong to the same site
+type SameSitePolicy string
+
+const (
+	// SameSiteExact considers the hosts to be the same site only if they are equal
+	SameSiteExact SameSitePolicy = "exact"
+	// SameSiteHost considers the hosts to be the same site if they are equal, ignoring the www. prefix
+	SameSiteHost SameSitePolicy = "host"
+	// SameSiteDomain considers the hosts to be the same site if they have the same registrable domain,
+	// for example: www.google.com and mail.google.com
+	SameSiteDomain SameSitePolicy = "domain"
+)
+
+// ParseSameSitePolicy returns the policy named by the value, it returns an error if the value is not a known policy,
+// so that a misconfigured policy is reported at startup rather than falling back to another policy
+func ParseSameSitePolicy(value string) (SameSitePolicy, error) {
+	switch policy := SameSitePolicy(value); policy {
+	case SameSiteExact, SameSiteHost, SameSiteDomain:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown same site policy %q, expected exact, host or domain", value)
+}
+
+// NormaliseURL returns a normalised copy of the URL, so that the URLs pointing to the same resource are equal.
+// The scheme and the host are lower cased, the default port, the fragment and the trailing slash of the path
+// are removed, and the query params are sorted by key if sortQuery is set.
+func NormaliseURL(u *url.URL, sortQuery bool) *url.URL {
+	n := *u
+	n.User = nil
+	n.Scheme = strings.ToLower(n.Scheme)
+	n.Host = strings.ToLower(n.Host)
+	if (n.Scheme == "http" && n.Port() == "80") || (n.Scheme == "https" && n.Port() == "443") {
+		n.Host = strings.TrimSuffix(n.Host, ":"+n.Port())
+	}
+
+	n.Fragment = ""
+	n.RawFragment = ""
+	n.ForceQuery = false
+
+	if n.Opaque == "" {
+		n.Path = strings.TrimSuffix(n.Path, "/")
+		n.RawPath = strings.TrimSuffix(n.RawPath, "/")
+		if n.Path == "" {
+			n.Path = "/"
+			n.RawPath = ""
+		}
+	}
+
+	if sortQuery && n.RawQuery != "" {
+		// Encode sorts the values by key
+		n.RawQuery = n.Query().Encode()
+	}
+	return &n
+}
+
+// IsSameSite checks whether the host belongs to the same site as the site host as per the policy, the aliases are
+// additional hosts which are always considered to be the same site
+func IsSameSite(policy SameSitePolicy, siteHost string, host string, aliases []string) bool {
+	siteHost, host = strings.ToLower(siteHost), strings.ToLower(host)
+	for _, alias := range aliases {
+		if strings.EqualFold(alias, host) {
+			return true
+		}
+	}
+
+	if policy == SameSiteExact {
+		return siteHost == host
+	}
+	if policy == SameSiteDomain {
+		siteDomain, siteErr := publicsuffix.EffectiveTLDPlusOne(siteHost)
+		domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+		// hosts without a registrable domain, for example: localhost or IP addresses, fall back to the host policy
+		if siteErr == nil && err == nil {
+			return siteDomain == domain
+		}
+	}
+
+	return strings.TrimPrefix(siteHost, "www.") == strings.TrimPrefix(host, "www.")
+}
+
+// IsSameOrigin checks whether the URLs have the same scheme, host and port, the default port of the scheme being the
+// same as no port
+func IsSameOrigin(a *url.URL, b *url.URL) bool {
+	a, b = NormaliseURL(a, false), NormaliseURL(b, false)
+	return a.Scheme == b.Scheme && a.Host == b.Host
+}
@@ -0,0 +1,41 @@
+package render
+
+import (
+	"encoding/json"
+	"github.com/rs/zerolog"
+	"io"
+	"net/http"
+	iCtx "web-analyser/internal/utils/ctx"
+	iHttp "web-analyser/internal/utils/http"
+)
+
+// Executor is implemented by the templates which can be rendered, for example html/template.Template
+type Executor interface {
+	ExecuteTemplate(io.Writer, string, any) error
+}
+
+// Template renders the template with the given data, in case of error it logs it and responds with 500
+func Template(w http.ResponseWriter, r *http.Request, logger *zerolog.Logger, tpl Executor, name string, data any) {
+	reqID := iCtx.RequestID(r.Context())
+	err := tpl.ExecuteTemplate(w, name, data)
+	if err != nil {
+		logger.Error().Str(iCtx.KeyRequestID, reqID).Err(err).Msg("template error")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// JSON writes the data as JSON with the given http status code, in case of error it logs it and responds with 500
+func JSON(w http.ResponseWriter, r *http.Request, logger *zerolog.Logger, statusCode int, data any) {
+	reqID := iCtx.RequestID(r.Context())
+	body, err := json.Marshal(data)
+	if err != nil {
+		logger.Error().Str(iCtx.KeyRequestID, reqID).Err(err).Msg("json error")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", iHttp.ContentTypeJSON)
+	w.WriteHeader(statusCode)
+	w.Write(body)
+}
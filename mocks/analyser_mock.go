@@ -10,6 +10,7 @@
 package mocks
 
 import (
+	context "context"
 	url "net/url"
 	reflect "reflect"
 	analyser "web-analyser/api/backend/analyser"
@@ -41,9 +42,9 @@ func (m *MockAnalyser) EXPECT() *MockAnalyserMockRecorder {
 }
 
 // Analyse mocks base method.
-func (m *MockAnalyser) Analyse(url *url.URL) (*analyser.Summary, error, int) {
+func (m *MockAnalyser) Analyse(ctx context.Context, url *url.URL) (*analyser.Summary, error, int) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Analyse", url)
+	ret := m.ctrl.Call(m, "Analyse", ctx, url)
 	ret0, _ := ret[0].(*analyser.Summary)
 	ret1, _ := ret[1].(error)
 	ret2, _ := ret[2].(int)
@@ -51,7 +52,23 @@ func (m *MockAnalyser) Analyse(url *url.URL) (*analyser.Summary, error, int) {
 }
 
 // Analyse indicates an expected call of Analyse.
-func (mr *MockAnalyserMockRecorder) Analyse(url any) *gomock.Call {
+func (mr *MockAnalyserMockRecorder) Analyse(ctx, url any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Analyse", reflect.TypeOf((*MockAnalyser)(nil).Analyse), url)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Analyse", reflect.TypeOf((*MockAnalyser)(nil).Analyse), ctx, url)
+}
+
+// AnalyseWithProgress mocks base method.
+func (m *MockAnalyser) AnalyseWithProgress(ctx context.Context, url *url.URL, progress analyser.ProgressFunc) (*analyser.Summary, error, int) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AnalyseWithProgress", ctx, url, progress)
+	ret0, _ := ret[0].(*analyser.Summary)
+	ret1, _ := ret[1].(error)
+	ret2, _ := ret[2].(int)
+	return ret0, ret1, ret2
+}
+
+// AnalyseWithProgress indicates an expected call of AnalyseWithProgress.
+func (mr *MockAnalyserMockRecorder) AnalyseWithProgress(ctx, url, progress any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnalyseWithProgress", reflect.TypeOf((*MockAnalyser)(nil).AnalyseWithProgress), ctx, url, progress)
 }
@@ -0,0 +1,56 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: api/backend/crawler/crawler.go
+//
+// Generated by this command:
+//
+//	mockgen -source=api/backend/crawler/crawler.go -destination=mocks/crawler_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	url "net/url"
+	reflect "reflect"
+	crawler "web-analyser/api/backend/crawler"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCrawler is a mock of Crawler interface.
+type MockCrawler struct {
+	ctrl     *gomock.Controller
+	recorder *MockCrawlerMockRecorder
+}
+
+// MockCrawlerMockRecorder is the mock recorder for MockCrawler.
+type MockCrawlerMockRecorder struct {
+	mock *MockCrawler
+}
+
+// NewMockCrawler creates a new mock instance.
+func NewMockCrawler(ctrl *gomock.Controller) *MockCrawler {
+	mock := &MockCrawler{ctrl: ctrl}
+	mock.recorder = &MockCrawlerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCrawler) EXPECT() *MockCrawlerMockRecorder {
+	return m.recorder
+}
+
+// Crawl mocks base method.
+func (m *MockCrawler) Crawl(ctx context.Context, url *url.URL) *crawler.SiteSummary {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Crawl", ctx, url)
+	ret0, _ := ret[0].(*crawler.SiteSummary)
+	return ret0
+}
+
+// Crawl indicates an expected call of Crawl.
+func (mr *MockCrawlerMockRecorder) Crawl(ctx, url any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Crawl", reflect.TypeOf((*MockCrawler)(nil).Crawl), ctx, url)
+}
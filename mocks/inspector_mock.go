@@ -0,0 +1,116 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: api/backend/analyser/inspector.go
+//
+// Generated by this command:
+//
+//	mockgen -source=api/backend/analyser/inspector.go -destination=mocks/inspector_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	analyser "web-analyser/api/backend/analyser"
+
+	gomock "go.uber.org/mock/gomock"
+	html "golang.org/x/net/html"
+)
+
+// MockInspector is a mock of Inspector interface.
+type MockInspector struct {
+	ctrl     *gomock.Controller
+	recorder *MockInspectorMockRecorder
+}
+
+// MockInspectorMockRecorder is the mock recorder for MockInspector.
+type MockInspectorMockRecorder struct {
+	mock *MockInspector
+}
+
+// NewMockInspector creates a new mock instance.
+func NewMockInspector(ctrl *gomock.Controller) *MockInspector {
+	mock := &MockInspector{ctrl: ctrl}
+	mock.recorder = &MockInspectorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInspector) EXPECT() *MockInspectorMockRecorder {
+	return m.recorder
+}
+
+// Inspect mocks base method.
+func (m *MockInspector) Inspect(page *analyser.Page) analyser.Inspection {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Inspect", page)
+	ret0, _ := ret[0].(analyser.Inspection)
+	return ret0
+}
+
+// Inspect indicates an expected call of Inspect.
+func (mr *MockInspectorMockRecorder) Inspect(page any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Inspect", reflect.TypeOf((*MockInspector)(nil).Inspect), page)
+}
+
+// Name mocks base method.
+func (m *MockInspector) Name() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Name")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Name indicates an expected call of Name.
+func (mr *MockInspectorMockRecorder) Name() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockInspector)(nil).Name))
+}
+
+// MockInspection is a mock of Inspection interface.
+type MockInspection struct {
+	ctrl     *gomock.Controller
+	recorder *MockInspectionMockRecorder
+}
+
+// MockInspectionMockRecorder is the mock recorder for MockInspection.
+type MockInspectionMockRecorder struct {
+	mock *MockInspection
+}
+
+// NewMockInspection creates a new mock instance.
+func NewMockInspection(ctrl *gomock.Controller) *MockInspection {
+	mock := &MockInspection{ctrl: ctrl}
+	mock.recorder = &MockInspectionMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInspection) EXPECT() *MockInspectionMockRecorder {
+	return m.recorder
+}
+
+// Finish mocks base method.
+func (m *MockInspection) Finish(summary *analyser.Summary) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Finish", summary)
+}
+
+// Finish indicates an expected call of Finish.
+func (mr *MockInspectionMockRecorder) Finish(summary any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Finish", reflect.TypeOf((*MockInspection)(nil).Finish), summary)
+}
+
+// Visit mocks base method.
+func (m *MockInspection) Visit(n *html.Node) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Visit", n)
+}
+
+// Visit indicates an expected call of Visit.
+func (mr *MockInspectionMockRecorder) Visit(n any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Visit", reflect.TypeOf((*MockInspection)(nil).Visit), n)
+}
@@ -0,0 +1,55 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: api/backend/analyser/link_checker.go
+//
+// Generated by this command:
+//
+//	mockgen -source=api/backend/analyser/link_checker.go -destination=mocks/link_checker_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	analyser "web-analyser/api/backend/analyser"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockLinkChecker is a mock of LinkChecker interface.
+type MockLinkChecker struct {
+	ctrl     *gomock.Controller
+	recorder *MockLinkCheckerMockRecorder
+}
+
+// MockLinkCheckerMockRecorder is the mock recorder for MockLinkChecker.
+type MockLinkCheckerMockRecorder struct {
+	mock *MockLinkChecker
+}
+
+// NewMockLinkChecker creates a new mock instance.
+func NewMockLinkChecker(ctrl *gomock.Controller) *MockLinkChecker {
+	mock := &MockLinkChecker{ctrl: ctrl}
+	mock.recorder = &MockLinkCheckerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLinkChecker) EXPECT() *MockLinkCheckerMockRecorder {
+	return m.recorder
+}
+
+// Check mocks base method.
+func (m *MockLinkChecker) Check(ctx context.Context, links []string, progress func(int, int)) map[string]*analyser.LinkStatus {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check", ctx, links, progress)
+	ret0, _ := ret[0].(map[string]*analyser.LinkStatus)
+	return ret0
+}
+
+// Check indicates an expected call of Check.
+func (mr *MockLinkCheckerMockRecorder) Check(ctx, links, progress any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockLinkChecker)(nil).Check), ctx, links, progress)
+}
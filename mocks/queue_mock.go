@@ -0,0 +1,103 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: api/backend/job/queue.go
+//
+// Generated by this command:
+//
+//	mockgen -source=api/backend/job/queue.go -destination=mocks/queue_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	url "net/url"
+	reflect "reflect"
+	job "web-analyser/api/backend/job"
+	http "web-analyser/internal/utils/http"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockQueue is a mock of Queue interface.
+type MockQueue struct {
+	ctrl     *gomock.Controller
+	recorder *MockQueueMockRecorder
+}
+
+// MockQueueMockRecorder is the mock recorder for MockQueue.
+type MockQueueMockRecorder struct {
+	mock *MockQueue
+}
+
+// NewMockQueue creates a new mock instance.
+func NewMockQueue(ctrl *gomock.Controller) *MockQueue {
+	mock := &MockQueue{ctrl: ctrl}
+	mock.recorder = &MockQueueMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockQueue) EXPECT() *MockQueueMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *MockQueue) Get(id string) (*job.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", id)
+	ret0, _ := ret[0].(*job.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockQueueMockRecorder) Get(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockQueue)(nil).Get), id)
+}
+
+// Submit mocks base method.
+func (m *MockQueue) Submit(url *url.URL, requestID string, options *http.RequestOptions) (*job.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Submit", url, requestID, options)
+	ret0, _ := ret[0].(*job.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Submit indicates an expected call of Submit.
+func (mr *MockQueueMockRecorder) Submit(url, requestID, options any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Submit", reflect.TypeOf((*MockQueue)(nil).Submit), url, requestID, options)
+}
+
+// SubmitCrawl mocks base method.
+func (m *MockQueue) SubmitCrawl(url *url.URL, requestID string) (*job.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubmitCrawl", url, requestID)
+	ret0, _ := ret[0].(*job.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SubmitCrawl indicates an expected call of SubmitCrawl.
+func (mr *MockQueueMockRecorder) SubmitCrawl(url, requestID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubmitCrawl", reflect.TypeOf((*MockQueue)(nil).SubmitCrawl), url, requestID)
+}
+
+// Subscribe mocks base method.
+func (m *MockQueue) Subscribe(id string) (<-chan struct{}, func(), error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", id)
+	ret0, _ := ret[0].(<-chan struct{})
+	ret1, _ := ret[1].(func())
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockQueueMockRecorder) Subscribe(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockQueue)(nil).Subscribe), id)
+}
@@ -0,0 +1,69 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: api/backend/batch/runner.go
+//
+// Generated by this command:
+//
+//	mockgen -source=api/backend/batch/runner.go -destination=mocks/runner_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	batch "web-analyser/api/backend/batch"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRunner is a mock of Runner interface.
+type MockRunner struct {
+	ctrl     *gomock.Controller
+	recorder *MockRunnerMockRecorder
+}
+
+// MockRunnerMockRecorder is the mock recorder for MockRunner.
+type MockRunnerMockRecorder struct {
+	mock *MockRunner
+}
+
+// NewMockRunner creates a new mock instance.
+func NewMockRunner(ctrl *gomock.Controller) *MockRunner {
+	mock := &MockRunner{ctrl: ctrl}
+	mock.recorder = &MockRunnerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRunner) EXPECT() *MockRunnerMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *MockRunner) Get(id string) (*batch.Report, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", id)
+	ret0, _ := ret[0].(*batch.Report)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockRunnerMockRecorder) Get(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockRunner)(nil).Get), id)
+}
+
+// Submit mocks base method.
+func (m *MockRunner) Submit(urls []string, requestID string) *batch.Report {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Submit", urls, requestID)
+	ret0, _ := ret[0].(*batch.Report)
+	return ret0
+}
+
+// Submit indicates an expected call of Submit.
+func (mr *MockRunnerMockRecorder) Submit(urls, requestID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Submit", reflect.TypeOf((*MockRunner)(nil).Submit), urls, requestID)
+}
@@ -0,0 +1,64 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: api/backend/monitor/scheduler.go
+//
+// Generated by this command:
+//
+//	mockgen -source=api/backend/monitor/scheduler.go -destination=mocks/scheduler_mock.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	monitor "web-analyser/api/backend/monitor"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockScheduler is a mock of Scheduler interface.
+type MockScheduler struct {
+	ctrl     *gomock.Controller
+	recorder *MockSchedulerMockRecorder
+}
+
+// MockSchedulerMockRecorder is the mock recorder for MockScheduler.
+type MockSchedulerMockRecorder struct {
+	mock *MockScheduler
+}
+
+// NewMockScheduler creates a new mock instance.
+func NewMockScheduler(ctrl *gomock.Controller) *MockScheduler {
+	mock := &MockScheduler{ctrl: ctrl}
+	mock.recorder = &MockSchedulerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockScheduler) EXPECT() *MockSchedulerMockRecorder {
+	return m.recorder
+}
+
+// Schedule mocks base method.
+func (m *MockScheduler) Schedule(monitor *monitor.Monitor) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Schedule", monitor)
+}
+
+// Schedule indicates an expected call of Schedule.
+func (mr *MockSchedulerMockRecorder) Schedule(monitor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Schedule", reflect.TypeOf((*MockScheduler)(nil).Schedule), monitor)
+}
+
+// Unschedule mocks base method.
+func (m *MockScheduler) Unschedule(id string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Unschedule", id)
+}
+
+// Unschedule indicates an expected call of Unschedule.
+func (mr *MockSchedulerMockRecorder) Unschedule(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unschedule", reflect.TypeOf((*MockScheduler)(nil).Unschedule), id)
+}